@@ -2,10 +2,24 @@ package main
 
 import (
 	"github.com/sirupsen/logrus"
+	"os"
 	"slm-bot-publisher/config"
+	"slm-bot-publisher/internal/cli"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/core/pause"
+	"slm-bot-publisher/internal/core/publisher"
+	"slm-bot-publisher/internal/core/service/api"
 	"slm-bot-publisher/internal/core/service/discord"
+	"slm-bot-publisher/internal/core/service/feed"
+	"slm-bot-publisher/internal/core/service/mastodon"
+	"slm-bot-publisher/internal/core/service/matrix"
+	"slm-bot-publisher/internal/core/service/slack"
 	"slm-bot-publisher/internal/core/service/telegram"
+	"slm-bot-publisher/internal/core/service/twitch"
+	"slm-bot-publisher/internal/core/service/webhook"
 	"slm-bot-publisher/internal/lib/database"
+	"slm-bot-publisher/internal/lib/media"
+	"slm-bot-publisher/internal/lib/server"
 	"slm-bot-publisher/internal/lib/storage"
 	"slm-bot-publisher/logging"
 	"time"
@@ -15,13 +29,57 @@ func main() {
 	logger := logging.SetupLogger()
 	logger.Info("slm-bot-publisher by JSOL Team")
 
-	configData := config.LoadConfig()
-	storageData := storage.NewStorage(configData.StreamerData)
+	// Подкоманды обслуживания запускаются вместо бота
+	if len(os.Args) > 1 {
+		cli.Run(os.Args[1:])
+		return
+	}
 
+	configData := config.LoadConfig()
 	dbHandlers := database.InitDB(configData.DatabasePath)
+	storageData := storage.NewStorage(dbHandlers.StreamerHandlers, configData.Keeper(), configData.StreamerData)
+
+	httpServer := server.NewServer(configData.HTTPAddr)
+	mediaStore := media.NewStore(configData.MediaDir, configData.PublicURL)
+	discordBot := discord.NewDiscordBot(storageData, configData.TelegramToken, dbHandlers, mediaStore)
+	if httpServer.Enabled() {
+		httpServer.Handle("/media/", mediaStore)
+		feed.NewHandler(storageData, dbHandlers, configData.PublicURL).Register(httpServer)
+	}
+
+	if configData.Twitch.ClientID != "" {
+		twitchService := twitch.NewService(configData.Twitch, storageData, discordBot, dbHandlers)
+		if httpServer.Enabled() && twitchService.WebhooksConfigured() {
+			httpServer.Handle("/twitch/eventsub", twitchService)
+		}
+		twitchService.Start(httpServer.Enabled())
+	}
+
+	httpServer.Start()
+
+	publishers := publisher.NewRegistry()
+	publishers.SetHolder(pause.NewQueue(dbHandlers))
+	publishers.Register(model.DestinationDiscord, discordBot)
+	publishers.Register(model.DestinationMatrix, matrix.NewPublisher(dbHandlers))
+	publishers.Register(model.DestinationMastodon, mastodon.NewPublisher(dbHandlers))
+	publishers.Register(model.DestinationSlack, slack.NewPublisher(model.DestinationSlack, mediaStore))
+	publishers.Register(model.DestinationMattermost, slack.NewPublisher(model.DestinationMattermost, mediaStore))
+	publishers.Register(model.DestinationWebhook, webhook.NewPublisher(mediaStore))
+	publishers.Register(model.DestinationFeed, feed.NewPublisher(dbHandlers, mediaStore))
+
+	pauseController := pause.NewController(storageData, publishers, dbHandlers)
+	telegramBot := telegram.NewTelegramBot(configData, storageData, publishers, pauseController, discordBot, discordBot, 10*time.Second, 3*time.Second, time.Hour, dbHandlers)
+	discordBot.StartListeners(storageData, telegramBot)
+	discordBot.StartScheduler(storageData, 30*time.Second)
 
-	discordBot := discord.NewDiscordBot(storageData, configData.TelegramToken, dbHandlers)
-	telegramBot := telegram.NewTelegramBot(configData, storageData, discordBot, 10*time.Second, 3*time.Second, dbHandlers)
+	var viaChatID int64
+	if len(configData.AdminIDs) > 0 {
+		viaChatID = configData.AdminIDs[0]
+	}
+	apiHandler := api.NewHandler(storageData, dbHandlers, pauseController, discordBot, telegramBot, configData.APITokens, viaChatID)
+	if httpServer.Enabled() && apiHandler.Enabled() {
+		apiHandler.Register(httpServer)
+	}
 
 	telegramBot.ListenUpdates()
 
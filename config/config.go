@@ -1,16 +1,42 @@
 package config
 
 import (
+	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 	"os"
+	"slm-bot-publisher/internal/lib/secrets"
 	"slm-bot-publisher/logging"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	TelegramToken string
 	StreamerData  string
 	DatabasePath  string
+	HTTPAddr      string
+	PublicURL     string
+	MediaDir      string
+	// AdminIDs - пользователи Telegram, которым доступна админка в личных сообщениях
+	AdminIDs []int64
+	// APITokens - bearer-токены HTTP API администрирования; без токенов API выключен
+	APITokens []string
+	// SecretKey и SecretKeyFile - мастер-ключ шифрования токенов ботов в base64 или файл с ним
+	SecretKey     string
+	SecretKeyFile string
+	Twitch        TwitchConfig
+}
+
+type TwitchConfig struct {
+	ClientID       string
+	ClientSecret   string
+	EventSubSecret string
+	CallbackURL    string
+	APIURL         string
+	AuthURL        string
+	PollInterval   time.Duration
 }
 
 func LoadConfig() *Config {
@@ -23,7 +49,88 @@ func LoadConfig() *Config {
 		TelegramToken: os.Getenv("TELEGRAM_TOKEN"),
 		StreamerData:  os.Getenv("STREAMER_DATA_FILE"),
 		DatabasePath:  os.Getenv("DATABASE_PATH"),
+		HTTPAddr:      os.Getenv("HTTP_ADDR"),
+		PublicURL:     os.Getenv("PUBLIC_URL"),
+		MediaDir:      getEnvDefault("MEDIA_DIR", "media"),
+		AdminIDs:      getEnvIDs("ADMIN_IDS"),
+		APITokens:     getEnvList("API_TOKENS"),
+		SecretKey:     os.Getenv("SECRET_KEY"),
+		SecretKeyFile: os.Getenv("SECRET_KEY_FILE"),
+		Twitch: TwitchConfig{
+			ClientID:       os.Getenv("TWITCH_CLIENT_ID"),
+			ClientSecret:   os.Getenv("TWITCH_CLIENT_SECRET"),
+			EventSubSecret: os.Getenv("TWITCH_EVENTSUB_SECRET"),
+			CallbackURL:    os.Getenv("TWITCH_CALLBACK_URL"),
+			APIURL:         getEnvDefault("TWITCH_API_URL", "https://api.twitch.tv/helix"),
+			AuthURL:        getEnvDefault("TWITCH_AUTH_URL", "https://id.twitch.tv/oauth2/token"),
+			PollInterval:   getEnvDuration("TWITCH_POLL_INTERVAL", time.Minute),
+		},
+	}
+
+	// Секреты из окружения скрываются во всех сообщениях лога
+	for _, secret := range append([]string{config.TelegramToken, config.SecretKey, config.Twitch.ClientSecret, config.Twitch.EventSubSecret}, config.APITokens...) {
+		logging.RegisterSecret(secret)
 	}
 
 	return config
 }
+
+// Keeper - возвращает шифрование секретов с мастер-ключом из SECRET_KEY или SECRET_KEY_FILE
+func (c *Config) Keeper() *secrets.Keeper {
+	keeper, err := secrets.NewKeeper(c.SecretKey, c.SecretKeyFile)
+	if err != nil {
+		logging.Log("Система", logrus.PanicLevel, fmt.Sprintf("Ошибка загрузки мастер-ключа: %v", err))
+	}
+	return keeper
+}
+
+func getEnvDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		logging.Log("Система", logrus.WarnLevel, "Некорректное значение "+key+", используется значение по умолчанию")
+		return defaultValue
+	}
+	return duration
+}
+
+// getEnvIDs - разбирает список ID через запятую, пропуская некорректные значения
+func getEnvIDs(key string) []int64 {
+	var ids []int64
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			logging.Log("Система", logrus.WarnLevel, "Некорректный ID в "+key+": "+value)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// getEnvList - разбирает список значений через запятую, пропуская пустые
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
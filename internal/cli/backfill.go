@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/config"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/core/pause"
+	"slm-bot-publisher/internal/core/publisher"
+	"slm-bot-publisher/internal/core/service/discord"
+	"slm-bot-publisher/internal/core/service/telegram"
+	"slm-bot-publisher/internal/lib/database"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	"slm-bot-publisher/internal/lib/media"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+	"time"
+)
+
+// Backfill - переносит старые посты канала в Discord: по диапазону ID через пересылку в служебный чат
+// или из экспорта Telegram Desktop. Посты, у которых уже есть копии, пропускаются
+func Backfill(args []string) error {
+	flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	streamerName := flags.String("streamer", "", "имя стримера")
+	fromID := flags.Int("from", 0, "ID первого переносимого сообщения")
+	toID := flags.Int("to", 0, "ID последнего переносимого сообщения")
+	exportPath := flags.String("export", "", "путь к result.json экспорта Telegram Desktop")
+	viaChatID := flags.Int64("via", 0, "чат, через который пересылаются сообщения при переносе по ID")
+	interval := flags.Duration("interval", 3*time.Second, "пауза между постами")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *streamerName == "" {
+		return fmt.Errorf("не указан -streamer")
+	}
+	if *exportPath == "" && (*fromID <= 0 || *toID < *fromID || *viaChatID == 0) {
+		return fmt.Errorf("укажите -export или диапазон -from, -to вместе с -via")
+	}
+
+	configData := config.LoadConfig()
+	dbHandlers := database.InitDB(configData.DatabasePath)
+	storageData := storage.NewStorage(dbHandlers.StreamerHandlers, configData.Keeper(), configData.StreamerData)
+	streamer := storageData.GetStreamerByName(*streamerName)
+	if streamer == nil {
+		return fmt.Errorf("стример %s не найден", *streamerName)
+	}
+
+	backfill, err := telegram.NewBackfill(configData.TelegramToken, storageData, discordPublishers(configData, storageData, dbHandlers), dbHandlers, *interval)
+	if err != nil {
+		return err
+	}
+
+	var updates []tgbotapi.Update
+	if *exportPath != "" {
+		updates, err = backfill.LoadExport(streamer, *exportPath)
+	} else {
+		updates, err = backfill.FetchRange(streamer, *fromID, *toID, *viaChatID)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Диапазон ID ограничивает и экспорт, если задан
+	if *exportPath != "" && (*fromID > 0 || *toID > 0) {
+		filtered := updates[:0]
+		for _, update := range updates {
+			if update.ChannelPost.MessageID >= *fromID && (*toID == 0 || update.ChannelPost.MessageID <= *toID) {
+				filtered = append(filtered, update)
+			}
+		}
+		updates = filtered
+	}
+
+	logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Найдено сообщений для переноса: %d", len(updates)))
+	published, skipped := backfill.Run(streamer, updates)
+	logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Перенос завершен: опубликовано постов %d, пропущено уже перенесенных %d", published, skipped))
+	return nil
+}
+
+// discordPublishers - реестр только с Discord: перенос истории не касается остальных площадок
+func discordPublishers(configData *config.Config, storageData *storage.Storage, dbHandlers *handlers.DBHandlers) *publisher.Registry {
+	mediaStore := media.NewStore(configData.MediaDir, configData.PublicURL)
+
+	publishers := publisher.NewRegistry()
+	publishers.SetHolder(pause.NewQueue(dbHandlers))
+	publishers.Register(model.DestinationDiscord, discord.NewDiscordBot(storageData, configData.TelegramToken, dbHandlers, mediaStore))
+	return publishers
+}
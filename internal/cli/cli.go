@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+)
+
+// commands - подкоманды, которые запускаются вместо бота: slm-bot-publisher <команда> [флаги]
+var commands = map[string]func(args []string) error{
+	"backfill": Backfill,
+	"resync":   Resync,
+	"import":   Import,
+	"export":   Export,
+	"encrypt":  Encrypt,
+	"keygen":   Keygen,
+}
+
+// Run - выполняет подкоманду и завершает процесс с кодом ошибки, если она не удалась
+func Run(args []string) {
+	command, exists := commands[args[0]]
+	if !exists {
+		fmt.Fprintf(os.Stderr, "Неизвестная команда %s. Доступные команды: backfill, resync, import, export, encrypt, keygen\n", args[0])
+		os.Exit(2)
+	}
+
+	if err := command(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+}
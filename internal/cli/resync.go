@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/config"
+	"slm-bot-publisher/internal/core/service/telegram"
+	"slm-bot-publisher/internal/lib/database"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+	"time"
+)
+
+// Resync - заново публикует в один канал Discord посты стримера, копии которых записаны в базе.
+// Нужна после пересоздания канала или добавления нового канала стримеру
+func Resync(args []string) error {
+	flags := flag.NewFlagSet("resync", flag.ExitOnError)
+	streamerName := flags.String("streamer", "", "имя стримера")
+	channelID := flags.String("channel", "", "ID канала Discord, в который публикуются посты")
+	last := flags.Int("last", 0, "сколько последних постов опубликовать")
+	since := flags.String("since", "", "публиковать посты начиная с даты в формате 2006-01-02")
+	viaChatID := flags.Int64("via", 0, "чат, через который пересылаются сообщения")
+	interval := flags.Duration("interval", 3*time.Second, "пауза между постами")
+	force := flags.Bool("force", false, "публиковать и посты, у которых в канале уже есть копия")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *streamerName == "" || *channelID == "" || *viaChatID == 0 {
+		return fmt.Errorf("укажите -streamer, -channel и -via")
+	}
+	if *last <= 0 && *since == "" {
+		return fmt.Errorf("укажите -last или -since")
+	}
+
+	options := telegram.ResyncOptions{ChannelID: *channelID, Last: *last, ViaChatID: *viaChatID, Force: *force}
+	if *since != "" {
+		sinceTime, err := time.ParseInLocation("2006-01-02", *since, time.Local)
+		if err != nil {
+			return fmt.Errorf("некорректная дата -since: %v", err)
+		}
+		options.Since = sinceTime
+	}
+
+	configData := config.LoadConfig()
+	dbHandlers := database.InitDB(configData.DatabasePath)
+	storageData := storage.NewStorage(dbHandlers.StreamerHandlers, configData.Keeper(), configData.StreamerData)
+	streamer := storageData.GetStreamerByName(*streamerName)
+	if streamer == nil {
+		return fmt.Errorf("стример %s не найден", *streamerName)
+	}
+
+	backfill, err := telegram.NewBackfill(configData.TelegramToken, storageData, discordPublishers(configData, storageData, dbHandlers), dbHandlers, *interval)
+	if err != nil {
+		return err
+	}
+
+	published, err := backfill.Resync(streamer, options)
+	if err != nil {
+		return err
+	}
+	logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Повторная публикация в канал %s завершена: опубликовано постов %d", *channelID, published))
+	return nil
+}
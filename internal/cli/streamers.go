@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"slm-bot-publisher/config"
+	"slm-bot-publisher/internal/lib/database"
+	"slm-bot-publisher/internal/lib/secrets"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+)
+
+// Import - загружает стримеров из JSON в базу. Стримеры с уже известными именами заменяются
+func Import(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	path := flags.String("file", "", "JSON со стримерами в формате экспорта")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return fmt.Errorf("не указан -file")
+	}
+
+	streamers, err := storage.ReadFile(*path)
+	if err != nil {
+		return err
+	}
+
+	storageData := openStorage()
+	added, replaced, err := storageData.Import(streamers)
+	if err != nil {
+		return err
+	}
+	logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Импорт завершен: добавлено стримеров %d, заменено %d", added, replaced))
+	return nil
+}
+
+// Export - выгружает стримеров из базы в JSON. Файл обязателен: в консоль пишет лог
+func Export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	path := flags.String("file", "", "куда сохранить JSON со стримерами")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return fmt.Errorf("не указан -file")
+	}
+
+	data, err := openStorage().Export()
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(*path, data, 0600); err != nil {
+		return err
+	}
+	logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Стримеры сохранены в %s", *path))
+	return nil
+}
+
+// openStorage - открывает стримеров из базы без переноса из STREAMER_DATA_FILE,
+// чтобы импорт и экспорт работали только с тем, что указано явно
+func openStorage() *storage.Storage {
+	configData := config.LoadConfig()
+	dbHandlers := database.InitDB(configData.DatabasePath)
+	return storage.NewStorage(dbHandlers.StreamerHandlers, configData.Keeper(), "")
+}
+
+// Encrypt - шифрует открытые токены ботов в JSON-файле стримеров мастер-ключом из SECRET_KEY или SECRET_KEY_FILE.
+// Ссылки env: и file: и уже зашифрованные токены не меняются
+func Encrypt(args []string) error {
+	flags := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	path := flags.String("file", "", "JSON со стримерами, который нужно зашифровать")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return fmt.Errorf("не указан -file")
+	}
+
+	sealed, err := storage.SealFile(*path, config.LoadConfig().Keeper())
+	if err != nil {
+		return err
+	}
+	logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Зашифровано токенов в %s: %d", *path, sealed))
+	return nil
+}
+
+// Keygen - создает файл с новым мастер-ключом для SECRET_KEY_FILE. Существующий файл не перезаписывается
+func Keygen(args []string) error {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	path := flags.String("file", "", "куда сохранить мастер-ключ")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return fmt.Errorf("не указан -file")
+	}
+
+	key, err := secrets.GenerateKey()
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(*path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err = file.WriteString(key + "\n"); err != nil {
+		return err
+	}
+	logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Мастер-ключ сохранен в %s", *path))
+	return nil
+}
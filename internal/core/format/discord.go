@@ -0,0 +1,262 @@
+package format
+
+import (
+	"slm-bot-publisher/internal/core/model"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Парные маркеры разметки Discord; более длинные маркеры проверяются раньше коротких
+var discordMarkers = []struct {
+	token      string
+	entityType string
+}{
+	{"**", "bold"},
+	{"__", "underline"},
+	{"~~", "strikethrough"},
+	{"||", "spoiler"},
+	{"*", "italic"},
+	{"_", "italic"},
+}
+
+// ParseDiscordMarkdown - разбирает разметку Discord и возвращает чистый текст с сущностями Telegram.
+// Смещения сущностей считаются в рунах, как и в остальных постах
+func ParseDiscordMarkdown(message string) (string, []model.TextEntity) {
+	parser := &discordParser{
+		input:       []rune(message),
+		openMarkers: make(map[string]int),
+		quoteStart:  -1,
+		lineStart:   -1,
+	}
+	parser.parse()
+
+	sort.SliceStable(parser.entities, func(i, j int) bool {
+		return parser.entities[i].Offset < parser.entities[j].Offset
+	})
+	return string(parser.output), parser.entities
+}
+
+type discordParser struct {
+	input    []rune
+	output   []rune
+	entities []model.TextEntity
+	// Позиция в выводе, где открыт маркер
+	openMarkers map[string]int
+	// Начало текущей цитаты и признак цитаты ">>>", которая длится до конца сообщения
+	quoteStart int
+	quoteAll   bool
+	// Начало заголовка и тип сущности, которой он выделяется
+	lineStart  int
+	lineEntity string
+}
+
+func (p *discordParser) parse() {
+	for i := 0; i < len(p.input); {
+		if i == 0 || p.input[i-1] == '\n' {
+			i = p.parseLineStart(i)
+			if i >= len(p.input) {
+				break
+			}
+		}
+
+		switch {
+		case p.input[i] == '\\' && i+1 < len(p.input) && isDiscordPunct(p.input[i+1]):
+			p.output = append(p.output, p.input[i+1])
+			i += 2
+		case p.input[i] == '\n':
+			p.closeLine()
+			p.output = append(p.output, '\n')
+			i++
+		case p.hasPrefix(i, "```"):
+			i = p.parseCodeBlock(i)
+		case p.input[i] == '`':
+			i = p.parseInlineCode(i)
+		case p.input[i] == '[':
+			i = p.parseLink(i)
+		default:
+			i = p.parseMarker(i)
+		}
+	}
+
+	p.closeLine()
+	p.closeQuote(len(p.output))
+}
+
+// parseLineStart - обрабатывает разметку начала строки: цитаты и заголовки
+func (p *discordParser) parseLineStart(i int) int {
+	quoted := p.quoteAll
+	switch {
+	case p.hasPrefix(i, ">>> "):
+		p.quoteAll, quoted = true, true
+		i += 4
+	case p.hasPrefix(i, "> "):
+		quoted = true
+		i += 2
+	}
+
+	if quoted && p.quoteStart < 0 {
+		p.quoteStart = len(p.output)
+	}
+	if !quoted {
+		// Цитата заканчивается перед переводом строки, которым завершилась последняя строка цитаты
+		p.closeQuote(len(p.output) - 1)
+	}
+
+	for _, heading := range []struct {
+		prefix     string
+		entityType string
+	}{{"### ", "bold"}, {"## ", "bold"}, {"# ", "bold"}, {"-# ", "italic"}} {
+		if p.hasPrefix(i, heading.prefix) {
+			p.lineStart = len(p.output)
+			p.lineEntity = heading.entityType
+			return i + len([]rune(heading.prefix))
+		}
+	}
+
+	return i
+}
+
+func (p *discordParser) parseCodeBlock(i int) int {
+	start := i + 3
+	end := p.find(start, "```")
+	if end < 0 {
+		p.output = append(p.output, p.input[i:start]...)
+		return start
+	}
+
+	content := p.input[start:end]
+	language := ""
+	if newline := indexRune(content, '\n'); newline >= 0 {
+		firstLine := string(content[:newline])
+		if firstLine != "" && !strings.ContainsAny(firstLine, " \t") {
+			language = firstLine
+			content = content[newline+1:]
+		} else if firstLine == "" {
+			content = content[1:]
+		}
+	}
+
+	if len(content) > 0 && content[len(content)-1] == '\n' {
+		content = content[:len(content)-1]
+	}
+
+	p.addEntity(model.TextEntity{Type: "pre", Language: language}, len(p.output), len(p.output)+len(content))
+	p.output = append(p.output, content...)
+	return end + 3
+}
+
+func (p *discordParser) parseInlineCode(i int) int {
+	end := p.find(i+1, "`")
+	if end < 0 {
+		p.output = append(p.output, '`')
+		return i + 1
+	}
+
+	p.addEntity(model.TextEntity{Type: "code"}, len(p.output), len(p.output)+end-i-1)
+	p.output = append(p.output, p.input[i+1:end]...)
+	return end + 1
+}
+
+// parseLink - разбирает ссылку вида [текст](адрес)
+func (p *discordParser) parseLink(i int) int {
+	labelEnd := p.find(i+1, "](")
+	if labelEnd < 0 || indexRune(p.input[i+1:labelEnd], '\n') >= 0 {
+		p.output = append(p.output, '[')
+		return i + 1
+	}
+	urlEnd := p.find(labelEnd+2, ")")
+	if urlEnd < 0 {
+		p.output = append(p.output, '[')
+		return i + 1
+	}
+
+	url := strings.Trim(string(p.input[labelEnd+2:urlEnd]), "<>")
+	label := p.input[i+1 : labelEnd]
+	p.addEntity(model.TextEntity{Type: "text_link", URL: url}, len(p.output), len(p.output)+len(label))
+	p.output = append(p.output, label...)
+	return urlEnd + 1
+}
+
+// parseMarker - открывает или закрывает парный маркер; маркер без пары остается в тексте
+func (p *discordParser) parseMarker(i int) int {
+	for _, marker := range discordMarkers {
+		if !p.hasPrefix(i, marker.token) {
+			continue
+		}
+		length := len(marker.token)
+
+		if start, open := p.openMarkers[marker.token]; open {
+			delete(p.openMarkers, marker.token)
+			p.addEntity(model.TextEntity{Type: marker.entityType}, start, len(p.output))
+			return i + length
+		}
+
+		// Подчеркивание внутри слова, как в snake_case, не считается разметкой
+		if marker.token == "_" && i > 0 && isWordRune(p.input[i-1]) {
+			break
+		}
+		if p.find(i+length, marker.token) > i+length {
+			p.openMarkers[marker.token] = len(p.output)
+			return i + length
+		}
+		break
+	}
+
+	p.output = append(p.output, p.input[i])
+	return i + 1
+}
+
+func (p *discordParser) closeLine() {
+	if p.lineStart >= 0 {
+		p.addEntity(model.TextEntity{Type: p.lineEntity}, p.lineStart, len(p.output))
+		p.lineStart = -1
+	}
+}
+
+func (p *discordParser) closeQuote(end int) {
+	if p.quoteStart >= 0 {
+		p.addEntity(model.TextEntity{Type: "blockquote"}, p.quoteStart, end)
+		p.quoteStart = -1
+	}
+}
+
+func (p *discordParser) addEntity(entity model.TextEntity, start, end int) {
+	if end <= start {
+		return
+	}
+	entity.Offset = start
+	entity.Length = end - start
+	p.entities = append(p.entities, entity)
+}
+
+func (p *discordParser) hasPrefix(i int, prefix string) bool {
+	return strings.HasPrefix(string(p.input[i:min(i+len(prefix), len(p.input))]), prefix)
+}
+
+// find - ищет подстроку начиная с позиции from и возвращает ее индекс в рунах
+func (p *discordParser) find(from int, token string) int {
+	for i := from; i < len(p.input); i++ {
+		if p.hasPrefix(i, token) {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexRune(runes []rune, r rune) int {
+	for idx, current := range runes {
+		if current == r {
+			return idx
+		}
+	}
+	return -1
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isDiscordPunct(r rune) bool {
+	return strings.ContainsRune("\\*_~|`>#-[]()", r)
+}
@@ -0,0 +1,95 @@
+package format
+
+import (
+	"html"
+	"net/url"
+	"slm-bot-publisher/internal/core/model"
+	"strings"
+)
+
+// HTML - преобразует текст с форматированием Telegram в HTML с экранированием остального текста
+func HTML(message string, entities []model.TextEntity) string {
+	runes := []rune(message)
+	n := len(runes)
+
+	openTags := make([]string, n+1)
+	closeTags := make([]string, n+1)
+	// Внутри блоков кода переносы строк остаются как есть
+	preformatted := make([]bool, n)
+
+	for _, entity := range entities {
+		entityStart := entity.Offset
+		entityEnd := entity.Offset + entity.Length
+
+		if entityStart < 0 || entityEnd > n {
+			continue
+		}
+
+		var openTag, closeTag string
+		switch entity.Type {
+		case "bold":
+			openTag, closeTag = "<strong>", "</strong>"
+		case "italic":
+			openTag, closeTag = "<em>", "</em>"
+		case "underline":
+			openTag, closeTag = "<u>", "</u>"
+		case "strikethrough":
+			openTag, closeTag = "<del>", "</del>"
+		case "spoiler":
+			openTag, closeTag = "<span data-mx-spoiler>", "</span>"
+		case "code":
+			openTag, closeTag = "<code>", "</code>"
+		case "pre":
+			if entity.Language != "" {
+				openTag = `<pre><code class="language-` + html.EscapeString(entity.Language) + `">`
+			} else {
+				openTag = "<pre><code>"
+			}
+			closeTag = "</code></pre>"
+			for i := entityStart; i < entityEnd; i++ {
+				preformatted[i] = true
+			}
+		case "blockquote":
+			openTag, closeTag = "<blockquote>", "</blockquote>"
+		case "url":
+			link := html.EscapeString(string(runes[entityStart:entityEnd]))
+			openTag, closeTag = `<a href="`+link+`">`, "</a>"
+		case "text_link":
+			// Ссылки со схемами вроде javascript: и data: остаются обычным текстом
+			if !isWebLink(entity.URL) {
+				continue
+			}
+			openTag, closeTag = `<a href="`+html.EscapeString(entity.URL)+`">`, "</a>"
+		case "text_mention":
+			openTag, closeTag = `<a href="https://t.me/`+html.EscapeString(entity.UserName)+`">`, "</a>"
+		default:
+			continue
+		}
+
+		openTags[entityStart] += openTag
+		closeTags[entityEnd] = closeTag + closeTags[entityEnd]
+	}
+
+	var formattedText strings.Builder
+	for i := 0; i < n; i++ {
+		formattedText.WriteString(openTags[i])
+		if runes[i] == '\n' && !preformatted[i] {
+			formattedText.WriteString("<br>")
+		} else {
+			formattedText.WriteString(html.EscapeString(string(runes[i])))
+		}
+		formattedText.WriteString(closeTags[i+1])
+	}
+
+	return formattedText.String()
+}
+
+// isWebLink - проверяет, что ссылка ведет на http или https
+func isWebLink(link string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	return scheme == "http" || scheme == "https"
+}
@@ -1,11 +1,12 @@
-package discord
+package format
 
 import (
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"slm-bot-publisher/internal/core/model"
 	"strings"
 )
 
-func FormatTelegramMessageToDiscord(message string, entities []tgbotapi.MessageEntity) string {
+// Markdown - преобразует текст с форматированием Telegram в разметку Markdown, понятную Discord
+func Markdown(message string, entities []model.TextEntity) string {
 	if message == "" || entities == nil {
 		return message
 	}
@@ -50,8 +51,8 @@ func FormatTelegramMessageToDiscord(message string, entities []tgbotapi.MessageE
 			openTags[entityStart] += "["
 			closeTags[entityEnd] = "](" + entity.URL + ")" + closeTags[entityEnd]
 		case "text_mention":
-			openTags[entityStart] += "[@" + entity.User.FirstName
-			closeTags[entityEnd] = "](https://t.me/" + entity.User.UserName + ")" + closeTags[entityEnd]
+			openTags[entityStart] += "[@" + entity.FirstName
+			closeTags[entityEnd] = "](https://t.me/" + entity.UserName + ")" + closeTags[entityEnd]
 		}
 	}
 
@@ -64,3 +65,14 @@ func FormatTelegramMessageToDiscord(message string, entities []tgbotapi.MessageE
 
 	return formattedText.String()
 }
+
+// discordEscaper - экранирует символы, которые Discord считает разметкой
+var discordEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`,
+	">", `\>`, "#", `\#`, "-", `\-`, "[", `\[`, "]", `\]`, "<", `\<`,
+)
+
+// EscapeMarkdown - экранирует обычный текст, чтобы Discord показал его как есть
+func EscapeMarkdown(text string) string {
+	return discordEscaper.Replace(text)
+}
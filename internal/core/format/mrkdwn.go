@@ -0,0 +1,62 @@
+package format
+
+import (
+	"slm-bot-publisher/internal/core/model"
+	"strings"
+)
+
+// Mrkdwn - преобразует текст с форматированием Telegram в разметку mrkdwn для Slack
+func Mrkdwn(message string, entities []model.TextEntity) string {
+	runes := []rune(message)
+	n := len(runes)
+
+	openTags := make([]string, n+1)
+	closeTags := make([]string, n+1)
+
+	for _, entity := range entities {
+		entityStart := entity.Offset
+		entityEnd := entity.Offset + entity.Length
+
+		if entityStart < 0 || entityEnd > n {
+			continue
+		}
+
+		switch entity.Type {
+		case "bold":
+			openTags[entityStart] += "*"
+			closeTags[entityEnd] = "*" + closeTags[entityEnd]
+		case "italic":
+			openTags[entityStart] += "_"
+			closeTags[entityEnd] = "_" + closeTags[entityEnd]
+		case "strikethrough":
+			openTags[entityStart] += "~"
+			closeTags[entityEnd] = "~" + closeTags[entityEnd]
+		case "code":
+			openTags[entityStart] += "`"
+			closeTags[entityEnd] = "`" + closeTags[entityEnd]
+		case "pre":
+			openTags[entityStart] += "```"
+			closeTags[entityEnd] = "```" + closeTags[entityEnd]
+		case "text_link":
+			openTags[entityStart] += "<" + escapeMrkdwn(entity.URL) + "|"
+			closeTags[entityEnd] = ">" + closeTags[entityEnd]
+		case "text_mention":
+			openTags[entityStart] += "<https://t.me/" + escapeMrkdwn(entity.UserName) + "|"
+			closeTags[entityEnd] = ">" + closeTags[entityEnd]
+		}
+	}
+
+	var formattedText strings.Builder
+	for i := 0; i < n; i++ {
+		formattedText.WriteString(openTags[i])
+		formattedText.WriteString(escapeMrkdwn(string(runes[i])))
+		formattedText.WriteString(closeTags[i+1])
+	}
+
+	return formattedText.String()
+}
+
+// escapeMrkdwn - экранирует управляющие символы Slack
+func escapeMrkdwn(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
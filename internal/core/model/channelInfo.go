@@ -0,0 +1,7 @@
+package model
+
+type ChannelInfo struct {
+	Title    string
+	UserName string
+	Avatar   []byte
+}
@@ -0,0 +1,19 @@
+package model
+
+// Comment - комментарий к посту, переносимый между группой обсуждения Telegram и веткой Discord
+type Comment struct {
+	// Пост канала, к которому написан комментарий
+	TelegramChatID int64
+	TelegramMsgID  int
+	// Сам комментарий в группе обсуждения
+	DiscussionChatID int64
+	DiscussionMsgID  int
+	AuthorName       string
+	AuthorAvatar     []byte
+	Text             string
+	Entities         []TextEntity
+	Attachments      []Attachment
+	// Автор и текст комментария, на который дан ответ; пустые, если ответ на сам пост
+	ReplyToAuthor string
+	ReplyToText   string
+}
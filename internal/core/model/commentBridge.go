@@ -0,0 +1,6 @@
+package model
+
+// CommentBridge - настройки переноса комментариев между группой обсуждения канала и ветками Discord
+type CommentBridge struct {
+	DiscussionChatID int64
+}
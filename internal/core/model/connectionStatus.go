@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// ConnectionStatus - состояние подключения стримера к Discord по последним запросам
+type ConnectionStatus struct {
+	// Listening - открыта постоянная сессия обратного моста или комментариев
+	Listening   bool
+	LastSuccess time.Time
+	LastError   string
+	LastErrorAt time.Time
+}
@@ -0,0 +1,19 @@
+package model
+
+import "encoding/json"
+
+const (
+	DestinationDiscord    = "discord"
+	DestinationMatrix     = "matrix"
+	DestinationMastodon   = "mastodon"
+	DestinationSlack      = "slack"
+	DestinationMattermost = "mattermost"
+	DestinationWebhook    = "webhook"
+	DestinationFeed       = "feed"
+)
+
+// Destination - площадка, на которую зеркалируются посты стримера; настройки разбирает сама площадка
+type Destination struct {
+	Type     string
+	Settings json.RawMessage
+}
@@ -1,6 +1,25 @@
 package model
 
+import "time"
+
 type DiscordChannel struct {
 	ChannelID string
 	Prefix    string
+	// Правила проверяются по порядку, решение принимает первое сработавшее
+	Rules []RouteRule
+	// Преобразования текста, выполняемые после преобразований стримера
+	Rewrite []RewriteRule
+	// Paused - новые посты в канал не отправляются; что с ними делать, решает PauseMode стримера
+	Paused bool
+	// Delay - задержка публикации в канал в формате time.ParseDuration, например "15m"
+	Delay string
+}
+
+// PublishDelay - возвращает задержку публикации; некорректное значение означает отсутствие задержки
+func (c DiscordChannel) PublishDelay() time.Duration {
+	delay, err := time.ParseDuration(c.Delay)
+	if err != nil || delay < 0 {
+		return 0
+	}
+	return delay
 }
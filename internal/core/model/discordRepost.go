@@ -1,9 +0,0 @@
-package model
-
-type DiscordRepost struct {
-	ChannelName    string
-	ChannelAvatar  string
-	MessageContent string
-	PhotoLink      string
-	RepostLink     string
-}
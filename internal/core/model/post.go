@@ -0,0 +1,51 @@
+package model
+
+const (
+	AttachmentPhoto     = "photo"
+	AttachmentVideo     = "video"
+	AttachmentVideoNote = "video_note"
+	AttachmentAudio     = "audio"
+	AttachmentVoice     = "voice"
+	AttachmentDocument  = "document"
+	AttachmentSticker   = "sticker"
+)
+
+// Post - пост из Telegram в независимом от площадки виде
+type Post struct {
+	ChatID       int64
+	ChatUserName string
+	MessageID    int
+	Parts        []PostPart
+	Text         string
+	Entities     []TextEntity
+	Attachments  []Attachment
+	Link         string
+	Repost       *RepostOrigin
+	// Silent - опубликовать без префикса с упоминанием роли
+	Silent bool
+}
+
+// PostPart - отдельное сообщение Telegram, из которых состоит пост (несколько для альбомов)
+type PostPart struct {
+	MessageID    int
+	AttachmentID string
+}
+
+// TextEntity - форматирование участка текста; смещения считаются в символах (рунах), а не в UTF-16
+type TextEntity struct {
+	Type      string
+	Offset    int
+	Length    int
+	URL       string
+	UserName  string
+	FirstName string
+	Language  string
+}
+
+type Attachment struct {
+	Kind      string
+	Name      string
+	FileID    string
+	MessageID int
+	Data      []byte
+}
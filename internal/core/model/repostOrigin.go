@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+const (
+	RepostOriginChannel    = "channel"
+	RepostOriginChat       = "chat"
+	RepostOriginUser       = "user"
+	RepostOriginHiddenUser = "hidden_user"
+)
+
+type RepostOrigin struct {
+	Type         string
+	AuthorName   string
+	AuthorAvatar []byte
+	AuthorLink   string
+	Link         string
+	Date         time.Time
+}
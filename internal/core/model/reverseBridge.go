@@ -0,0 +1,6 @@
+package model
+
+// ReverseBridge - настройки обратного моста: сообщения из канала Discord публикуются в канал Telegram стримера
+type ReverseBridge struct {
+	DiscordChannelID string
+}
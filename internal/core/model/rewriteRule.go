@@ -0,0 +1,21 @@
+package model
+
+const (
+	RewriteReplace       = "replace"
+	RewriteStripHashtags = "strip_hashtags"
+	RewriteRemoveLines   = "remove_lines"
+	RewriteLinks         = "rewrite_links"
+	RewriteAppend        = "append"
+)
+
+// RewriteRule - шаг преобразования текста поста перед публикацией в Discord
+type RewriteRule struct {
+	// replace, strip_hashtags, remove_lines, rewrite_links или append
+	Type string
+	// Регулярное выражение для replace, remove_lines и rewrite_links
+	Pattern string
+	// Замена для replace и rewrite_links, поддерживает группы $1
+	Replacement string
+	// Текст, который добавляет append
+	Text string
+}
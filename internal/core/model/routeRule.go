@@ -0,0 +1,23 @@
+package model
+
+const (
+	RouteInclude = "include"
+	RouteExclude = "exclude"
+)
+
+// RouteRule - правило отбора постов для канала Discord. Правило срабатывает, когда выполнены
+// все заданные в нем условия; незаданные условия не проверяются
+type RouteRule struct {
+	// include - отправить пост в канал, exclude - пропустить
+	Action string
+	// Хотя бы один из хэштегов, без учета регистра и символа #
+	Hashtags []string
+	// Регулярное выражение по тексту поста
+	Pattern string
+	// Хотя бы одно вложение одного из типов: photo, video, document и т.д.
+	MediaTypes []string
+	// true - только репосты, false - только оригинальные посты
+	Repost *bool
+	// Минимальная длина текста в символах
+	MinLength int
+}
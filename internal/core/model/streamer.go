@@ -5,4 +5,52 @@ type Streamer struct {
 	TelegramChannelID int64
 	DiscordBotToken   string
 	DiscordChannels   []DiscordChannel
+	Destinations      []Destination
+	Twitch            *TwitchSettings
+	ReverseBridge     *ReverseBridge
+	CommentBridge     *CommentBridge
+	// Преобразования текста для всех каналов Discord, выполняются до правил канала
+	Rewrite []RewriteRule
+	// Paused - новые посты не зеркалируются ни на одну площадку
+	Paused bool
+	// PauseMode - что делать с постами во время паузы: PauseQueue или PauseDrop (по умолчанию)
+	PauseMode string
+}
+
+const (
+	// PauseQueue - посты копятся в очереди и отправляются после снятия паузы
+	PauseQueue = "queue"
+	// PauseDrop - посты во время паузы не отправляются
+	PauseDrop = "drop"
+)
+
+// DestinationTypes - возвращает типы площадок стримера; Discord подключается автоматически при заданных каналах
+func (s *Streamer) DestinationTypes() []string {
+	var types []string
+	seen := make(map[string]bool)
+
+	if len(s.DiscordChannels) > 0 {
+		types = append(types, DestinationDiscord)
+		seen[DestinationDiscord] = true
+	}
+
+	for _, destination := range s.Destinations {
+		if !seen[destination.Type] {
+			types = append(types, destination.Type)
+			seen[destination.Type] = true
+		}
+	}
+
+	return types
+}
+
+// DestinationsOf - возвращает все площадки стримера указанного типа
+func (s *Streamer) DestinationsOf(destinationType string) []Destination {
+	var destinations []Destination
+	for _, destination := range s.Destinations {
+		if destination.Type == destinationType {
+			destinations = append(destinations, destination)
+		}
+	}
+	return destinations
 }
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+type TwitchSettings struct {
+	Login           string
+	DiscordChannels []DiscordChannel
+}
+
+type TwitchStream struct {
+	StreamID     string
+	Login        string
+	DisplayName  string
+	Title        string
+	GameName     string
+	ThumbnailURL string
+	ViewerCount  int
+	PeakViewers  int
+	StartedAt    time.Time
+	EndedAt      time.Time
+}
@@ -0,0 +1,90 @@
+package pause
+
+import (
+	"fmt"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/core/publisher"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	"slm-bot-publisher/internal/lib/storage"
+	"sync"
+)
+
+// Controller - ставит на паузу и возобновляет зеркалирование, сохраняя состояние в хранилище стримеров
+type Controller struct {
+	storage    *storage.Storage
+	publishers *publisher.Registry
+	queue      *Queue
+	// Очередь отправляется по одной, чтобы повторное возобновление не продублировало посты
+	replayMutex sync.Mutex
+}
+
+func NewController(storage *storage.Storage, publishers *publisher.Registry, DBHandlers *handlers.DBHandlers) *Controller {
+	return &Controller{
+		storage:    storage,
+		publishers: publishers,
+		queue:      NewQueue(DBHandlers),
+	}
+}
+
+// SetPaused - ставит на паузу или возобновляет стримера (пустой channelID) или его канал Discord.
+// После возобновления отложенные посты отправляются в фоне
+func (c *Controller) SetPaused(name, channelID string, paused bool) error {
+	found := channelID == ""
+	err := c.storage.Update(name, func(streamer *model.Streamer) {
+		if channelID == "" {
+			streamer.Paused = paused
+			return
+		}
+		for idx := range streamer.DiscordChannels {
+			if streamer.DiscordChannels[idx].ChannelID == channelID {
+				streamer.DiscordChannels[idx].Paused = paused
+				found = true
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("канал %s не найден у стримера %s", channelID, name)
+	}
+
+	if !paused {
+		go c.replay(name, channelID)
+	}
+	return nil
+}
+
+// replay - отправляет посты, накопленные за время паузы. Посты каналов ждут, пока на паузе весь стример
+func (c *Controller) replay(name, channelID string) {
+	c.replayMutex.Lock()
+	defer c.replayMutex.Unlock()
+
+	streamer := c.storage.GetStreamerByName(name)
+	if streamer == nil || streamer.Paused {
+		return
+	}
+
+	if channelID == "" {
+		c.queue.Replay(name, "", func(post *model.Post) {
+			c.publishers.Publish(streamer, post)
+		})
+	}
+
+	discord, exists := c.publishers.Get(model.DestinationDiscord)
+	if !exists {
+		return
+	}
+	for _, channel := range streamer.DiscordChannels {
+		if channel.Paused || (channelID != "" && channel.ChannelID != channelID) {
+			continue
+		}
+
+		// Пост отправляется только в возобновленный канал
+		channelStreamer := *streamer
+		channelStreamer.DiscordChannels = []model.DiscordChannel{channel}
+		c.queue.Replay(name, channel.ChannelID, func(post *model.Post) {
+			discord.Publish(&channelStreamer, post)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package pause
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/logging"
+)
+
+// Queue - посты, отложенные на время паузы стримера или канала Discord
+type Queue struct {
+	DBHandlers *handlers.DBHandlers
+}
+
+func NewQueue(DBHandlers *handlers.DBHandlers) *Queue {
+	return &Queue{DBHandlers: DBHandlers}
+}
+
+// Hold - откладывает пост до снятия паузы или отбрасывает его, в зависимости от PauseMode стримера.
+// Пустой channelID означает паузу всего стримера
+func (q *Queue) Hold(streamer *model.Streamer, channelID string, post *model.Post) {
+	target := streamer.Name
+	if channelID != "" {
+		target = fmt.Sprintf("канала %s стримера %s", channelID, streamer.Name)
+	}
+
+	if streamer.PauseMode != model.PauseQueue {
+		logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Пост %d пропущен: зеркалирование %s на паузе", post.MessageID, target))
+		return
+	}
+
+	payload, err := json.Marshal(post)
+	if err != nil {
+		logging.Log("Система", logrus.ErrorLevel, fmt.Sprintf("Ошибка сериализации поста %d для очереди: %v", post.MessageID, err))
+		return
+	}
+
+	queuedPost := modeldb.QueuedPost{
+		StreamerName:   streamer.Name,
+		ChannelID:      channelID,
+		TelegramChatID: post.ChatID,
+		TelegramMsgID:  post.MessageID,
+		Payload:        string(payload),
+	}
+	if err = q.DBHandlers.QueueHandlers.CreateQueuedPost(&queuedPost); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения поста %d в очередь: %v", post.MessageID, err))
+		return
+	}
+	logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Пост %d отложен до снятия паузы %s", post.MessageID, target))
+}
+
+// Update - переносит правку поста в его отложенные копии, вложения остаются прежними
+func (q *Queue) Update(streamer *model.Streamer, post *model.Post) {
+	queuedPosts, err := q.DBHandlers.QueueHandlers.GetQueuedPostsByTelegramID(post.ChatID, post.MessageID)
+	if err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения отложенных копий поста %d %s: %v", post.MessageID, streamer.Name, err))
+		return
+	}
+
+	for _, queuedPost := range queuedPosts {
+		var pending model.Post
+		if err = json.Unmarshal([]byte(queuedPost.Payload), &pending); err != nil {
+			continue
+		}
+		pending.Text = post.Text
+		pending.Entities = post.Entities
+
+		payload, err := json.Marshal(pending)
+		if err != nil {
+			continue
+		}
+		queuedPost.Payload = string(payload)
+		if err = q.DBHandlers.QueueHandlers.UpdateQueuedPost(&queuedPost); err != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка обновления поста %d в очереди: %v", post.MessageID, err))
+			continue
+		}
+		logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Правка поста %d перенесена в очередь паузы %s", post.MessageID, streamer.Name))
+	}
+}
+
+// Forget - убирает из очереди пост, удаленный до окончания паузы
+func (q *Queue) Forget(streamer *model.Streamer, post *model.Post) {
+	if err := q.DBHandlers.QueueHandlers.DeleteQueuedPostsByTelegramID(post.ChatID, post.MessageID); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка удаления поста %d из очереди %s: %v", post.MessageID, streamer.Name, err))
+	}
+}
+
+// Replay - передает отложенные посты в publish по порядку и удаляет их из очереди
+func (q *Queue) Replay(streamerName, channelID string, publish func(post *model.Post)) {
+	queuedPosts, err := q.DBHandlers.QueueHandlers.GetQueuedPosts(streamerName, channelID)
+	if err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения очереди %s: %v", streamerName, err))
+		return
+	}
+
+	for _, queuedPost := range queuedPosts {
+		var post model.Post
+		if err = json.Unmarshal([]byte(queuedPost.Payload), &post); err != nil {
+			logging.Log("Система", logrus.ErrorLevel, fmt.Sprintf("Ошибка чтения поста %d из очереди: %v", queuedPost.TelegramMsgID, err))
+		} else {
+			publish(&post)
+		}
+
+		if err = q.DBHandlers.QueueHandlers.DeleteQueuedPost(queuedPost.ID); err != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка удаления поста %d из очереди: %v", queuedPost.TelegramMsgID, err))
+		}
+	}
+
+	if len(queuedPosts) > 0 {
+		logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Отправлено отложенных постов %s: %d", streamerName, len(queuedPosts)))
+	}
+}
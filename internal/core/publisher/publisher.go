@@ -0,0 +1,138 @@
+package publisher
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/logging"
+)
+
+// Publisher - площадка, на которую зеркалируются посты из Telegram
+type Publisher interface {
+	Publish(streamer *model.Streamer, post *model.Post)
+	Edit(streamer *model.Streamer, post *model.Post)
+	Delete(streamer *model.Streamer, post *model.Post)
+}
+
+// Pinner - площадка, на которой можно закрепить опубликованный пост
+type Pinner interface {
+	Pin(streamer *model.Streamer, post *model.Post, pinned bool)
+}
+
+// Resender - площадка, которая хранит соответствие постов и их копий и может дослать пост туда, где копии нет
+type Resender interface {
+	Resend(streamer *model.Streamer, post *model.Post)
+}
+
+// Holder - очередь постов, опубликованных во время паузы
+type Holder interface {
+	Hold(streamer *model.Streamer, channelID string, post *model.Post)
+	Update(streamer *model.Streamer, post *model.Post)
+	Forget(streamer *model.Streamer, post *model.Post)
+}
+
+// Registry - набор площадок по типам назначения; сам является Publisher и рассылает пост по всем площадкам стримера
+type Registry struct {
+	publishers map[string]Publisher
+	holder     Holder
+}
+
+func NewRegistry() *Registry {
+	return &Registry{publishers: make(map[string]Publisher)}
+}
+
+// Register - регистрирует площадку для указанного типа назначения
+func (r *Registry) Register(destinationType string, publisher Publisher) {
+	r.publishers[destinationType] = publisher
+}
+
+// SetHolder - задает очередь, в которую попадают посты стримеров на паузе
+func (r *Registry) SetHolder(holder Holder) {
+	r.holder = holder
+}
+
+// Get - возвращает площадку по типу назначения
+func (r *Registry) Get(destinationType string) (Publisher, bool) {
+	publisher, exists := r.publishers[destinationType]
+	return publisher, exists
+}
+
+// ForStreamer - возвращает площадки, на которые зеркалируются посты стримера
+func (r *Registry) ForStreamer(streamer *model.Streamer) []Publisher {
+	var publishers []Publisher
+	for _, destinationType := range streamer.DestinationTypes() {
+		publisher, exists := r.publishers[destinationType]
+		if !exists {
+			logging.Log("Система", logrus.WarnLevel, fmt.Sprintf("Неизвестный тип площадки %s у стримера %s", destinationType, streamer.Name))
+			continue
+		}
+		publishers = append(publishers, publisher)
+	}
+	return publishers
+}
+
+func (r *Registry) Publish(streamer *model.Streamer, post *model.Post) {
+	if streamer.Paused {
+		if r.holder != nil {
+			r.holder.Hold(streamer, "", post)
+		} else {
+			logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Зеркалирование %s на паузе, пост %d пропущен", streamer.Name, post.MessageID))
+		}
+		return
+	}
+
+	for _, publisher := range r.ForStreamer(streamer) {
+		publisher.Publish(streamer, post)
+	}
+}
+
+func (r *Registry) Edit(streamer *model.Streamer, post *model.Post) {
+	if r.holder != nil {
+		r.holder.Update(streamer, post)
+	}
+
+	for _, publisher := range r.ForStreamer(streamer) {
+		publisher.Edit(streamer, post)
+	}
+}
+
+func (r *Registry) Delete(streamer *model.Streamer, post *model.Post) {
+	if r.holder != nil {
+		r.holder.Forget(streamer, post)
+	}
+
+	for _, publisher := range r.ForStreamer(streamer) {
+		publisher.Delete(streamer, post)
+	}
+}
+
+// Resend - досылает пост на площадки стримера, где его копии нет. Площадки, которые не хранят копии постов
+// (вебхуки Slack, Mattermost и собственные вебхуки), пропускаются, чтобы пост не появился там дважды
+func (r *Registry) Resend(streamer *model.Streamer, post *model.Post) {
+	if streamer.Paused {
+		logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Зеркалирование %s на паузе, повторная отправка поста %d пропущена", streamer.Name, post.MessageID))
+		return
+	}
+
+	for _, destinationType := range streamer.DestinationTypes() {
+		publisher, exists := r.publishers[destinationType]
+		if !exists {
+			continue
+		}
+		resender, ok := publisher.(Resender)
+		if !ok {
+			logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Площадка %s не хранит копии постов, пост %d туда повторно не отправляется", destinationType, post.MessageID))
+			continue
+		}
+		resender.Resend(streamer, post)
+	}
+}
+
+// Pin - закрепляет или открепляет пост на площадках стримера, которые это поддерживают
+func (r *Registry) Pin(streamer *model.Streamer, post *model.Post, pinned bool) {
+	for _, publisher := range r.ForStreamer(streamer) {
+		if pinner, ok := publisher.(Pinner); ok {
+			pinner.Pin(streamer, post, pinned)
+		}
+	}
+}
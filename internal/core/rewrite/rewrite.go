@@ -0,0 +1,191 @@
+package rewrite
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/patterns"
+	"slm-bot-publisher/logging"
+	"unicode"
+)
+
+var trailingHashtagsRegexp = regexp.MustCompile(`(\s*#[\p{L}\p{N}_]+)+\s*$`)
+
+// Apply - применяет правила по порядку и возвращает новый текст с пересчитанными смещениями сущностей.
+// Исходные текст и сущности не изменяются
+func Apply(rules []model.RewriteRule, text string, entities []model.TextEntity) (string, []model.TextEntity) {
+	if len(rules) == 0 {
+		return text, entities
+	}
+
+	doc := &document{
+		runes:    []rune(text),
+		entities: append([]model.TextEntity(nil), entities...),
+	}
+
+	for _, rule := range rules {
+		switch rule.Type {
+		case model.RewriteReplace:
+			if pattern := compile(rule.Pattern); pattern != nil {
+				doc.replaceAll(pattern, rule.Replacement)
+			}
+		case model.RewriteStripHashtags:
+			doc.replaceAll(trailingHashtagsRegexp, "")
+		case model.RewriteRemoveLines:
+			if pattern := compile(rule.Pattern); pattern != nil {
+				doc.removeLines(pattern)
+			}
+		case model.RewriteLinks:
+			if pattern := compile(rule.Pattern); pattern != nil {
+				doc.replaceAll(pattern, rule.Replacement)
+				doc.rewriteEntityURLs(pattern, rule.Replacement)
+			}
+		case model.RewriteAppend:
+			doc.replace(len(doc.runes), len(doc.runes), rule.Text)
+		default:
+			logging.Log("Rewrite", logrus.ErrorLevel, fmt.Sprintf("Неизвестный тип правила преобразования: %s", rule.Type))
+		}
+	}
+
+	doc.trim()
+	return string(doc.runes), doc.entities
+}
+
+// document - текст в рунах вместе с сущностями, смещения которых считаются в рунах
+type document struct {
+	runes    []rune
+	entities []model.TextEntity
+}
+
+// replace - заменяет руны [start, end) текстом и сдвигает сущности. Сущность, задетая заменой частично,
+// обрезается по ее границе; сущность, целиком попавшая в заменяемый участок, удаляется
+func (d *document) replace(start, end int, replacement string) {
+	inserted := []rune(replacement)
+	delta := len(inserted) - (end - start)
+
+	mapStart := func(pos int) int {
+		switch {
+		case pos <= start:
+			return pos
+		case pos >= end:
+			return pos + delta
+		default:
+			return start + len(inserted)
+		}
+	}
+	mapEnd := func(pos int) int {
+		switch {
+		case pos <= start:
+			return pos
+		case pos >= end:
+			return pos + delta
+		default:
+			return start
+		}
+	}
+
+	entities := d.entities[:0]
+	for _, entity := range d.entities {
+		newStart := mapStart(entity.Offset)
+		newEnd := mapEnd(entity.Offset + entity.Length)
+		if newEnd <= newStart {
+			continue
+		}
+		entity.Offset, entity.Length = newStart, newEnd-newStart
+		entities = append(entities, entity)
+	}
+	d.entities = entities
+
+	runes := make([]rune, 0, len(d.runes)+delta)
+	runes = append(runes, d.runes[:start]...)
+	runes = append(runes, inserted...)
+	runes = append(runes, d.runes[end:]...)
+	d.runes = runes
+}
+
+// replaceAll - заменяет все совпадения выражения; замены идут с конца, чтобы не сбивать позиции следующих
+func (d *document) replaceAll(pattern *regexp.Regexp, replacement string) {
+	text := string(d.runes)
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	offsets := byteToRune(text)
+
+	for idx := len(matches) - 1; idx >= 0; idx-- {
+		match := matches[idx]
+		expanded := pattern.ExpandString(nil, replacement, text, match)
+		d.replace(offsets[match[0]], offsets[match[1]], string(expanded))
+	}
+}
+
+// removeLines - удаляет строки, в которых есть совпадение, вместе с переводом строки
+func (d *document) removeLines(pattern *regexp.Regexp) {
+	type lineRange struct{ start, end int }
+	var lines []lineRange
+
+	start := 0
+	for idx := 0; idx <= len(d.runes); idx++ {
+		if idx == len(d.runes) || d.runes[idx] == '\n' {
+			end := idx
+			if pattern.MatchString(string(d.runes[start:end])) {
+				// Перевод строки удаляется вместе со строкой, у последней строки - предыдущий
+				if end < len(d.runes) {
+					lines = append(lines, lineRange{start, end + 1})
+				} else if start > 0 {
+					lines = append(lines, lineRange{start - 1, end})
+				} else {
+					lines = append(lines, lineRange{start, end})
+				}
+			}
+			start = idx + 1
+		}
+	}
+
+	for idx := len(lines) - 1; idx >= 0; idx-- {
+		d.replace(lines[idx].start, lines[idx].end, "")
+	}
+}
+
+// rewriteEntityURLs - применяет замену к адресам скрытых ссылок
+func (d *document) rewriteEntityURLs(pattern *regexp.Regexp, replacement string) {
+	for idx := range d.entities {
+		if d.entities[idx].URL != "" {
+			d.entities[idx].URL = pattern.ReplaceAllString(d.entities[idx].URL, replacement)
+		}
+	}
+}
+
+// trim - убирает пробелы и пустые строки, оставшиеся по краям после удаления подписей
+func (d *document) trim() {
+	end := len(d.runes)
+	for end > 0 && unicode.IsSpace(d.runes[end-1]) {
+		end--
+	}
+	d.replace(end, len(d.runes), "")
+
+	start := 0
+	for start < len(d.runes) && unicode.IsSpace(d.runes[start]) {
+		start++
+	}
+	d.replace(0, start, "")
+}
+
+// byteToRune - сопоставляет байтовым позициям строки номера рун
+func byteToRune(text string) []int {
+	offsets := make([]int, len(text)+1)
+	runeIdx := 0
+	for byteIdx := range text {
+		offsets[byteIdx] = runeIdx
+		runeIdx++
+	}
+	offsets[len(text)] = runeIdx
+	return offsets
+}
+
+func compile(pattern string) *regexp.Regexp {
+	compiled, err := patterns.Compile(pattern)
+	if err != nil {
+		logging.Log("Rewrite", logrus.ErrorLevel, fmt.Sprintf("Ошибка в регулярном выражении правила %q: %v", pattern, err))
+		return nil
+	}
+	return compiled
+}
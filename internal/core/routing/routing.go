@@ -0,0 +1,148 @@
+package routing
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/patterns"
+	"slm-bot-publisher/logging"
+	"strings"
+)
+
+var hashtagRegexp = regexp.MustCompile(`#[\p{L}\p{N}_]+`)
+
+// Decision - решение об отправке поста в канал с объяснением
+type Decision struct {
+	Send   bool
+	Reason string
+}
+
+// Evaluate - применяет правила канала к посту. Решение принимает первое сработавшее правило.
+// Если не сработало ни одно, пост отправляется, только когда среди правил нет include
+func Evaluate(rules []model.RouteRule, post *model.Post) Decision {
+	if len(rules) == 0 {
+		return Decision{Send: true, Reason: "правил нет"}
+	}
+
+	hasInclude := false
+	for idx, rule := range rules {
+		if rule.Action == model.RouteInclude {
+			hasInclude = true
+		}
+
+		matched, details := match(rule, post)
+		if !matched {
+			continue
+		}
+
+		reason := fmt.Sprintf("правило %d (%s): %s", idx+1, rule.Action, details)
+		switch rule.Action {
+		case model.RouteInclude:
+			return Decision{Send: true, Reason: reason}
+		case model.RouteExclude:
+			return Decision{Send: false, Reason: reason}
+		default:
+			return Decision{Send: false, Reason: fmt.Sprintf("правило %d: неизвестное действие %q", idx+1, rule.Action)}
+		}
+	}
+
+	if hasInclude {
+		return Decision{Send: false, Reason: "не сработало ни одно правило include"}
+	}
+	return Decision{Send: true, Reason: "не сработало ни одно правило exclude"}
+}
+
+// Explain - возвращает решения для всех каналов стримера в читаемом виде
+func Explain(channels []model.DiscordChannel, post *model.Post) string {
+	var lines []string
+	for _, channel := range channels {
+		decision := Evaluate(channel.Rules, post)
+		status := "пропущен"
+		if decision.Send {
+			status = "отправлен"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s, %s", channel.ChannelID, status, decision.Reason))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// match - проверяет все заданные условия правила и перечисляет выполненные
+func match(rule model.RouteRule, post *model.Post) (bool, string) {
+	var details []string
+
+	if len(rule.Hashtags) > 0 {
+		hashtag, found := findHashtag(post.Text, rule.Hashtags)
+		if !found {
+			return false, ""
+		}
+		details = append(details, "хэштег "+hashtag)
+	}
+
+	if rule.Pattern != "" {
+		pattern, err := patterns.Compile(rule.Pattern)
+		if err != nil {
+			logging.Log("Routing", logrus.ErrorLevel, fmt.Sprintf("Ошибка в регулярном выражении правила %q: %v", rule.Pattern, err))
+			return false, ""
+		}
+		if !pattern.MatchString(post.Text) {
+			return false, ""
+		}
+		details = append(details, fmt.Sprintf("текст совпал с %q", rule.Pattern))
+	}
+
+	if len(rule.MediaTypes) > 0 {
+		kind, found := findMediaType(post.Attachments, rule.MediaTypes)
+		if !found {
+			return false, ""
+		}
+		details = append(details, "вложение "+kind)
+	}
+
+	if rule.Repost != nil {
+		isRepost := post.Repost != nil
+		if isRepost != *rule.Repost {
+			return false, ""
+		}
+		if isRepost {
+			details = append(details, "репост")
+		} else {
+			details = append(details, "оригинальный пост")
+		}
+	}
+
+	if rule.MinLength > 0 {
+		length := len([]rune(strings.TrimSpace(post.Text)))
+		if length < rule.MinLength {
+			return false, ""
+		}
+		details = append(details, fmt.Sprintf("длина %d из минимальных %d", length, rule.MinLength))
+	}
+
+	if len(details) == 0 {
+		return true, "правило без условий"
+	}
+	return true, strings.Join(details, ", ")
+}
+
+func findHashtag(text string, hashtags []string) (string, bool) {
+	for _, found := range hashtagRegexp.FindAllString(text, -1) {
+		for _, hashtag := range hashtags {
+			if strings.EqualFold(strings.TrimPrefix(found, "#"), strings.TrimPrefix(hashtag, "#")) {
+				return found, true
+			}
+		}
+	}
+	return "", false
+}
+
+func findMediaType(attachments []model.Attachment, mediaTypes []string) (string, bool) {
+	for _, attachment := range attachments {
+		for _, mediaType := range mediaTypes {
+			if attachment.Kind == mediaType {
+				return attachment.Kind, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/core/pause"
+	"slm-bot-publisher/internal/lib/cache"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+	"strings"
+)
+
+// Sessions - применение настроек стримера к Discord без перезапуска и состояние подключения. Реализуется ботом Discord
+type Sessions interface {
+	SetStreamer(streamer model.Streamer)
+	RemoveStreamer(name string)
+	Status(name string) model.ConnectionStatus
+	MessageLink(streamer *model.Streamer, channelID, msgID string) string
+}
+
+// Posts - действия с постами канала. Реализуется ботом Telegram
+type Posts interface {
+	ResendPost(streamer *model.Streamer, msgID int, viaChatID int64, silent bool) error
+	DeletePost(streamer *model.Streamer, msgID int)
+	RetryDelivery(streamer *model.Streamer, channelID string, msgIDs []int, viaChatID int64) error
+}
+
+// Handler - HTTP API администрирования: стримеры, каналы Discord, копии постов и очереди,
+// а также панель управления для модераторов. Запросы к API требуют заголовок
+// Authorization: Bearer <токен из API_TOKENS>, панель принимает тот же токен через форму входа
+// и выдает вместо него отдельный токен сессии
+type Handler struct {
+	storage    *storage.Storage
+	DBHandlers *handlers.DBHandlers
+	pause      *pause.Controller
+	sessions   Sessions
+	posts      Posts
+	tokens     []string
+	// dashboardSessions - токены сессий панели и токены API, по которым они выданы
+	dashboardSessions *cache.Cache[string, string]
+	// viaChatID - служебный чат по умолчанию для повторной отправки постов
+	viaChatID int64
+}
+
+func NewHandler(storage *storage.Storage, DBHandlers *handlers.DBHandlers, pauseController *pause.Controller, sessions Sessions, posts Posts, tokens []string, viaChatID int64) *Handler {
+	return &Handler{
+		storage:    storage,
+		DBHandlers: DBHandlers,
+		pause:      pauseController,
+		sessions:   sessions,
+		posts:      posts,
+		tokens:     tokens,
+		viaChatID:  viaChatID,
+
+		dashboardSessions: cache.New[string, string](dashboardSessionTTL),
+	}
+}
+
+// Enabled - проверяет, заданы ли токены доступа
+func (h *Handler) Enabled() bool {
+	return len(h.tokens) > 0
+}
+
+// Register - регистрирует адреса API на HTTP сервере
+func (h *Handler) Register(mux interface {
+	Handle(pattern string, handler http.Handler)
+}) {
+	routes := map[string]http.HandlerFunc{
+		"GET /api/streamers":                                 h.listStreamers,
+		"POST /api/streamers":                                h.createStreamer,
+		"GET /api/streamers/{name}":                          h.getStreamer,
+		"PUT /api/streamers/{name}":                          h.updateStreamer,
+		"DELETE /api/streamers/{name}":                       h.deleteStreamer,
+		"GET /api/streamers/{name}/channels":                 h.listChannels,
+		"POST /api/streamers/{name}/channels":                h.createChannel,
+		"PUT /api/streamers/{name}/channels/{channel}":       h.updateChannel,
+		"DELETE /api/streamers/{name}/channels/{channel}":    h.deleteChannel,
+		"GET /api/streamers/{name}/messages":                 h.listMessages,
+		"GET /api/streamers/{name}/messages/{msgID}":         h.getMessage,
+		"POST /api/streamers/{name}/messages/{msgID}/resend": h.resendMessage,
+		"DELETE /api/streamers/{name}/messages/{msgID}":      h.deleteMessage,
+		"GET /api/messages/{platform}/{destinationMsgID}":    h.lookupMessage,
+		"GET /api/queue":                                     h.getQueue,
+	}
+	for pattern, handler := range routes {
+		mux.Handle(pattern, h.authorize(handler))
+	}
+
+	h.registerDashboard(mux)
+}
+
+// authorize - пропускает только запросы с известным токеном
+func (h *Handler) authorize(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if found && h.validToken(token) {
+			next(w, r)
+			return
+		}
+
+		logging.Log("API", logrus.WarnLevel, fmt.Sprintf("Отклонен запрос без действующего токена: %s %s", r.Method, r.URL.Path))
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "требуется действующий токен")
+	})
+}
+
+// validToken - проверяет токен; токены сравниваются за постоянное время
+func (h *Handler) validToken(token string) bool {
+	for _, allowed := range h.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// loadStreamer - возвращает стримера из пути запроса; если его нет, отвечает 404
+func (h *Handler) loadStreamer(w http.ResponseWriter, r *http.Request) (*model.Streamer, bool) {
+	streamer := h.storage.GetStreamerByName(r.PathValue("name"))
+	if streamer == nil {
+		writeError(w, http.StatusNotFound, "стример не найден")
+		return nil, false
+	}
+	return streamer, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logging.Log("API", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки ответа: %v", err))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// readJSON - разбирает тело запроса; неизвестные поля считаются ошибкой, чтобы опечатки не терялись
+func readJSON(w http.ResponseWriter, r *http.Request, value any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("некорректное тело запроса: %v", err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/logging"
+)
+
+func (h *Handler) listChannels(w http.ResponseWriter, r *http.Request) {
+	streamer, ok := h.loadStreamer(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, streamer.DiscordChannels)
+}
+
+func (h *Handler) createChannel(w http.ResponseWriter, r *http.Request) {
+	streamer, ok := h.loadStreamer(w, r)
+	if !ok {
+		return
+	}
+
+	var channel model.DiscordChannel
+	if !readJSON(w, r, &channel) {
+		return
+	}
+	if channel.ChannelID == "" {
+		writeError(w, http.StatusBadRequest, "обязателен ChannelID")
+		return
+	}
+	if channelIndex(streamer, channel.ChannelID) >= 0 {
+		writeError(w, http.StatusConflict, "канал уже добавлен")
+		return
+	}
+
+	err := h.saveStreamer(streamer, func(updated *model.Streamer) {
+		updated.DiscordChannels = append(updated.DiscordChannels, channel)
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logging.Log("API", logrus.InfoLevel, fmt.Sprintf("Стримеру %s добавлен канал %s", streamer.Name, channel.ChannelID))
+	writeJSON(w, http.StatusCreated, channel)
+}
+
+// updateChannel - заменяет настройки канала; ID канала берется из пути
+func (h *Handler) updateChannel(w http.ResponseWriter, r *http.Request) {
+	streamer, ok := h.loadStreamer(w, r)
+	if !ok {
+		return
+	}
+
+	channelID := r.PathValue("channel")
+	if channelIndex(streamer, channelID) < 0 {
+		writeError(w, http.StatusNotFound, "канал не найден")
+		return
+	}
+
+	var channel model.DiscordChannel
+	if !readJSON(w, r, &channel) {
+		return
+	}
+	if channel.ChannelID != "" && channel.ChannelID != channelID {
+		writeError(w, http.StatusBadRequest, "ID канала нельзя изменить")
+		return
+	}
+	channel.ChannelID = channelID
+
+	err := h.saveStreamer(streamer, func(updated *model.Streamer) {
+		if idx := channelIndex(updated, channelID); idx >= 0 {
+			updated.DiscordChannels[idx] = channel
+		}
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logging.Log("API", logrus.InfoLevel, fmt.Sprintf("Изменены настройки канала %s стримера %s", channelID, streamer.Name))
+	writeJSON(w, http.StatusOK, channel)
+}
+
+func (h *Handler) deleteChannel(w http.ResponseWriter, r *http.Request) {
+	streamer, ok := h.loadStreamer(w, r)
+	if !ok {
+		return
+	}
+
+	channelID := r.PathValue("channel")
+	if channelIndex(streamer, channelID) < 0 {
+		writeError(w, http.StatusNotFound, "канал не найден")
+		return
+	}
+
+	err := h.saveStreamer(streamer, func(updated *model.Streamer) {
+		if idx := channelIndex(updated, channelID); idx >= 0 {
+			updated.DiscordChannels = append(updated.DiscordChannels[:idx], updated.DiscordChannels[idx+1:]...)
+		}
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logging.Log("API", logrus.InfoLevel, fmt.Sprintf("У стримера %s удален канал %s", streamer.Name, channelID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func channelIndex(streamer *model.Streamer, channelID string) int {
+	for idx, channel := range streamer.DiscordChannels {
+		if channel.ChannelID == channelID {
+			return idx
+		}
+	}
+	return -1
+}
@@ -0,0 +1,367 @@
+package api
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slm-bot-publisher/internal/core/model"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/logging"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// dashboardCookie - cookie с токеном сессии, выданным после входа. Токен API в cookie не попадает
+	dashboardCookie = "slm_dashboard"
+	dashboardPosts  = 30
+	// Копий у поста столько, сколько у стримера площадок и каналов, поэтому записей читается с запасом
+	dashboardMessages = dashboardPosts * 10
+	dashboardFailures = 30
+
+	// dashboardSessionTTL - время жизни сессии панели; сессии хранятся в памяти и не переживают перезапуск
+	dashboardSessionTTL = 7 * 24 * time.Hour
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"formatTime": func(t time.Time) string {
+		if t.IsZero() {
+			return "—"
+		}
+		return t.Local().Format("02.01.2006 15:04:05")
+	},
+}).ParseFS(templateFS, "templates/*.html"))
+
+type dashboardStreamer struct {
+	Name           string
+	Paused         bool
+	Channels       int
+	PausedChannels int
+	Status         model.ConnectionStatus
+}
+
+// Healthy - последний запрос к Discord завершился успешно
+func (s dashboardStreamer) Healthy() bool {
+	return s.Status.LastErrorAt.IsZero() || s.Status.LastSuccess.After(s.Status.LastErrorAt)
+}
+
+type dashboardCopy struct {
+	Platform  string
+	ChannelID string
+	Link      string
+}
+
+type dashboardPost struct {
+	Streamer      string
+	TelegramMsgID int
+	TelegramLink  string
+	Copies        []dashboardCopy
+}
+
+type dashboardPage struct {
+	Notice    string
+	Streamers []dashboardStreamer
+	Posts     []dashboardPost
+	Failures  []modeldb.FailedDelivery
+}
+
+func (h *Handler) registerDashboard(mux interface {
+	Handle(pattern string, handler http.Handler)
+}) {
+	mux.Handle("GET /dashboard/login", http.HandlerFunc(h.showLogin))
+	mux.Handle("POST /dashboard/login", http.HandlerFunc(h.login))
+	mux.Handle("POST /dashboard/logout", http.HandlerFunc(h.logout))
+	mux.Handle("GET /dashboard", h.authorizePage(h.showDashboard))
+	mux.Handle("POST /dashboard/failures/{id}/retry", h.authorizePage(h.retryFailure))
+	mux.Handle("GET /dashboard/logs", h.authorizePage(h.streamLogs))
+}
+
+// authorizePage - пропускает модераторов с действующей сессией, остальных отправляет на страницу входа.
+// Сессия перестает действовать и после удаления токена API, по которому она выдана
+func (h *Handler) authorizePage(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(dashboardCookie)
+		if err == nil {
+			if token, exists := h.dashboardSessions.Get(cookie.Value); exists && h.validToken(token) {
+				next(w, r)
+				return
+			}
+		}
+		http.Redirect(w, r, "/dashboard/login", http.StatusSeeOther)
+	})
+}
+
+func (h *Handler) showLogin(w http.ResponseWriter, r *http.Request) {
+	renderPage(w, "login.html", map[string]string{"Error": r.URL.Query().Get("error")})
+}
+
+func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
+	token := r.PostFormValue("token")
+	if !h.validToken(token) {
+		logging.Log("API", logrus.WarnLevel, "Неудачная попытка входа в панель управления")
+		http.Redirect(w, r, "/dashboard/login?error=1", http.StatusSeeOther)
+		return
+	}
+
+	session, err := newSessionToken()
+	if err != nil {
+		logging.Log("API", logrus.ErrorLevel, fmt.Sprintf("Ошибка создания сессии панели управления: %v", err))
+		http.Error(w, "не удалось создать сессию", http.StatusInternalServerError)
+		return
+	}
+	h.dashboardSessions.Set(session, token)
+
+	// SameSite=Strict не дает другим сайтам отправлять формы панели от имени модератора
+	http.SetCookie(w, &http.Cookie{
+		Name:     dashboardCookie,
+		Value:    session,
+		Path:     "/dashboard",
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   int(dashboardSessionTTL.Seconds()),
+	})
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(dashboardCookie); err == nil {
+		h.dashboardSessions.Delete(cookie.Value)
+	}
+	http.SetCookie(w, &http.Cookie{Name: dashboardCookie, Path: "/dashboard", MaxAge: -1})
+	http.Redirect(w, r, "/dashboard/login", http.StatusSeeOther)
+}
+
+// newSessionToken - создает случайный токен сессии панели
+func newSessionToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// isHTTPS - запрос пришел по HTTPS напрямую или через обратный прокси, который сообщает схему в X-Forwarded-Proto
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+func (h *Handler) showDashboard(w http.ResponseWriter, r *http.Request) {
+	page := dashboardPage{Notice: r.URL.Query().Get("notice")}
+
+	streamers := h.storage.All()
+	for _, streamer := range streamers {
+		item := dashboardStreamer{
+			Name:     streamer.Name,
+			Paused:   streamer.Paused,
+			Channels: len(streamer.DiscordChannels),
+			Status:   h.sessions.Status(streamer.Name),
+		}
+		for _, channel := range streamer.DiscordChannels {
+			if channel.Paused {
+				item.PausedChannels++
+			}
+		}
+		page.Streamers = append(page.Streamers, item)
+	}
+
+	posts, err := h.recentPosts(streamers)
+	if err != nil {
+		logging.Log("API", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения последних постов для панели: %v", err))
+	}
+	page.Posts = posts
+
+	page.Failures, err = h.DBHandlers.DeliveryHandlers.GetFailedDeliveries(dashboardFailures)
+	if err != nil {
+		logging.Log("API", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения неудачных отправок для панели: %v", err))
+	}
+
+	renderPage(w, "dashboard.html", page)
+}
+
+// recentPosts - собирает последние посты со ссылками на оригинал в Telegram и копии на площадках
+func (h *Handler) recentPosts(streamers []model.Streamer) ([]dashboardPost, error) {
+	messages, err := h.DBHandlers.MessageHandlers.GetLatestOutgoingMessages(dashboardMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	type postKey struct {
+		streamer string
+		msgID    int
+	}
+	var posts []dashboardPost
+	indexes := make(map[postKey]int)
+
+	for _, message := range messages {
+		streamer := findStreamer(streamers, message)
+		if streamer == nil {
+			continue
+		}
+
+		key := postKey{streamer.Name, message.TelegramMsgID}
+		idx, exists := indexes[key]
+		if !exists {
+			if len(posts) == dashboardPosts {
+				continue
+			}
+			idx = len(posts)
+			indexes[key] = idx
+			posts = append(posts, dashboardPost{
+				Streamer:      streamer.Name,
+				TelegramMsgID: message.TelegramMsgID,
+				TelegramLink:  telegramLink(streamer.TelegramChannelID, message.TelegramMsgID),
+			})
+		}
+
+		postCopy := dashboardCopy{Platform: message.Platform, ChannelID: message.ChannelID}
+		if message.Platform == model.DestinationDiscord {
+			postCopy.Link = h.sessions.MessageLink(streamer, message.ChannelID, message.DestinationMsgID)
+		}
+		posts[idx].Copies = append(posts[idx].Copies, postCopy)
+	}
+
+	return posts, nil
+}
+
+// retryFailure - повторяет неудачную отправку и при успехе убирает ее из списка
+func (h *Handler) retryFailure(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		redirectNotice(w, r, "Некорректный ID отправки")
+		return
+	}
+
+	delivery, err := h.DBHandlers.DeliveryHandlers.GetFailedDelivery(uint(id))
+	if err != nil {
+		redirectNotice(w, r, "Отправка не найдена, возможно, ее уже повторили")
+		return
+	}
+	streamer := h.storage.GetStreamerByName(delivery.StreamerName)
+	if streamer == nil {
+		redirectNotice(w, r, "Стример "+delivery.StreamerName+" больше не настроен")
+		return
+	}
+	if h.viaChatID == 0 {
+		redirectNotice(w, r, "Для повтора нужен служебный чат: задайте ADMIN_IDS")
+		return
+	}
+
+	var msgIDs []int
+	for _, value := range strings.Split(delivery.TelegramMsgIDs, ",") {
+		if msgID, err := strconv.Atoi(value); err == nil {
+			msgIDs = append(msgIDs, msgID)
+		}
+	}
+	if len(msgIDs) == 0 {
+		msgIDs = []int{delivery.TelegramMsgID}
+	}
+
+	// Запись удаляется до повтора: если он тоже не удастся, бот сохранит новую
+	if err = h.DBHandlers.DeliveryHandlers.DeleteFailedDelivery(delivery.ID); err != nil {
+		redirectNotice(w, r, "Ошибка базы данных: "+err.Error())
+		return
+	}
+	if err = h.posts.RetryDelivery(streamer, delivery.ChannelID, msgIDs, h.viaChatID); err != nil {
+		logging.Log("API", logrus.ErrorLevel, fmt.Sprintf("Ошибка повтора отправки поста %d: %v", delivery.TelegramMsgID, err))
+		if createErr := h.DBHandlers.DeliveryHandlers.CreateFailedDelivery(&modeldb.FailedDelivery{
+			StreamerName:   delivery.StreamerName,
+			ChannelID:      delivery.ChannelID,
+			TelegramChatID: delivery.TelegramChatID,
+			TelegramMsgID:  delivery.TelegramMsgID,
+			TelegramMsgIDs: delivery.TelegramMsgIDs,
+			Error:          err.Error(),
+		}); createErr != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения неудачной отправки поста %d в базу", delivery.TelegramMsgID))
+		}
+		redirectNotice(w, r, "Повтор не удался: "+err.Error())
+		return
+	}
+
+	logging.Log("API", logrus.InfoLevel, fmt.Sprintf("Из панели повторена отправка поста %d от %s", delivery.TelegramMsgID, streamer.Name))
+	redirectNotice(w, r, fmt.Sprintf("Пост %d отправлен повторно", delivery.TelegramMsgID))
+}
+
+// streamLogs - передает новые записи лога через Server-Sent Events, начиная с последних сохраненных
+func (h *Handler) streamLogs(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Потоковая передача не поддерживается", http.StatusInternalServerError)
+		return
+	}
+
+	entries, unsubscribe := logging.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	for _, entry := range logging.RecentEntries() {
+		writeLogEvent(w, entry)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case entry := <-entries:
+			writeLogEvent(w, entry)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeLogEvent(w http.ResponseWriter, entry logging.Entry) {
+	data, err := json.Marshal(map[string]string{
+		"time":    entry.Time.Format("15:04:05"),
+		"level":   entry.Level,
+		"module":  entry.Module,
+		"message": entry.Message,
+	})
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+func renderPage(w http.ResponseWriter, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, name, data); err != nil {
+		logging.Log("API", logrus.ErrorLevel, fmt.Sprintf("Ошибка отрисовки страницы %s: %v", name, err))
+	}
+}
+
+func redirectNotice(w http.ResponseWriter, r *http.Request, notice string) {
+	http.Redirect(w, r, "/dashboard?notice="+url.QueryEscape(notice), http.StatusSeeOther)
+}
+
+// findStreamer - находит стримера записи; записи без ID чата относятся к стримеру по каналу Discord
+func findStreamer(streamers []model.Streamer, message modeldb.Message) *model.Streamer {
+	for idx := range streamers {
+		if message.TelegramChatID != 0 && streamers[idx].TelegramChannelID == message.TelegramChatID {
+			return &streamers[idx]
+		}
+		if message.TelegramChatID == 0 && channelIndex(&streamers[idx], message.ChannelID) >= 0 {
+			return &streamers[idx]
+		}
+	}
+	return nil
+}
+
+// telegramLink - ссылка на пост канала по ID чата; работает и для закрытых каналов у их подписчиков
+func telegramLink(chatID int64, msgID int) string {
+	channelID := strings.TrimPrefix(strconv.FormatInt(chatID, 10), "-100")
+	return fmt.Sprintf("https://t.me/c/%s/%d", channelID, msgID)
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"net/http"
+	"slm-bot-publisher/internal/core/model"
+	"strconv"
+)
+
+const (
+	defaultMessagesLimit = 50
+	maxMessagesLimit     = 500
+)
+
+// listMessages - возвращает последние копии постов стримера на всех площадках, начиная с новых
+func (h *Handler) listMessages(w http.ResponseWriter, r *http.Request) {
+	streamer, ok := h.loadStreamer(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultMessagesLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "некорректный limit")
+			return
+		}
+		limit = min(parsed, maxMessagesLimit)
+	}
+
+	var channelIDs []string
+	for _, channel := range streamer.DiscordChannels {
+		channelIDs = append(channelIDs, channel.ChannelID)
+	}
+	messages, err := h.DBHandlers.MessageHandlers.GetOutgoingMessages(streamer.TelegramChannelID, channelIDs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	latest := messages[max(len(messages)-limit, 0):]
+	for left, right := 0, len(latest)-1; left < right; left, right = left+1, right-1 {
+		latest[left], latest[right] = latest[right], latest[left]
+	}
+	writeJSON(w, http.StatusOK, latest)
+}
+
+// getMessage - возвращает все копии поста Telegram на площадках
+func (h *Handler) getMessage(w http.ResponseWriter, r *http.Request) {
+	streamer, msgID, ok := h.loadMessage(w, r)
+	if !ok {
+		return
+	}
+
+	messages, err := h.DBHandlers.MessageHandlers.GetDeliveries(streamer.TelegramChannelID, msgID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(messages) == 0 {
+		writeError(w, http.StatusNotFound, "копии поста не найдены")
+		return
+	}
+	writeJSON(w, http.StatusOK, messages)
+}
+
+// lookupMessage - находит пост Telegram по ID его копии на площадке
+func (h *Handler) lookupMessage(w http.ResponseWriter, r *http.Request) {
+	message, err := h.DBHandlers.MessageHandlers.GetMessageByDestinationID(r.PathValue("platform"), r.PathValue("destinationMsgID"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(w, http.StatusNotFound, "копия поста не найдена")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, message)
+}
+
+type resendRequest struct {
+	// Silent - отправить без упоминания роли, как /silent
+	Silent bool `json:"silent"`
+	// Via - служебный чат для получения поста; по умолчанию первый из ADMIN_IDS
+	Via int64 `json:"via"`
+}
+
+// resendMessage - досылает пост на площадки, где его копии нет, как /resend. Тело запроса необязательно
+func (h *Handler) resendMessage(w http.ResponseWriter, r *http.Request) {
+	streamer, msgID, ok := h.loadMessage(w, r)
+	if !ok {
+		return
+	}
+
+	request := resendRequest{Via: h.viaChatID}
+	if r.ContentLength != 0 && !readJSON(w, r, &request) {
+		return
+	}
+	if request.Via == 0 {
+		writeError(w, http.StatusBadRequest, "не указан служебный чат via и не задан ADMIN_IDS")
+		return
+	}
+
+	if err := h.posts.ResendPost(streamer, msgID, request.Via, request.Silent); err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// deleteMessage - удаляет пост из канала Telegram и все его копии
+func (h *Handler) deleteMessage(w http.ResponseWriter, r *http.Request) {
+	streamer, msgID, ok := h.loadMessage(w, r)
+	if !ok {
+		return
+	}
+
+	h.posts.DeletePost(streamer, msgID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) loadMessage(w http.ResponseWriter, r *http.Request) (*model.Streamer, int, bool) {
+	streamer, ok := h.loadStreamer(w, r)
+	if !ok {
+		return nil, 0, false
+	}
+
+	msgID, err := strconv.Atoi(r.PathValue("msgID"))
+	if err != nil || msgID <= 0 {
+		writeError(w, http.StatusBadRequest, "некорректный ID сообщения")
+		return nil, 0, false
+	}
+	return streamer, msgID, true
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+)
+
+type queueState struct {
+	// Paused - посты, отложенные паузой стримера или канала
+	Paused []modeldb.QueuedPost
+	// Scheduled - отложенные отправки в каналы Discord
+	Scheduled []modeldb.ScheduledPost
+}
+
+// getQueue - возвращает посты, ожидающие отправки; параметр streamer оставляет посты одного стримера
+func (h *Handler) getQueue(w http.ResponseWriter, r *http.Request) {
+	paused, err := h.DBHandlers.QueueHandlers.ListQueuedPosts()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	scheduled, err := h.DBHandlers.ScheduleHandlers.ListScheduledPosts()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	state := queueState{Paused: []modeldb.QueuedPost{}, Scheduled: []modeldb.ScheduledPost{}}
+	name := r.URL.Query().Get("streamer")
+	for _, post := range paused {
+		if name == "" || post.StreamerName == name {
+			state.Paused = append(state.Paused, post)
+		}
+	}
+	for _, post := range scheduled {
+		if name == "" || post.StreamerName == name {
+			state.Scheduled = append(state.Scheduled, post)
+		}
+	}
+	writeJSON(w, http.StatusOK, state)
+}
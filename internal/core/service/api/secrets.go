@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"slm-bot-publisher/internal/core/model"
+	"strings"
+)
+
+// secretSettings - поля настроек площадок, которые API не возвращает
+var secretSettings = map[string][]string{
+	model.DestinationMatrix:     {"AccessToken"},
+	model.DestinationMastodon:   {"AccessToken"},
+	model.DestinationWebhook:    {"Secret"},
+	model.DestinationSlack:      {"WebhookURL"},
+	model.DestinationMattermost: {"WebhookURL"},
+}
+
+// redact - убирает из ответа токен бота Discord и секреты в настройках площадок.
+// Площадки копируются, потому что стример из хранилища делит их с ним
+func redact(streamer *model.Streamer) {
+	streamer.DiscordBotToken = ""
+
+	destinations := make([]model.Destination, len(streamer.Destinations))
+	for idx, destination := range streamer.Destinations {
+		destinations[idx] = destination
+		if len(secretSettings[destination.Type]) == 0 {
+			continue
+		}
+
+		settings, ok := decodeSettings(destination.Settings)
+		if !ok {
+			destinations[idx].Settings = nil
+			continue
+		}
+		for key := range settings {
+			if isSecretSetting(destination.Type, key) {
+				settings[key] = json.RawMessage(`""`)
+			}
+		}
+		destinations[idx].Settings, _ = json.Marshal(settings)
+	}
+	if streamer.Destinations != nil {
+		streamer.Destinations = destinations
+	}
+}
+
+// keepSecrets - подставляет прежние секреты площадок, пустые в запросе, потому что API их не возвращает.
+// Площадка сопоставляется с прежней того же типа по порядку среди площадок этого типа
+func keepSecrets(streamer, current *model.Streamer) {
+	previous := make(map[string][]model.Destination)
+	for _, destination := range current.Destinations {
+		previous[destination.Type] = append(previous[destination.Type], destination)
+	}
+
+	seen := make(map[string]int)
+	for idx, destination := range streamer.Destinations {
+		position := seen[destination.Type]
+		seen[destination.Type]++
+		if len(secretSettings[destination.Type]) == 0 || position >= len(previous[destination.Type]) {
+			continue
+		}
+
+		settings, ok := decodeSettings(destination.Settings)
+		if !ok {
+			continue
+		}
+		previousSettings, ok := decodeSettings(previous[destination.Type][position].Settings)
+		if !ok {
+			continue
+		}
+
+		changed := false
+		for key, value := range previousSettings {
+			if !isSecretSetting(destination.Type, key) || !isEmptySetting(settings, key) {
+				continue
+			}
+			for existing := range settings {
+				if strings.EqualFold(existing, key) {
+					delete(settings, existing)
+				}
+			}
+			settings[key] = value
+			changed = true
+		}
+		if changed {
+			streamer.Destinations[idx].Settings, _ = json.Marshal(settings)
+		}
+	}
+}
+
+func decodeSettings(raw json.RawMessage) (map[string]json.RawMessage, bool) {
+	settings := make(map[string]json.RawMessage)
+	if len(raw) == 0 {
+		return settings, true
+	}
+	if err := json.Unmarshal(raw, &settings); err != nil || settings == nil {
+		return nil, false
+	}
+	return settings, true
+}
+
+// isSecretSetting - имена полей сравниваются без учета регистра, как их разбирает encoding/json
+func isSecretSetting(destinationType, key string) bool {
+	for _, secret := range secretSettings[destinationType] {
+		if strings.EqualFold(secret, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptySetting(settings map[string]json.RawMessage, key string) bool {
+	for existing, value := range settings {
+		if !strings.EqualFold(existing, key) {
+			continue
+		}
+		var text string
+		if err := json.Unmarshal(value, &text); err != nil || text != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/secrets"
+	"slm-bot-publisher/logging"
+)
+
+func (h *Handler) listStreamers(w http.ResponseWriter, r *http.Request) {
+	streamers := h.storage.All()
+	for idx := range streamers {
+		redact(&streamers[idx])
+	}
+	writeJSON(w, http.StatusOK, streamers)
+}
+
+func (h *Handler) getStreamer(w http.ResponseWriter, r *http.Request) {
+	streamer, ok := h.loadStreamer(w, r)
+	if !ok {
+		return
+	}
+	redact(streamer)
+	writeJSON(w, http.StatusOK, streamer)
+}
+
+func (h *Handler) createStreamer(w http.ResponseWriter, r *http.Request) {
+	var streamer model.Streamer
+	if !readJSON(w, r, &streamer) {
+		return
+	}
+	if streamer.Name == "" || streamer.TelegramChannelID == 0 || streamer.DiscordBotToken == "" {
+		writeError(w, http.StatusBadRequest, "обязательны Name, TelegramChannelID и DiscordBotToken")
+		return
+	}
+	if !plainToken(w, streamer.DiscordBotToken) {
+		return
+	}
+
+	if err := h.storage.Add(streamer); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	h.sessions.SetStreamer(streamer)
+
+	logging.Log("API", logrus.InfoLevel, fmt.Sprintf("Добавлен стример %s", streamer.Name))
+	redact(&streamer)
+	writeJSON(w, http.StatusCreated, streamer)
+}
+
+// updateStreamer - заменяет настройки стримера. Имя не меняется, а пустые DiscordBotToken и секреты площадок
+// оставляют прежние значения, потому что API их не возвращает. Снятие паузы отправляет накопленные посты
+func (h *Handler) updateStreamer(w http.ResponseWriter, r *http.Request) {
+	current, ok := h.loadStreamer(w, r)
+	if !ok {
+		return
+	}
+
+	var streamer model.Streamer
+	if !readJSON(w, r, &streamer) {
+		return
+	}
+	if streamer.Name != "" && streamer.Name != current.Name {
+		writeError(w, http.StatusBadRequest, "имя стримера нельзя изменить")
+		return
+	}
+	if other := h.storage.GetStreamerByTelegramID(streamer.TelegramChannelID); streamer.TelegramChannelID == 0 || (other != nil && other.Name != current.Name) {
+		writeError(w, http.StatusBadRequest, "TelegramChannelID пуст или уже подключен к другому стримеру")
+		return
+	}
+	streamer.Name = current.Name
+	if streamer.DiscordBotToken == "" {
+		streamer.DiscordBotToken = current.DiscordBotToken
+	} else if !plainToken(w, streamer.DiscordBotToken) {
+		return
+	}
+	keepSecrets(&streamer, current)
+
+	if err := h.saveStreamer(current, func(updated *model.Streamer) { *updated = streamer }); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logging.Log("API", logrus.InfoLevel, fmt.Sprintf("Изменены настройки стримера %s", streamer.Name))
+	redact(&streamer)
+	writeJSON(w, http.StatusOK, streamer)
+}
+
+func (h *Handler) deleteStreamer(w http.ResponseWriter, r *http.Request) {
+	streamer, ok := h.loadStreamer(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.storage.Remove(streamer.Name); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.sessions.RemoveStreamer(streamer.Name)
+
+	logging.Log("API", logrus.InfoLevel, fmt.Sprintf("Удален стример %s", streamer.Name))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// saveStreamer - сохраняет изменение стримера и применяет его к сессиям Discord.
+// Пауза снимается через контроллер, чтобы отправить посты, накопленные в очереди
+func (h *Handler) saveStreamer(current *model.Streamer, update func(streamer *model.Streamer)) error {
+	var resumed []string
+	err := h.storage.Update(current.Name, func(streamer *model.Streamer) {
+		update(streamer)
+		resumed = resumedTargets(current, streamer)
+	})
+	if err != nil {
+		return err
+	}
+
+	updated := h.storage.GetStreamerByName(current.Name)
+	if updated != nil {
+		h.sessions.SetStreamer(*updated)
+	}
+
+	for _, channelID := range resumed {
+		if err = h.pause.SetPaused(current.Name, channelID, false); err != nil {
+			logging.Log("API", logrus.ErrorLevel, fmt.Sprintf("Ошибка возобновления %s %s: %v", current.Name, channelID, err))
+		}
+	}
+	return nil
+}
+
+// resumedTargets - возвращает, с чего сняли паузу: пустая строка означает всего стримера, иначе ID канала
+func resumedTargets(previous, streamer *model.Streamer) []string {
+	var resumed []string
+	if previous.Paused && !streamer.Paused {
+		resumed = append(resumed, "")
+	}
+
+	for _, channel := range streamer.DiscordChannels {
+		if channel.Paused {
+			continue
+		}
+		for _, previousChannel := range previous.DiscordChannels {
+			if previousChannel.ChannelID == channel.ChannelID && previousChannel.Paused {
+				resumed = append(resumed, channel.ChannelID)
+			}
+		}
+	}
+	return resumed
+}
+
+// plainToken - API принимает токен бота только открытым текстом. Ссылки env: и file: задаются через CLI
+// и файл стримеров: иначе клиент API мог бы прочитать любой файл или переменную окружения бота
+func plainToken(w http.ResponseWriter, token string) bool {
+	if !secrets.IsPlain(token) {
+		writeError(w, http.StatusBadRequest, "DiscordBotToken передается открытым текстом; ссылки env:, file: и значения enc:v1: задаются через CLI")
+		return false
+	}
+	return true
+}
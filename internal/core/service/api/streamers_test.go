@@ -0,0 +1,286 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/database/dbtest"
+	"slm-bot-publisher/internal/lib/secrets"
+	"slm-bot-publisher/internal/lib/storage"
+	"strings"
+	"testing"
+)
+
+const testAPIToken = "api-token"
+
+// fakeSessions - сессии Discord, которые только запоминают примененных стримеров
+type fakeSessions struct {
+	applied []string
+}
+
+func (f *fakeSessions) SetStreamer(streamer model.Streamer) {
+	f.applied = append(f.applied, streamer.Name)
+}
+
+func (f *fakeSessions) RemoveStreamer(name string) {}
+
+func (f *fakeSessions) Status(name string) model.ConnectionStatus {
+	return model.ConnectionStatus{}
+}
+
+func (f *fakeSessions) MessageLink(streamer *model.Streamer, channelID, msgID string) string {
+	return ""
+}
+
+type testAPI struct {
+	server  *httptest.Server
+	storage *storage.Storage
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	DBHandlers := dbtest.New(t)
+	streamerStorage := storage.NewStorage(DBHandlers.StreamerHandlers, &secrets.Keeper{}, "")
+	streamer := model.Streamer{
+		Name:              "Test",
+		TelegramChannelID: -100,
+		DiscordBotToken:   "discord-token",
+		Destinations: []model.Destination{
+			{Type: model.DestinationMatrix, Settings: json.RawMessage(`{"Homeserver":"https://matrix.test","AccessToken":"matrix-token","Rooms":["!room:test"]}`)},
+			{Type: model.DestinationWebhook, Settings: json.RawMessage(`{"URL":"https://hooks.test","Secret":"webhook-secret"}`)},
+			{Type: model.DestinationSlack, Settings: json.RawMessage(`{"WebhookURL":"https://hooks.slack.test/secret","Username":"bot"}`)},
+		},
+	}
+	if err := streamerStorage.Add(streamer); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewHandler(streamerStorage, DBHandlers, nil, &fakeSessions{}, nil, []string{testAPIToken}, 0)
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	api := &testAPI{server: httptest.NewServer(mux), storage: streamerStorage}
+	t.Cleanup(api.server.Close)
+	return api
+}
+
+// request - выполняет запрос к API; пустой token означает запрос без заголовка Authorization
+func (a *testAPI) request(t *testing.T, method, path, token, body string) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, a.server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var data json.RawMessage
+	_ = json.NewDecoder(resp.Body).Decode(&data)
+	return resp, data
+}
+
+func destinationSettings(t *testing.T, streamer model.Streamer, destinationType string) map[string]interface{} {
+	t.Helper()
+
+	for _, destination := range streamer.Destinations {
+		if destination.Type != destinationType {
+			continue
+		}
+		var settings map[string]interface{}
+		if err := json.Unmarshal(destination.Settings, &settings); err != nil {
+			t.Fatal(err)
+		}
+		return settings
+	}
+	t.Fatalf("площадка %s не найдена", destinationType)
+	return nil
+}
+
+func TestRequiresToken(t *testing.T) {
+	api := newTestAPI(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{name: "без токена", method: http.MethodGet, path: "/api/streamers", wantStatus: http.StatusUnauthorized},
+		{name: "неизвестный токен", method: http.MethodGet, path: "/api/streamers", token: "wrong", wantStatus: http.StatusUnauthorized},
+		{name: "изменение без токена", method: http.MethodPut, path: "/api/streamers/Test", wantStatus: http.StatusUnauthorized},
+		{name: "удаление без токена", method: http.MethodDelete, path: "/api/streamers/Test", wantStatus: http.StatusUnauthorized},
+		{name: "действующий токен", method: http.MethodGet, path: "/api/streamers", token: testAPIToken, wantStatus: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, _ := api.request(t, test.method, test.path, test.token, "")
+			if resp.StatusCode != test.wantStatus {
+				t.Fatalf("статус %d, ожидался %d", resp.StatusCode, test.wantStatus)
+			}
+		})
+	}
+
+	if api.storage.GetStreamerByName("Test") == nil {
+		t.Fatal("запрос без токена удалил стримера")
+	}
+}
+
+func TestGetRedactsSecrets(t *testing.T) {
+	api := newTestAPI(t)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "список стримеров", path: "/api/streamers"},
+		{name: "один стример", path: "/api/streamers/Test"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, body := api.request(t, http.MethodGet, test.path, testAPIToken, "")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("статус %d", resp.StatusCode)
+			}
+
+			var streamer model.Streamer
+			if strings.HasPrefix(string(body), "[") {
+				var streamers []model.Streamer
+				if err := json.Unmarshal(body, &streamers); err != nil || len(streamers) != 1 {
+					t.Fatalf("неверный список стримеров: %s", body)
+				}
+				streamer = streamers[0]
+			} else if err := json.Unmarshal(body, &streamer); err != nil {
+				t.Fatal(err)
+			}
+
+			if streamer.DiscordBotToken != "" {
+				t.Fatalf("токен бота Discord в ответе: %q", streamer.DiscordBotToken)
+			}
+			if settings := destinationSettings(t, streamer, model.DestinationMatrix); settings["AccessToken"] != "" || settings["Homeserver"] != "https://matrix.test" {
+				t.Fatalf("неверные настройки Matrix: %v", settings)
+			}
+			if settings := destinationSettings(t, streamer, model.DestinationWebhook); settings["Secret"] != "" || settings["URL"] != "https://hooks.test" {
+				t.Fatalf("неверные настройки вебхука: %v", settings)
+			}
+			if settings := destinationSettings(t, streamer, model.DestinationSlack); settings["WebhookURL"] != "" || settings["Username"] != "bot" {
+				t.Fatalf("неверные настройки Slack: %v", settings)
+			}
+		})
+	}
+
+	// Ответ не должен менять стримера в хранилище
+	stored := api.storage.GetStreamerByName("Test")
+	if stored.DiscordBotToken != "discord-token" || destinationSettings(t, *stored, model.DestinationMatrix)["AccessToken"] != "matrix-token" {
+		t.Fatalf("секреты стримера в хранилище изменены: %+v", stored)
+	}
+}
+
+func TestUpdateKeepsEmptySecrets(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		wantToken        string
+		wantMatrixToken  string
+		wantWebhookToken string
+	}{
+		{
+			name:             "пустые секреты",
+			body:             `{"TelegramChannelID":-100,"DiscordBotToken":"","Destinations":[{"Type":"matrix","Settings":{"Homeserver":"https://new.test","AccessToken":"","Rooms":[]}},{"Type":"webhook","Settings":{"URL":"https://hooks.test","Secret":""}}]}`,
+			wantToken:        "discord-token",
+			wantMatrixToken:  "matrix-token",
+			wantWebhookToken: "webhook-secret",
+		},
+		{
+			name:             "поля секретов не переданы",
+			body:             `{"TelegramChannelID":-100,"Destinations":[{"Type":"matrix","Settings":{"Homeserver":"https://new.test"}},{"Type":"webhook","Settings":{"URL":"https://hooks.test"}}]}`,
+			wantToken:        "discord-token",
+			wantMatrixToken:  "matrix-token",
+			wantWebhookToken: "webhook-secret",
+		},
+		{
+			name:             "новые секреты",
+			body:             `{"TelegramChannelID":-100,"DiscordBotToken":"new-token","Destinations":[{"Type":"matrix","Settings":{"Homeserver":"https://new.test","AccessToken":"new-matrix"}},{"Type":"webhook","Settings":{"URL":"https://hooks.test","Secret":"new-secret"}}]}`,
+			wantToken:        "new-token",
+			wantMatrixToken:  "new-matrix",
+			wantWebhookToken: "new-secret",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			api := newTestAPI(t)
+
+			resp, body := api.request(t, http.MethodPut, "/api/streamers/Test", testAPIToken, test.body)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("статус %d: %s", resp.StatusCode, body)
+			}
+			if strings.Contains(string(body), test.wantMatrixToken) || strings.Contains(string(body), test.wantToken) {
+				t.Fatalf("секреты в ответе на PUT: %s", body)
+			}
+
+			stored := api.storage.GetStreamerByName("Test")
+			if stored.DiscordBotToken != test.wantToken {
+				t.Fatalf("токен бота %q, ожидался %q", stored.DiscordBotToken, test.wantToken)
+			}
+			matrixSettings := destinationSettings(t, *stored, model.DestinationMatrix)
+			if matrixSettings["AccessToken"] != test.wantMatrixToken || matrixSettings["Homeserver"] != "https://new.test" {
+				t.Fatalf("неверные настройки Matrix: %v", matrixSettings)
+			}
+			if secret := destinationSettings(t, *stored, model.DestinationWebhook)["Secret"]; secret != test.wantWebhookToken {
+				t.Fatalf("секрет вебхука %v, ожидался %q", secret, test.wantWebhookToken)
+			}
+		})
+	}
+}
+
+func TestRejectsTokenReferences(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+	}{
+		{name: "создание со ссылкой env", method: http.MethodPost, path: "/api/streamers", token: "env:DISCORD_TOKEN"},
+		{name: "создание со ссылкой file", method: http.MethodPost, path: "/api/streamers", token: "file:/etc/passwd"},
+		{name: "изменение со ссылкой env", method: http.MethodPut, path: "/api/streamers/Test", token: "env:DISCORD_TOKEN"},
+		{name: "изменение со ссылкой file", method: http.MethodPut, path: "/api/streamers/Test", token: "file:/etc/passwd"},
+		{name: "изменение с шифротекстом", method: http.MethodPut, path: "/api/streamers/Test", token: secrets.EncryptedPrefix + "a:b"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			api := newTestAPI(t)
+
+			name := "Test"
+			channelID := -100
+			if test.method == http.MethodPost {
+				name, channelID = "Other", -200
+			}
+			body, _ := json.Marshal(map[string]interface{}{"Name": name, "TelegramChannelID": channelID, "DiscordBotToken": test.token})
+
+			resp, _ := api.request(t, test.method, test.path, testAPIToken, string(body))
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("статус %d, ожидался %d", resp.StatusCode, http.StatusBadRequest)
+			}
+			if api.storage.GetStreamerByName("Other") != nil {
+				t.Fatal("стример со ссылкой вместо токена добавлен")
+			}
+			if token := api.storage.GetStreamerByName("Test").DiscordBotToken; token != "discord-token" {
+				t.Fatalf("токен стримера изменен: %q", token)
+			}
+		})
+	}
+}
@@ -5,42 +5,73 @@ import (
 	"fmt"
 	"github.com/bwmarrin/discordgo"
 	"github.com/sirupsen/logrus"
-	"io/ioutil"
+	"slm-bot-publisher/internal/core/format"
 	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/core/pause"
+	"slm-bot-publisher/internal/core/rewrite"
+	"slm-bot-publisher/internal/core/routing"
 	"slm-bot-publisher/internal/lib/database/handlers"
 	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/internal/lib/media"
 	"slm-bot-publisher/internal/lib/storage"
 	"slm-bot-publisher/logging"
 	"strings"
+	"sync"
+	"time"
 )
 
 type BotDiscord struct {
 	SessionCreators map[string]func() (*discordgo.Session, error)
 	TelegramToken   string
 	DBHandlers      *handlers.DBHandlers
+	mediaStore      *media.Store
+	// Постоянные сессии стримеров, которые слушают события Discord
+	listenerSessions map[string]*discordgo.Session
+	// Защищает SessionCreators и listenerSessions, которые меняются при правке стримеров через API
+	sessionMutex sync.RWMutex
+	// Получатель сообщений обратного моста; задается при запуске постоянных сессий
+	sender TelegramSender
+	// Вебхуки для комментариев из Telegram по ID канала
+	commentWebhooks     map[string]*discordgo.Webhook
+	commentWebhookMutex sync.Mutex
+	pauseQueue          *pause.Queue
+	// Состояние подключения стримеров и ID серверов каналов для ссылок на сообщения
+	statuses    map[string]model.ConnectionStatus
+	statusMutex sync.Mutex
+	guildIDs    sync.Map
 }
 
 const (
 	ThreadName          = "Комментарии"
 	AutoArchiveDuration = 60
 	FirstMessageContent = "Пожалуйста, соблюдайте правила общения в комментариях!"
+	RepostAvatarName    = "avatar.jpg"
+	GalleryLimit        = 4
+	RepostEmptyContent  = "-----------------------------------------"
 )
 
-func NewDiscordBot(storage *storage.Storage, tgToken string, DBHandlers *handlers.DBHandlers) *BotDiscord {
+func NewDiscordBot(storage *storage.Storage, tgToken string, DBHandlers *handlers.DBHandlers, mediaStore *media.Store) *BotDiscord {
 	sessionCreators := make(map[string]func() (*discordgo.Session, error))
 
-	for _, streamer := range storage.Streamers {
-		sessionCreators[streamer.Name] = func(s *model.Streamer) func() (*discordgo.Session, error) {
-			return func() (*discordgo.Session, error) {
-				return createSession(s)
-			}
-		}(&streamer)
+	for _, streamer := range storage.All() {
+		sessionCreators[streamer.Name] = newSessionCreator(streamer)
 	}
 
 	return &BotDiscord{
-		SessionCreators: sessionCreators,
-		TelegramToken:   tgToken,
-		DBHandlers:      DBHandlers,
+		SessionCreators:  sessionCreators,
+		TelegramToken:    tgToken,
+		DBHandlers:       DBHandlers,
+		mediaStore:       mediaStore,
+		listenerSessions: make(map[string]*discordgo.Session),
+		commentWebhooks:  make(map[string]*discordgo.Webhook),
+		pauseQueue:       pause.NewQueue(DBHandlers),
+		statuses:         make(map[string]model.ConnectionStatus),
+	}
+}
+
+func newSessionCreator(streamer model.Streamer) func() (*discordgo.Session, error) {
+	return func() (*discordgo.Session, error) {
+		return createSession(&streamer)
 	}
 }
 
@@ -61,24 +92,30 @@ func createSession(streamer *model.Streamer) (*discordgo.Session, error) {
 	return dg, nil
 }
 
-// sendWithSession - вспомогательная функция для взаимодействия с Discord с использованием сессии
-func (d *BotDiscord) sendWithSession(streamer *model.Streamer, sendFunc func(*discordgo.Session) error) {
-	sessionCreator, exists := d.SessionCreators[streamer.Name]
+// sendWithSession - вспомогательная функция для взаимодействия с Discord с использованием сессии.
+// Ошибка записывается в лог и в состояние подключения стримера
+func (d *BotDiscord) sendWithSession(streamer *model.Streamer, sendFunc func(*discordgo.Session) error) error {
+	sessionCreator, exists := d.sessionCreator(streamer.Name)
 	if !exists {
 		logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Стример %s не найден", streamer.Name))
-		return
+		return fmt.Errorf("стример %s не найден", streamer.Name)
 	}
 
 	session, err := sessionCreator()
 	if err != nil {
 		logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка создания сессии для стримера %s: %v", streamer.Name, err))
-		return
+		d.markFailure(streamer.Name, err)
+		return err
 	}
 	defer session.Close()
 
 	if err = sendFunc(session); err != nil {
 		logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки запроса для стримера %s: %v", streamer.Name, err))
+		d.markFailure(streamer.Name, err)
+		return err
 	}
+	d.markSuccess(streamer.Name)
+	return nil
 }
 
 // sendMessage - отправляет сообщение в канал Discord с вложениями
@@ -135,56 +172,58 @@ func (d *BotDiscord) deleteSystemThreadMessage(session *discordgo.Session, chann
 	return nil
 }
 
-// SendMessageToDiscord - отправляет сообщение с вложениями в Discord
-func (d *BotDiscord) SendMessageToDiscord(streamer *model.Streamer, message string, attachments []*discordgo.File, messageModel []modeldb.Message, postLink string) {
-	filesData := readFilesData(attachments)
-	if filesData == nil {
-		return
-	}
-
-	d.sendWithSession(streamer, func(session *discordgo.Session) error {
+// SendMessageToDiscord - отправляет пост с вложениями в Discord. Ошибка одного канала не мешает
+// отправке в остальные; неудачные отправки сохраняются для повтора
+func (d *BotDiscord) SendMessageToDiscord(streamer *model.Streamer, post *model.Post) {
+	err := d.sendWithSession(streamer, func(session *discordgo.Session) error {
 		for _, discordChannel := range streamer.DiscordChannels {
-			content := formatPrefix(discordChannel.Prefix) + "\n" + message
-			files := prepareFiles(attachments, filesData)
+			if !isRouted(streamer, discordChannel, post) || d.holdIfPaused(streamer, discordChannel, post) || d.delayIfNeeded(streamer, discordChannel, post) {
+				continue
+			}
+
+			content := formatText(streamer, discordChannel, post)
+			if !post.Silent {
+				content = withPrefix(discordChannel.Prefix, content)
+			}
+			files := prepareFiles(post.Attachments)
 
-			sentMessage, err := d.sendMessage(session, discordChannel.ChannelID, content, files, postLink)
+			sentMessage, err := d.sendMessage(session, discordChannel.ChannelID, content, files, post.Link)
 			if err != nil {
-				return fmt.Errorf("ошибка отправки сообщения на канал %s: %v", discordChannel.ChannelID, err)
+				d.channelFailed(streamer, discordChannel.ChannelID, post, err)
+				continue
 			}
 
-			d.saveMessagesToDB(sentMessage, discordChannel.ChannelID, messageModel)
+			d.saveMessagesToDB(sentMessage, discordChannel.ChannelID, post)
 			logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Сообщения от %s успешно отправлено в канал %s", streamer.Name, discordChannel.ChannelID))
 		}
 		return nil
 	})
+	if err != nil {
+		d.saveFailure(streamer, "", post, err)
+	}
 }
 
-// readFilesData - читает данные файлов из вложений
-func readFilesData(attachments []*discordgo.File) [][]byte {
-	filesData := make([][]byte, len(attachments))
-	for i, attachment := range attachments {
-		fileData, err := ioutil.ReadAll(attachment.Reader)
-		if err != nil {
-			logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка чтения файла: %v", err))
-			return nil
-		}
-		filesData[i] = fileData
-	}
-	return filesData
+// channelFailed - записывает ошибку отправки поста в канал и сохраняет пост для повтора
+func (d *BotDiscord) channelFailed(streamer *model.Streamer, channelID string, post *model.Post, err error) {
+	err = fmt.Errorf("ошибка отправки сообщения на канал %s: %v", channelID, err)
+	logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Пост %d от %s не отправлен: %v", post.MessageID, streamer.Name, err))
+	d.saveFailure(streamer, channelID, post, err)
 }
 
 // saveMessagesToDB - сохраняет отправленные сообщения в базе данных
-func (d *BotDiscord) saveMessagesToDB(sentMessage *discordgo.Message, channelID string, messageModel []modeldb.Message) {
-	for idx, msg := range messageModel {
+func (d *BotDiscord) saveMessagesToDB(sentMessage *discordgo.Message, channelID string, post *model.Post) {
+	for idx, part := range post.Parts {
 		messageDB := modeldb.Message{
-			MainPost:      msg.MainPost,
-			ChannelID:     channelID,
-			TelegramMsgID: msg.TelegramMsgID,
-			DiscordMsgID:  sentMessage.ID,
+			Platform:         model.DestinationDiscord,
+			MainPost:         idx == 0,
+			ChannelID:        channelID,
+			TelegramChatID:   post.ChatID,
+			TelegramMsgID:    part.MessageID,
+			DestinationMsgID: sentMessage.ID,
 		}
-		if msg.TelegramAttachmentID != "" && len(sentMessage.Attachments) > 0 && sentMessage.Attachments[idx] != nil {
-			messageDB.TelegramAttachmentID = msg.TelegramAttachmentID
-			messageDB.DiscordAttachmentID = sentMessage.Attachments[idx].ID
+		if part.AttachmentID != "" && idx < len(sentMessage.Attachments) && sentMessage.Attachments[idx] != nil {
+			messageDB.TelegramAttachmentID = part.AttachmentID
+			messageDB.DestinationAttachmentID = sentMessage.Attachments[idx].ID
 		}
 
 		err := d.DBHandlers.MessageHandlers.CreateMessage(&messageDB)
@@ -195,65 +234,143 @@ func (d *BotDiscord) saveMessagesToDB(sentMessage *discordgo.Message, channelID
 }
 
 // prepareFiles - подготавливает файлы для отправки
-func prepareFiles(attachments []*discordgo.File, filesData [][]byte) []*discordgo.File {
+func prepareFiles(attachments []model.Attachment) []*discordgo.File {
 	files := make([]*discordgo.File, len(attachments))
-	for i, fileData := range filesData {
+	for i, attachment := range attachments {
 		files[i] = &discordgo.File{
-			Name:   attachments[i].Name,
-			Reader: bytes.NewReader(fileData),
+			Name:   attachment.Name,
+			Reader: bytes.NewReader(attachment.Data),
 		}
 	}
 	return files
 }
 
 // SendRepostToDiscord - отправляет репост в Discord
-func (d *BotDiscord) SendRepostToDiscord(streamer *model.Streamer, repost model.DiscordRepost, attachments []*discordgo.File, messageModel []modeldb.Message) {
-	filesData := readFilesData(attachments)
-	if filesData == nil {
-		return
-	}
-
-	d.sendWithSession(streamer, func(session *discordgo.Session) error {
-		embed := buildRepostEmbed(repost)
+func (d *BotDiscord) SendRepostToDiscord(streamer *model.Streamer, post *model.Post) {
+	err := d.sendWithSession(streamer, func(session *discordgo.Session) error {
 		for _, discordChannel := range streamer.DiscordChannels {
-			files := prepareFiles(attachments, filesData)
+			if !isRouted(streamer, discordChannel, post) || d.holdIfPaused(streamer, discordChannel, post) || d.delayIfNeeded(streamer, discordChannel, post) {
+				continue
+			}
+
+			embeds := buildRepostEmbeds(post, formatText(streamer, discordChannel, post))
+
+			files := prepareFiles(post.Attachments)
+			// Аватар добавляется последним, чтобы не сбить соответствие вложений записям в базе
+			if len(post.Repost.AuthorAvatar) > 0 {
+				files = append(files, &discordgo.File{
+					Name:   RepostAvatarName,
+					Reader: bytes.NewReader(post.Repost.AuthorAvatar),
+				})
+			}
 			sentMessage, err := session.ChannelMessageSendComplex(discordChannel.ChannelID, &discordgo.MessageSend{
-				Files: files,
-				Embed: embed,
+				Files:  files,
+				Embeds: embeds,
 			})
 			if err != nil {
-				return fmt.Errorf("ошибка отправки сообщения на канал %s: %v", discordChannel.ChannelID, err)
+				d.channelFailed(streamer, discordChannel.ChannelID, post, err)
+				continue
 			}
 
-			d.saveMessagesToDB(sentMessage, discordChannel.ChannelID, messageModel)
+			d.saveMessagesToDB(sentMessage, discordChannel.ChannelID, post)
 			logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Сообщения от %s успешно отправлено в канал %s", streamer.Name, discordChannel.ChannelID))
 		}
 		return nil
 	})
+	if err != nil {
+		d.saveFailure(streamer, "", post, err)
+	}
 }
 
-// buildRepostEmbed - создает встраиваемое сообщение (embed) для репоста
-func buildRepostEmbed(repost model.DiscordRepost) *discordgo.MessageEmbed {
-	return &discordgo.MessageEmbed{
-		Author: &discordgo.MessageEmbedAuthor{
-			Name:    fmt.Sprintf("Переслано из %s", repost.ChannelName),
-			IconURL: repost.ChannelAvatar,
-			URL:     repost.RepostLink,
-		},
-		Description: repost.MessageContent,
+// buildRepostEmbeds - создает встраиваемые сообщения (embeds) для репоста.
+// Discord объединяет в сетку до четырех изображений из embeds с одинаковым URL,
+// поэтому фото альбома раскладываются по отдельным embeds, а видео и документы
+// остаются обычными вложениями под галереей
+func buildRepostEmbeds(post *model.Post, description string) []*discordgo.MessageEmbed {
+	repost := post.Repost
+
+	authorLink := repost.Link
+	if authorLink == "" {
+		authorLink = repost.AuthorLink
+	}
+
+	author := &discordgo.MessageEmbedAuthor{
+		Name: formatRepostAuthor(repost),
+		URL:  authorLink,
+	}
+	if len(repost.AuthorAvatar) > 0 {
+		author.IconURL = "attachment://" + RepostAvatarName
+	}
+
+	// Без общего URL галерея не собирается, поэтому для закрытых каналов подставляем ссылку на Telegram
+	galleryURL := repost.Link
+	if galleryURL == "" {
+		galleryURL = "https://t.me/"
+	}
+
+	if description == "" {
+		description = RepostEmptyContent
+	}
+
+	embeds := []*discordgo.MessageEmbed{{
+		Author:      author,
+		Description: description,
 		Color:       1796358,
-		Image: &discordgo.MessageEmbedImage{
-			URL: repost.PhotoLink,
-		},
-		URL: repost.RepostLink,
+		URL:         galleryURL,
+	}}
+	if !repost.Date.IsZero() {
+		embeds[0].Timestamp = repost.Date.Format(time.RFC3339)
+		embeds[0].Footer = &discordgo.MessageEmbedFooter{Text: "Опубликовано в оригинале"}
+	}
+
+	// Фото загружаются вместе с сообщением, поэтому ссылки не устаревают и не содержат токен бота
+	var photos int
+	for _, attachment := range post.Attachments {
+		if attachment.Kind != model.AttachmentPhoto {
+			continue
+		}
+		if photos >= GalleryLimit {
+			break
+		}
+
+		image := &discordgo.MessageEmbedImage{
+			URL: "attachment://" + attachment.Name,
+		}
+		if photos == 0 {
+			embeds[0].Image = image
+		} else {
+			embeds = append(embeds, &discordgo.MessageEmbed{
+				URL:   galleryURL,
+				Image: image,
+			})
+		}
+		photos++
+	}
+
+	return embeds
+}
+
+// formatRepostAuthor - возвращает подпись автора репоста в зависимости от источника пересылки
+func formatRepostAuthor(repost *model.RepostOrigin) string {
+	switch repost.Type {
+	case model.RepostOriginUser, model.RepostOriginHiddenUser:
+		return fmt.Sprintf("Переслано от %s", repost.AuthorName)
+	default:
+		return fmt.Sprintf("Переслано из %s", repost.AuthorName)
 	}
 }
 
 // EditMessageOnDiscord - редактирует сообщение в Discord
-func (d *BotDiscord) EditMessageOnDiscord(streamer *model.Streamer, channel *model.DiscordChannel, message, msgID string) {
+func (d *BotDiscord) EditMessageOnDiscord(streamer *model.Streamer, channel *model.DiscordChannel, post *model.Post, msgID string) {
 	d.sendWithSession(streamer, func(session *discordgo.Session) error {
-		content := formatPrefix(channel.Prefix) + "\n" + message
-		_, err := session.ChannelMessageEdit(channel.ChannelID, msgID, content)
+		text := formatText(streamer, *channel, post)
+
+		var err error
+		if post.Repost != nil {
+			err = editRepostDescription(session, channel.ChannelID, msgID, text)
+		} else {
+			_, err = session.ChannelMessageEdit(channel.ChannelID, msgID, withPrefix(channel.Prefix, text))
+		}
 		if err != nil {
 			return fmt.Errorf("ошибка изменения сообщения на канале %s: %v", channel.ChannelID, err)
 		}
@@ -262,6 +379,29 @@ func (d *BotDiscord) EditMessageOnDiscord(streamer *model.Streamer, channel *mod
 	})
 }
 
+// editRepostDescription - меняет текст репоста, сохраняя остальные embeds галереи
+func editRepostDescription(session *discordgo.Session, channelID, msgID, text string) error {
+	message, err := session.ChannelMessage(channelID, msgID)
+	if err != nil {
+		return err
+	}
+	if len(message.Embeds) == 0 {
+		return fmt.Errorf("у сообщения %s нет embed репоста", msgID)
+	}
+
+	if text == "" {
+		text = RepostEmptyContent
+	}
+	message.Embeds[0].Description = text
+
+	_, err = session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel: channelID,
+		ID:      msgID,
+		Embeds:  &message.Embeds,
+	})
+	return err
+}
+
 // DeleteMessageFromDiscord - удаляет сообщение из Discord
 func (d *BotDiscord) DeleteMessageFromDiscord(streamer *model.Streamer, channelID, msgID string) {
 	d.sendWithSession(streamer, func(session *discordgo.Session) error {
@@ -274,10 +414,74 @@ func (d *BotDiscord) DeleteMessageFromDiscord(streamer *model.Streamer, channelI
 	})
 }
 
+// formatText - применяет преобразования стримера и канала к тексту поста и переводит его в Markdown
+func formatText(streamer *model.Streamer, channel model.DiscordChannel, post *model.Post) string {
+	rules := make([]model.RewriteRule, 0, len(streamer.Rewrite)+len(channel.Rewrite))
+	rules = append(rules, streamer.Rewrite...)
+	rules = append(rules, channel.Rewrite...)
+
+	text, entities := rewrite.Apply(rules, post.Text, post.Entities)
+	return format.Markdown(text, entities)
+}
+
+// isRouted - применяет правила канала к посту и записывает в лог причину пропуска
+func isRouted(streamer *model.Streamer, channel model.DiscordChannel, post *model.Post) bool {
+	decision := routing.Evaluate(channel.Rules, post)
+	if !decision.Send {
+		logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Пост %d от %s пропущен для канала %s: %s", post.MessageID, streamer.Name, channel.ChannelID, decision.Reason))
+	}
+	return decision.Send
+}
+
+// holdIfPaused - откладывает или отбрасывает пост для канала на паузе
+func (d *BotDiscord) holdIfPaused(streamer *model.Streamer, channel model.DiscordChannel, post *model.Post) bool {
+	if !channel.Paused {
+		return false
+	}
+	d.pauseQueue.Hold(streamer, channel.ChannelID, post)
+	return true
+}
+
+// Pin - закрепляет или открепляет копии поста во всех каналах Discord стримера
+func (d *BotDiscord) Pin(streamer *model.Streamer, post *model.Post, pinned bool) {
+	d.sendWithSession(streamer, func(session *discordgo.Session) error {
+		for _, channel := range streamer.DiscordChannels {
+			messages, err := d.DBHandlers.MessageHandlers.GetMessageByID(channel.ChannelID, post.MessageID)
+			if err != nil || len(messages) == 0 {
+				continue
+			}
+
+			msgID := messages[0].DestinationMsgID
+			if pinned {
+				err = session.ChannelMessagePin(channel.ChannelID, msgID)
+			} else {
+				err = session.ChannelMessageUnpin(channel.ChannelID, msgID)
+			}
+			if err != nil {
+				logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка закрепления сообщения %s в канале %s: %v", msgID, channel.ChannelID, err))
+				continue
+			}
+			logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Сообщение %s в канале %s закреплено: %t", msgID, channel.ChannelID, pinned))
+		}
+		return nil
+	})
+}
+
 // formatPrefix - возвращает форматированный префикс для уведомлений
 func formatPrefix(prefix string) string {
+	if prefix == "" {
+		return "" // Упоминание выключено
+	}
 	if strings.HasPrefix(prefix, "@") {
 		return prefix // Прямое использование, если это @everyone или @here
 	}
 	return fmt.Sprintf("<@&%s>", prefix) // Использование ID роли
 }
+
+// withPrefix - добавляет упоминание перед текстом, если оно задано
+func withPrefix(prefix, text string) string {
+	if prefix == "" {
+		return text
+	}
+	return formatPrefix(prefix) + "\n" + text
+}
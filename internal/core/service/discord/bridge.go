@@ -0,0 +1,249 @@
+package discord
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"regexp"
+	"slm-bot-publisher/internal/core/format"
+	"slm-bot-publisher/internal/core/model"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+	"strings"
+	"time"
+)
+
+// TelegramSender - публикация в канал Telegram для обратного моста. Интерфейс реализуется ботом Telegram,
+// чтобы пакеты discord и telegram не зависели друг от друга
+type TelegramSender interface {
+	SendPost(chatID int64, post *model.Post) ([]model.PostPart, error)
+	EditPost(chatID int64, part model.PostPart, post *model.Post) error
+	SendComment(chatID int64, replyToMsgID int, post *model.Post) (int, error)
+	DeleteMessage(chatID int64, msgID int)
+}
+
+var customEmojiRegexp = regexp.MustCompile(`<a?:(\w+):\d+>`)
+
+var attachmentClient = &http.Client{Timeout: 60 * time.Second}
+
+// StartListeners - открывает постоянные сессии Discord для стримеров с обратным мостом или переносом комментариев
+func (d *BotDiscord) StartListeners(storage *storage.Storage, sender TelegramSender) {
+	d.sessionMutex.Lock()
+	defer d.sessionMutex.Unlock()
+
+	d.sender = sender
+	for _, streamer := range storage.All() {
+		d.openListener(streamer)
+	}
+}
+
+// openListener - открывает постоянную сессию стримера, если она ему нужна. Вызывается под sessionMutex
+func (d *BotDiscord) openListener(streamer model.Streamer) {
+	hasReverseBridge := streamer.ReverseBridge != nil && streamer.ReverseBridge.DiscordChannelID != ""
+	if !hasReverseBridge && streamer.CommentBridge == nil {
+		return
+	}
+
+	session, err := d.startListener(&streamer, d.sender)
+	if err != nil {
+		logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка запуска постоянной сессии для %s: %v", streamer.Name, err))
+		return
+	}
+	d.listenerSessions[streamer.Name] = session
+
+	if hasReverseBridge {
+		logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Обратный мост для %s слушает канал %s", streamer.Name, streamer.ReverseBridge.DiscordChannelID))
+	}
+	if streamer.CommentBridge != nil {
+		logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Перенос комментариев для %s включен", streamer.Name))
+	}
+}
+
+func (d *BotDiscord) startListener(streamer *model.Streamer, sender TelegramSender) (*discordgo.Session, error) {
+	session, err := discordgo.New("Bot " + streamer.DiscordBotToken)
+	if err != nil {
+		return nil, err
+	}
+	// Без привилегированного намерения Discord присылает события с пустым текстом сообщения
+	session.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentMessageContent
+
+	channelID := ""
+	if streamer.ReverseBridge != nil {
+		channelID = streamer.ReverseBridge.DiscordChannelID
+	}
+
+	session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		if !isBridgeable(s, m.Message) {
+			return
+		}
+		if channelID != "" && m.ChannelID == channelID {
+			d.bridgeCreate(s, streamer, sender, m.Message)
+			return
+		}
+		if streamer.CommentBridge != nil {
+			d.bridgeComment(s, streamer, sender, m.Message)
+		}
+	})
+	if channelID != "" {
+		session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageUpdate) {
+			// Обновления без отметки о правке приходят при подгрузке превью ссылок
+			if m.ChannelID != channelID || m.EditedTimestamp == nil {
+				return
+			}
+			d.bridgeEdit(s, streamer, sender, m.Message)
+		})
+		session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageDelete) {
+			if m.ChannelID != channelID {
+				return
+			}
+			d.bridgeDelete(streamer, sender, m.ChannelID, m.ID)
+		})
+	}
+
+	if err = session.Open(); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// isBridgeable - отсекает сообщения ботов и вебхуков, в том числе копии постов Telegram, чтобы мост не зацикливался
+func isBridgeable(s *discordgo.Session, message *discordgo.Message) bool {
+	if message.Author == nil || message.Author.Bot || message.WebhookID != "" {
+		return false
+	}
+	if s.State != nil && s.State.User != nil && message.Author.ID == s.State.User.ID {
+		return false
+	}
+	return message.Type == discordgo.MessageTypeDefault || message.Type == discordgo.MessageTypeReply
+}
+
+func (d *BotDiscord) bridgeCreate(s *discordgo.Session, streamer *model.Streamer, sender TelegramSender, message *discordgo.Message) {
+	post := buildBridgePost(s, message)
+	post.Attachments = downloadAttachments(message.Attachments)
+	if post.Text == "" && len(post.Attachments) == 0 {
+		return
+	}
+
+	parts, err := sender.SendPost(streamer.TelegramChannelID, post)
+	if err != nil {
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки сообщения Discord %s в Telegram для %s: %v", message.ID, streamer.Name, err))
+	}
+
+	// Даже частично отправленный пост сохраняется, чтобы его можно было изменить и удалить
+	for idx, part := range parts {
+		messageDB := modeldb.Message{
+			Platform:             model.DestinationDiscord,
+			Direction:            modeldb.DirectionIncoming,
+			MainPost:             idx == 0,
+			ChannelID:            message.ChannelID,
+			TelegramChatID:       streamer.TelegramChannelID,
+			TelegramMsgID:        part.MessageID,
+			DestinationMsgID:     message.ID,
+			TelegramAttachmentID: part.AttachmentID,
+		}
+
+		if err = d.DBHandlers.MessageHandlers.CreateMessage(&messageDB); err != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения сообщения %d в базу", messageDB.TelegramMsgID))
+		}
+	}
+
+	if len(parts) > 0 {
+		logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Сообщение %s от %s перенесено в Telegram", message.ID, streamer.Name))
+	}
+}
+
+func (d *BotDiscord) bridgeEdit(s *discordgo.Session, streamer *model.Streamer, sender TelegramSender, message *discordgo.Message) {
+	// Записи есть только у сообщений, перенесенных мостом, поэтому копии постов Telegram здесь не изменяются
+	messages, err := d.DBHandlers.MessageHandlers.GetIncomingMessages(message.ChannelID, message.ID)
+	if err != nil || len(messages) == 0 {
+		return
+	}
+
+	main := messages[0]
+	for _, msg := range messages {
+		if msg.MainPost {
+			main = msg
+		}
+	}
+
+	part := model.PostPart{MessageID: main.TelegramMsgID, AttachmentID: main.TelegramAttachmentID}
+	if err = sender.EditPost(streamer.TelegramChannelID, part, buildBridgePost(s, message)); err != nil {
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка изменения сообщения %d для %s: %v", main.TelegramMsgID, streamer.Name, err))
+		return
+	}
+	logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Изменение сообщения %s от %s перенесено в Telegram", message.ID, streamer.Name))
+}
+
+func (d *BotDiscord) bridgeDelete(streamer *model.Streamer, sender TelegramSender, channelID, messageID string) {
+	messages, err := d.DBHandlers.MessageHandlers.GetIncomingMessages(channelID, messageID)
+	if err != nil || len(messages) == 0 {
+		return
+	}
+
+	for _, msg := range messages {
+		sender.DeleteMessage(streamer.TelegramChannelID, msg.TelegramMsgID)
+	}
+
+	if err = d.DBHandlers.MessageHandlers.DeleteIncomingMessages(channelID, messageID); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Не удалось удалить сообщения с ID Discord %s", messageID))
+	}
+	logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Сообщение %s от %s удалено из Telegram", messageID, streamer.Name))
+}
+
+// buildBridgePost - переводит текст сообщения Discord с упоминаниями и разметкой в пост с сущностями Telegram
+func buildBridgePost(s *discordgo.Session, message *discordgo.Message) *model.Post {
+	content, err := message.ContentWithMoreMentionsReplaced(s)
+	if err != nil {
+		content = message.ContentWithMentionsReplaced()
+	}
+	content = customEmojiRegexp.ReplaceAllString(content, ":$1:")
+
+	text, entities := format.ParseDiscordMarkdown(content)
+	return &model.Post{
+		Text:     strings.TrimSpace(text),
+		Entities: entities,
+	}
+}
+
+// downloadAttachments - скачивает вложения сообщения Discord для повторной загрузки в Telegram
+func downloadAttachments(attachments []*discordgo.MessageAttachment) []model.Attachment {
+	var downloaded []model.Attachment
+	for _, attachment := range attachments {
+		resp, err := attachmentClient.Get(attachment.URL)
+		if err != nil {
+			logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка скачивания вложения %s: %v", attachment.Filename, err))
+			continue
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Не удалось скачать вложение %s: статус %d", attachment.Filename, resp.StatusCode))
+			continue
+		}
+
+		downloaded = append(downloaded, model.Attachment{
+			Kind: attachmentKind(attachment.ContentType),
+			Name: attachment.Filename,
+			Data: data,
+		})
+	}
+	return downloaded
+}
+
+// attachmentKind - определяет тип вложения; GIF отправляется документом, чтобы не потерять анимацию
+func attachmentKind(contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "image/") && contentType != "image/gif":
+		return model.AttachmentPhoto
+	case strings.HasPrefix(contentType, "video/"):
+		return model.AttachmentVideo
+	case strings.HasPrefix(contentType, "audio/"):
+		return model.AttachmentAudio
+	default:
+		return model.AttachmentDocument
+	}
+}
@@ -0,0 +1,240 @@
+package discord
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"slm-bot-publisher/internal/core/format"
+	"slm-bot-publisher/internal/core/model"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/logging"
+	"strings"
+)
+
+const (
+	CommentWebhookName = "SLM Comments"
+	// Ограничения Discord на имя автора вебхука и длину сообщения
+	WebhookUsernameLimit = 80
+	MessageLimit         = 2000
+	// Сколько символов комментария цитируется в ответе
+	ReplyQuoteLength = 100
+)
+
+// Discord отклоняет имена вебхуков с этими словами
+var forbiddenUsernameRegexp = regexp.MustCompile(`(?i)discord|clyde`)
+
+// PublishComment - публикует комментарий из Telegram в ветки Discord под копиями поста от имени автора
+func (d *BotDiscord) PublishComment(streamer *model.Streamer, comment *model.Comment) {
+	session, exists := d.listenerSession(streamer.Name)
+	if !exists {
+		logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Нет постоянной сессии Discord для переноса комментариев %s", streamer.Name))
+		return
+	}
+
+	params := &discordgo.WebhookParams{
+		Content:         buildCommentContent(comment),
+		Username:        webhookUsername(comment.AuthorName),
+		AvatarURL:       d.avatarURL(comment.AuthorAvatar),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+
+	for _, channel := range streamer.DiscordChannels {
+		messages, err := d.DBHandlers.MessageHandlers.GetMessageByID(channel.ChannelID, comment.TelegramMsgID)
+		if err != nil || len(messages) == 0 {
+			continue
+		}
+		// Ветка создается из сообщения поста, поэтому ее ID совпадает с ID сообщения
+		threadID := messages[0].DestinationMsgID
+
+		webhook, err := d.commentWebhook(session, channel.ChannelID)
+		if err != nil {
+			logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения вебхука для канала %s: %v", channel.ChannelID, err))
+			continue
+		}
+
+		// Файлы читаются при отправке, поэтому для каждого канала готовятся заново
+		params.Files = prepareFiles(comment.Attachments)
+		sentMessage, err := session.WebhookThreadExecute(webhook.ID, webhook.Token, true, threadID, params)
+		if err != nil {
+			logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки комментария в ветку %s: %v", threadID, err))
+			continue
+		}
+
+		commentDB := modeldb.Comment{
+			Direction:        modeldb.DirectionOutgoing,
+			TelegramChatID:   comment.TelegramChatID,
+			TelegramMsgID:    comment.TelegramMsgID,
+			DiscussionChatID: comment.DiscussionChatID,
+			DiscussionMsgID:  comment.DiscussionMsgID,
+			DiscordThreadID:  threadID,
+			DiscordMsgID:     sentMessage.ID,
+		}
+		if err = d.DBHandlers.CommentHandlers.CreateComment(&commentDB); err != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения комментария %d в базу", comment.DiscussionMsgID))
+		}
+		logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Комментарий к посту %d перенесен в ветку %s", comment.TelegramMsgID, threadID))
+	}
+}
+
+// bridgeComment - отправляет ответ из ветки Discord в группу обсуждения ответом на копию поста или комментарий
+func (d *BotDiscord) bridgeComment(s *discordgo.Session, streamer *model.Streamer, sender TelegramSender, message *discordgo.Message) {
+	// Ветки под постами имеют тот же ID, что и сообщение поста
+	postMessage, err := d.DBHandlers.MessageHandlers.GetMessageByDestinationID(model.DestinationDiscord, message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	telegramChatID := postMessage.TelegramChatID
+	if telegramChatID == 0 {
+		telegramChatID = streamer.TelegramChannelID
+	}
+
+	discussionPost, err := d.DBHandlers.CommentHandlers.GetDiscussionPost(telegramChatID, postMessage.TelegramMsgID)
+	if err != nil {
+		logging.Log("Discord", logrus.DebugLevel, fmt.Sprintf("Копия поста %d в группе обсуждения не найдена", postMessage.TelegramMsgID))
+		return
+	}
+
+	replyToMsgID := discussionPost.DiscussionMsgID
+	if message.MessageReference != nil && message.MessageReference.MessageID != "" {
+		if parent, err := d.DBHandlers.CommentHandlers.GetCommentByDiscordMsg(message.ChannelID, message.MessageReference.MessageID); err == nil {
+			replyToMsgID = parent.DiscussionMsgID
+		}
+	}
+
+	post := buildCommentPost(s, message)
+	if post.Text == "" {
+		return
+	}
+
+	discussionMsgID, err := sender.SendComment(discussionPost.DiscussionChatID, replyToMsgID, post)
+	if err != nil {
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки комментария из Discord в группу обсуждения %s: %v", streamer.Name, err))
+		return
+	}
+
+	commentDB := modeldb.Comment{
+		Direction:        modeldb.DirectionIncoming,
+		TelegramChatID:   telegramChatID,
+		TelegramMsgID:    postMessage.TelegramMsgID,
+		DiscussionChatID: discussionPost.DiscussionChatID,
+		DiscussionMsgID:  discussionMsgID,
+		DiscordThreadID:  message.ChannelID,
+		DiscordMsgID:     message.ID,
+	}
+	if err = d.DBHandlers.CommentHandlers.CreateComment(&commentDB); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения комментария %s в базу", message.ID))
+	}
+	logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Комментарий из ветки %s перенесен в Telegram", message.ChannelID))
+}
+
+// commentWebhook - возвращает вебхук бота в канале, создавая его при первом комментарии
+func (d *BotDiscord) commentWebhook(session *discordgo.Session, channelID string) (*discordgo.Webhook, error) {
+	d.commentWebhookMutex.Lock()
+	defer d.commentWebhookMutex.Unlock()
+
+	if webhook, exists := d.commentWebhooks[channelID]; exists {
+		return webhook, nil
+	}
+
+	webhooks, err := session.ChannelWebhooks(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Токен доступен только у вебхуков, созданных этим ботом
+	for _, webhook := range webhooks {
+		if webhook.Name == CommentWebhookName && webhook.Token != "" {
+			d.commentWebhooks[channelID] = webhook
+			return webhook, nil
+		}
+	}
+
+	webhook, err := session.WebhookCreate(channelID, CommentWebhookName, "")
+	if err != nil {
+		return nil, err
+	}
+	d.commentWebhooks[channelID] = webhook
+	return webhook, nil
+}
+
+// avatarURL - публикует аватар автора через хранилище медиа, чтобы не раскрывать ссылку с токеном бота
+func (d *BotDiscord) avatarURL(avatar []byte) string {
+	if len(avatar) == 0 || !d.mediaStore.Enabled() {
+		return ""
+	}
+
+	url, err := d.mediaStore.Save(model.Attachment{Kind: model.AttachmentPhoto, Name: RepostAvatarName, Data: avatar})
+	if err != nil {
+		logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения аватара автора комментария: %v", err))
+		return ""
+	}
+	return url
+}
+
+func buildCommentContent(comment *model.Comment) string {
+	content := format.Markdown(comment.Text, comment.Entities)
+
+	if comment.ReplyToText != "" {
+		quote := []rune(strings.Join(strings.Fields(comment.ReplyToText), " "))
+		if len(quote) > ReplyQuoteLength {
+			quote = append(quote[:ReplyQuoteLength-1], '…')
+		}
+
+		// Имя автора и цитата - обычный текст, поэтому разметка в них экранируется
+		header := "> "
+		if author := strings.Join(strings.Fields(comment.ReplyToAuthor), " "); author != "" {
+			header += "**" + format.EscapeMarkdown(author) + "**: "
+		}
+		content = header + format.EscapeMarkdown(string(quote)) + "\n" + content
+	}
+
+	runes := []rune(content)
+	if len(runes) > MessageLimit {
+		content = string(runes[:MessageLimit-1]) + "…"
+	}
+	return content
+}
+
+func webhookUsername(name string) string {
+	name = strings.TrimSpace(forbiddenUsernameRegexp.ReplaceAllString(name, "•"))
+	if name == "" {
+		name = "Telegram"
+	}
+
+	runes := []rune(name)
+	if len(runes) > WebhookUsernameLimit {
+		name = string(runes[:WebhookUsernameLimit])
+	}
+	return name
+}
+
+// buildCommentPost - собирает комментарий для Telegram: имя автора жирным, затем текст и ссылки на вложения
+func buildCommentPost(s *discordgo.Session, message *discordgo.Message) *model.Post {
+	post := buildBridgePost(s, message)
+	for _, attachment := range message.Attachments {
+		post.Text = strings.TrimSpace(post.Text + "\n" + attachment.URL)
+	}
+	if post.Text == "" {
+		return post
+	}
+
+	authorName := message.Author.GlobalName
+	if message.Member != nil && message.Member.Nick != "" {
+		authorName = message.Member.Nick
+	}
+	if authorName == "" {
+		authorName = message.Author.Username
+	}
+
+	prefix := authorName + ": "
+	shift := len([]rune(prefix))
+	for idx := range post.Entities {
+		post.Entities[idx].Offset += shift
+	}
+	post.Entities = append([]model.TextEntity{{Type: "bold", Offset: 0, Length: len([]rune(authorName))}}, post.Entities...)
+	post.Text = prefix + post.Text
+
+	return post
+}
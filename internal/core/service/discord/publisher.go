@@ -0,0 +1,107 @@
+package discord
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/logging"
+)
+
+// Publish - публикует пост во все каналы Discord стримера
+func (d *BotDiscord) Publish(streamer *model.Streamer, post *model.Post) {
+	if post.Repost != nil {
+		d.SendRepostToDiscord(streamer, post)
+		return
+	}
+	d.SendMessageToDiscord(streamer, post)
+}
+
+// Edit - обновляет текст опубликованного или ожидающего отправки поста во всех каналах Discord стримера
+func (d *BotDiscord) Edit(streamer *model.Streamer, post *model.Post) {
+	d.updateScheduled(post)
+
+	for _, channel := range streamer.DiscordChannels {
+		messageIDs, err := d.DBHandlers.MessageHandlers.GetMessageByID(channel.ChannelID, post.MessageID)
+		if err != nil || len(messageIDs) == 0 {
+			continue
+		}
+
+		d.EditMessageOnDiscord(streamer, &channel, post, messageIDs[0].DestinationMsgID)
+	}
+}
+
+// Delete - удаляет опубликованный пост из всех каналов Discord стримера и отменяет его отложенные отправки
+func (d *BotDiscord) Delete(streamer *model.Streamer, post *model.Post) {
+	d.cancelScheduled(post)
+
+	for _, channel := range streamer.DiscordChannels {
+		messageIDs, err := d.DBHandlers.MessageHandlers.GetMessageByID(channel.ChannelID, post.MessageID)
+		if err != nil || len(messageIDs) == 0 {
+			continue
+		}
+
+		d.DeleteMessageFromDiscord(streamer, channel.ChannelID, messageIDs[0].DestinationMsgID)
+		err = d.DBHandlers.MessageHandlers.DeleteMessageByID(channel.ChannelID, post.MessageID)
+		if err != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Не удалось удалить сообщения с ID Discord %s", messageIDs[0].DestinationMsgID))
+		}
+	}
+}
+
+// Resend - досылает пост в каналы, где нет ни его копии, ни отложенной отправки. Для поста без упоминания
+// копии в каналах с упоминанием удаляются и отправляются заново; задержка канала при этом не действует
+func (d *BotDiscord) Resend(streamer *model.Streamer, post *model.Post) {
+	pending := d.pendingChannels(post)
+
+	target := *streamer
+	target.DiscordChannels = nil
+	for _, channel := range streamer.DiscordChannels {
+		if pending[channel.ChannelID] {
+			continue
+		}
+
+		messageIDs, err := d.DBHandlers.MessageHandlers.GetMessageByID(channel.ChannelID, post.MessageID)
+		if err == nil && len(messageIDs) > 0 {
+			if !post.Silent || channel.Prefix == "" {
+				continue
+			}
+			d.DeleteMessageFromDiscord(streamer, channel.ChannelID, messageIDs[0].DestinationMsgID)
+			if err = d.DBHandlers.MessageHandlers.DeleteMessageByID(channel.ChannelID, post.MessageID); err != nil {
+				logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Не удалось удалить сообщения с ID Discord %s", messageIDs[0].DestinationMsgID))
+			}
+		}
+
+		channel.Delay = ""
+		target.DiscordChannels = append(target.DiscordChannels, channel)
+	}
+
+	if len(target.DiscordChannels) == 0 {
+		logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Пост %d от %s уже есть во всех каналах Discord", post.MessageID, streamer.Name))
+		return
+	}
+	d.Publish(&target, post)
+}
+
+// pendingChannels - каналы, в которые пост еще будет отправлен: по расписанию или после снятия паузы канала
+func (d *BotDiscord) pendingChannels(post *model.Post) map[string]bool {
+	pending := make(map[string]bool)
+
+	scheduledPosts, err := d.DBHandlers.ScheduleHandlers.GetScheduledPostsByTelegramID(post.ChatID, post.MessageID)
+	if err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения отложенных отправок поста %d: %v", post.MessageID, err))
+	}
+	for _, scheduledPost := range scheduledPosts {
+		pending[scheduledPost.ChannelID] = true
+	}
+
+	queuedPosts, err := d.DBHandlers.QueueHandlers.GetQueuedPostsByTelegramID(post.ChatID, post.MessageID)
+	if err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения отложенных копий поста %d: %v", post.MessageID, err))
+	}
+	for _, queuedPost := range queuedPosts {
+		if queuedPost.ChannelID != "" {
+			pending[queuedPost.ChannelID] = true
+		}
+	}
+	return pending
+}
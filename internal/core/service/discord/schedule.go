@@ -0,0 +1,165 @@
+package discord
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+	"time"
+)
+
+// StartScheduler - запускает горутину, которая отправляет отложенные посты, когда наступает их время.
+// Отправки хранятся в базе, поэтому переживают перезапуск бота
+func (d *BotDiscord) StartScheduler(storage *storage.Storage, interval time.Duration) {
+	go func() {
+		for {
+			d.sendDuePosts(storage)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+func (d *BotDiscord) sendDuePosts(storage *storage.Storage) {
+	scheduledPosts, err := d.DBHandlers.ScheduleHandlers.GetDueScheduledPosts(time.Now())
+	if err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения отложенных постов: %v", err))
+		return
+	}
+
+	for _, scheduledPost := range scheduledPosts {
+		d.sendScheduledPost(storage, scheduledPost)
+
+		if err = d.DBHandlers.ScheduleHandlers.DeleteScheduledPost(scheduledPost.ID); err != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка удаления отложенного поста %d: %v", scheduledPost.TelegramMsgID, err))
+		}
+	}
+}
+
+func (d *BotDiscord) sendScheduledPost(storage *storage.Storage, scheduledPost modeldb.ScheduledPost) {
+	streamer := storage.GetStreamerByName(scheduledPost.StreamerName)
+	if streamer == nil {
+		logging.Log("Discord", logrus.WarnLevel, fmt.Sprintf("Стример %s отложенного поста %d не найден", scheduledPost.StreamerName, scheduledPost.TelegramMsgID))
+		return
+	}
+
+	var post model.Post
+	if err := json.Unmarshal([]byte(scheduledPost.Payload), &post); err != nil {
+		logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка чтения отложенного поста %d: %v", scheduledPost.TelegramMsgID, err))
+		return
+	}
+
+	for _, channel := range streamer.DiscordChannels {
+		if channel.ChannelID != scheduledPost.ChannelID {
+			continue
+		}
+
+		// Во время паузы стримера пост ждет в очереди канала и после возобновления уйдет только в него
+		if streamer.Paused {
+			d.pauseQueue.Hold(streamer, channel.ChannelID, &post)
+			return
+		}
+
+		// Пост отправляется только в свой канал и уже без задержки
+		channel.Delay = ""
+		channelStreamer := *streamer
+		channelStreamer.DiscordChannels = []model.DiscordChannel{channel}
+		d.Publish(&channelStreamer, &post)
+		return
+	}
+
+	logging.Log("Discord", logrus.WarnLevel, fmt.Sprintf("Канал %s отложенного поста %d больше не настроен у %s", scheduledPost.ChannelID, scheduledPost.TelegramMsgID, streamer.Name))
+}
+
+// SchedulePost - переносит отправку поста во все каналы Discord стримера на время sendAt.
+// Уже опубликованные копии удаляются, прежние отложенные отправки заменяются. Возвращает число каналов
+func (d *BotDiscord) SchedulePost(streamer *model.Streamer, post *model.Post, sendAt time.Time) (int, error) {
+	d.Delete(streamer, post)
+
+	scheduled := 0
+	for _, channel := range streamer.DiscordChannels {
+		if !isRouted(streamer, channel, post) {
+			continue
+		}
+		if err := d.schedule(streamer, channel, post, sendAt); err != nil {
+			return scheduled, err
+		}
+		scheduled++
+	}
+	return scheduled, nil
+}
+
+// delayIfNeeded - откладывает пост, если у канала задана задержка публикации
+func (d *BotDiscord) delayIfNeeded(streamer *model.Streamer, channel model.DiscordChannel, post *model.Post) bool {
+	delay := channel.PublishDelay()
+	if delay == 0 {
+		return false
+	}
+
+	if err := d.schedule(streamer, channel, post, time.Now().Add(delay)); err != nil {
+		// Лучше опубликовать пост сразу, чем потерять его
+		logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка откладывания поста %d для канала %s: %v", post.MessageID, channel.ChannelID, err))
+		return false
+	}
+	return true
+}
+
+func (d *BotDiscord) schedule(streamer *model.Streamer, channel model.DiscordChannel, post *model.Post, sendAt time.Time) error {
+	payload, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+
+	scheduledPost := modeldb.ScheduledPost{
+		StreamerName:   streamer.Name,
+		ChannelID:      channel.ChannelID,
+		TelegramChatID: post.ChatID,
+		TelegramMsgID:  post.MessageID,
+		Payload:        string(payload),
+		SendAt:         sendAt,
+	}
+	if err = d.DBHandlers.ScheduleHandlers.CreateScheduledPost(&scheduledPost); err != nil {
+		return err
+	}
+
+	logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Пост %d от %s будет отправлен в канал %s в %s", post.MessageID, streamer.Name, channel.ChannelID, sendAt.Format("2006-01-02 15:04:05")))
+	return nil
+}
+
+// updateScheduled - переносит правку поста в его отложенные отправки, вложения остаются прежними
+func (d *BotDiscord) updateScheduled(post *model.Post) {
+	scheduledPosts, err := d.DBHandlers.ScheduleHandlers.GetScheduledPostsByTelegramID(post.ChatID, post.MessageID)
+	if err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения отложенных отправок поста %d: %v", post.MessageID, err))
+		return
+	}
+
+	for _, scheduledPost := range scheduledPosts {
+		var pending model.Post
+		if err = json.Unmarshal([]byte(scheduledPost.Payload), &pending); err != nil {
+			continue
+		}
+		pending.Text = post.Text
+		pending.Entities = post.Entities
+
+		payload, err := json.Marshal(pending)
+		if err != nil {
+			continue
+		}
+		scheduledPost.Payload = string(payload)
+		if err = d.DBHandlers.ScheduleHandlers.UpdateScheduledPost(&scheduledPost); err != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка обновления отложенного поста %d: %v", post.MessageID, err))
+			continue
+		}
+		logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Правка поста %d перенесена в отложенную отправку в канал %s", post.MessageID, scheduledPost.ChannelID))
+	}
+}
+
+// cancelScheduled - отменяет отложенные отправки удаленного поста
+func (d *BotDiscord) cancelScheduled(post *model.Post) {
+	if err := d.DBHandlers.ScheduleHandlers.DeleteScheduledPostsByTelegramID(post.ChatID, post.MessageID); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка отмены отложенных отправок поста %d: %v", post.MessageID, err))
+	}
+}
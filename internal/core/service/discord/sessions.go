@@ -0,0 +1,63 @@
+package discord
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/logging"
+)
+
+// SetStreamer - применяет новые настройки стримера без перезапуска: обновляет токен для публикации
+// и переоткрывает постоянную сессию обратного моста и комментариев
+func (d *BotDiscord) SetStreamer(streamer model.Streamer) {
+	d.sessionMutex.Lock()
+	defer d.sessionMutex.Unlock()
+
+	d.SessionCreators[streamer.Name] = newSessionCreator(streamer)
+	d.closeListener(streamer.Name)
+	// До запуска бота постоянные сессии откроет StartListeners
+	if d.sender != nil {
+		d.openListener(streamer)
+	}
+	logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Настройки Discord для %s обновлены", streamer.Name))
+}
+
+// RemoveStreamer - закрывает сессии удаленного стримера
+func (d *BotDiscord) RemoveStreamer(name string) {
+	d.sessionMutex.Lock()
+	defer d.sessionMutex.Unlock()
+
+	delete(d.SessionCreators, name)
+	d.closeListener(name)
+	logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Сессии Discord для %s закрыты", name))
+}
+
+func (d *BotDiscord) sessionCreator(name string) (func() (*discordgo.Session, error), bool) {
+	d.sessionMutex.RLock()
+	defer d.sessionMutex.RUnlock()
+
+	sessionCreator, exists := d.SessionCreators[name]
+	return sessionCreator, exists
+}
+
+func (d *BotDiscord) listenerSession(name string) (*discordgo.Session, bool) {
+	d.sessionMutex.RLock()
+	defer d.sessionMutex.RUnlock()
+
+	session, exists := d.listenerSessions[name]
+	return session, exists
+}
+
+// closeListener - закрывает постоянную сессию стримера. Вызывается под sessionMutex
+func (d *BotDiscord) closeListener(name string) {
+	session, exists := d.listenerSessions[name]
+	if !exists {
+		return
+	}
+
+	if err := session.Close(); err != nil {
+		logging.Log("Discord", logrus.WarnLevel, fmt.Sprintf("Ошибка закрытия постоянной сессии для %s: %v", name, err))
+	}
+	delete(d.listenerSessions, name)
+}
@@ -0,0 +1,86 @@
+package discord
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/logging"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status - возвращает состояние подключения стримера к Discord
+func (d *BotDiscord) Status(name string) model.ConnectionStatus {
+	d.statusMutex.Lock()
+	status := d.statuses[name]
+	d.statusMutex.Unlock()
+
+	if session, exists := d.listenerSession(name); exists {
+		status.Listening = session.DataReady
+	}
+	return status
+}
+
+// MessageLink - возвращает ссылку на сообщение в канале Discord. ID сервера канала запрашивается
+// один раз и запоминается; если его узнать не удалось, возвращается пустая строка
+func (d *BotDiscord) MessageLink(streamer *model.Streamer, channelID, msgID string) string {
+	guildID, cached := d.guildIDs.Load(channelID)
+	if !cached {
+		// Для запроса канала достаточно REST, постоянное подключение не открывается
+		session, err := discordgo.New("Bot " + streamer.DiscordBotToken)
+		if err != nil {
+			return ""
+		}
+		channel, err := session.Channel(channelID)
+		if err != nil {
+			logging.Log("Discord", logrus.WarnLevel, fmt.Sprintf("Не удалось получить сервер канала %s: %v", channelID, err))
+			return ""
+		}
+		guildID, _ = d.guildIDs.LoadOrStore(channelID, channel.GuildID)
+	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, msgID)
+}
+
+func (d *BotDiscord) markSuccess(name string) {
+	d.statusMutex.Lock()
+	defer d.statusMutex.Unlock()
+
+	status := d.statuses[name]
+	status.LastSuccess = time.Now()
+	d.statuses[name] = status
+}
+
+func (d *BotDiscord) markFailure(name string, err error) {
+	d.statusMutex.Lock()
+	defer d.statusMutex.Unlock()
+
+	status := d.statuses[name]
+	status.LastError, status.LastErrorAt = err.Error(), time.Now()
+	d.statuses[name] = status
+}
+
+// saveFailure - запоминает пост, который не удалось отправить, чтобы его можно было отправить повторно.
+// Пустой channelID означает, что пост не попал ни в один канал
+func (d *BotDiscord) saveFailure(streamer *model.Streamer, channelID string, post *model.Post, err error) {
+	d.markFailure(streamer.Name, err)
+
+	msgIDs := make([]string, 0, len(post.Parts))
+	for _, part := range post.Parts {
+		msgIDs = append(msgIDs, strconv.Itoa(part.MessageID))
+	}
+
+	delivery := modeldb.FailedDelivery{
+		StreamerName:   streamer.Name,
+		ChannelID:      channelID,
+		TelegramChatID: post.ChatID,
+		TelegramMsgID:  post.MessageID,
+		TelegramMsgIDs: strings.Join(msgIDs, ","),
+		Error:          err.Error(),
+	}
+	if err = d.DBHandlers.DeliveryHandlers.CreateFailedDelivery(&delivery); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения неудачной отправки поста %d в базу", post.MessageID))
+	}
+}
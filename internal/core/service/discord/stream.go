@@ -0,0 +1,122 @@
+package discord
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/logging"
+	"strings"
+	"time"
+)
+
+const (
+	TwitchLiveColor  = 9520895
+	TwitchEndedColor = 7506394
+)
+
+// SendStreamAnnouncement - отправляет анонс начала трансляции в каналы Discord и возвращает ID сообщений по ID каналов
+func (d *BotDiscord) SendStreamAnnouncement(streamer *model.Streamer, channels []model.DiscordChannel, stream model.TwitchStream) map[string]string {
+	sentMessages := make(map[string]string)
+
+	d.sendWithSession(streamer, func(session *discordgo.Session) error {
+		embed := buildStreamEmbed(stream)
+		for _, discordChannel := range channels {
+			sentMessage, err := session.ChannelMessageSendComplex(discordChannel.ChannelID, &discordgo.MessageSend{
+				Content: formatPrefix(discordChannel.Prefix),
+				Embeds:  []*discordgo.MessageEmbed{embed},
+			})
+			if err != nil {
+				logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки анонса трансляции на канал %s: %v", discordChannel.ChannelID, err))
+				continue
+			}
+
+			sentMessages[discordChannel.ChannelID] = sentMessage.ID
+			logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Анонс трансляции %s отправлен в канал %s", streamer.Name, discordChannel.ChannelID))
+		}
+		return nil
+	})
+
+	return sentMessages
+}
+
+// EditStreamAnnouncement - обновляет анонс трансляции; для завершенной трансляции анонс превращается в итоги
+func (d *BotDiscord) EditStreamAnnouncement(streamer *model.Streamer, channelID, msgID string, stream model.TwitchStream) {
+	d.sendWithSession(streamer, func(session *discordgo.Session) error {
+		embeds := []*discordgo.MessageEmbed{buildStreamEmbed(stream)}
+		_, err := session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			Channel: channelID,
+			ID:      msgID,
+			Embeds:  &embeds,
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка изменения анонса трансляции на канале %s: %v", channelID, err)
+		}
+		logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Анонс трансляции %s обновлен в канале %s", msgID, channelID))
+		return nil
+	})
+}
+
+// buildStreamEmbed - создает embed анонса трансляции или итогов завершенной трансляции
+func buildStreamEmbed(stream model.TwitchStream) *discordgo.MessageEmbed {
+	displayName := stream.DisplayName
+	if displayName == "" {
+		displayName = stream.Login
+	}
+
+	gameName := stream.GameName
+	if gameName == "" {
+		gameName = "Не указана"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: stream.Title,
+		URL:   "https://twitch.tv/" + stream.Login,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Категория", Value: gameName, Inline: true},
+		},
+	}
+	if embed.Title == "" {
+		embed.Title = "Трансляция на Twitch"
+	}
+
+	if stream.EndedAt.IsZero() {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: fmt.Sprintf("%s в эфире на Twitch", displayName), URL: embed.URL}
+		embed.Color = TwitchLiveColor
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Зрители", Value: fmt.Sprintf("%d", stream.ViewerCount), Inline: true})
+		if stream.ThumbnailURL != "" {
+			// Параметр в конце ссылки не дает Discord показать закэшированное превью прошлой трансляции
+			thumbnail := strings.NewReplacer("{width}", "1280", "{height}", "720").Replace(stream.ThumbnailURL)
+			embed.Image = &discordgo.MessageEmbedImage{URL: fmt.Sprintf("%s?t=%d", thumbnail, time.Now().Unix())}
+		}
+		if !stream.StartedAt.IsZero() {
+			embed.Timestamp = stream.StartedAt.Format(time.RFC3339)
+		}
+		return embed
+	}
+
+	embed.Author = &discordgo.MessageEmbedAuthor{Name: fmt.Sprintf("Трансляция %s на Twitch завершена", displayName), URL: embed.URL}
+	embed.Color = TwitchEndedColor
+	embed.Description = "Спасибо всем, кто был на трансляции!"
+	if !stream.StartedAt.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Длительность", Value: formatDuration(stream.EndedAt.Sub(stream.StartedAt)), Inline: true})
+	}
+	if stream.PeakViewers > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Пик зрителей", Value: fmt.Sprintf("%d", stream.PeakViewers), Inline: true})
+	}
+	embed.Timestamp = stream.EndedAt.Format(time.RFC3339)
+
+	return embed
+}
+
+// formatDuration - форматирует длительность трансляции в часы и минуты
+func formatDuration(duration time.Duration) string {
+	duration = duration.Round(time.Minute)
+	hours := int(duration.Hours())
+	minutes := int(duration.Minutes()) % 60
+
+	if hours == 0 {
+		return fmt.Sprintf("%d мин", minutes)
+	}
+	return fmt.Sprintf("%d ч %d мин", hours, minutes)
+}
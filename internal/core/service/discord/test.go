@@ -0,0 +1,37 @@
+package discord
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/logging"
+)
+
+const TestMessageContent = "Проверка связи: SLM Bot Publisher может публиковать в этот канал."
+
+// SendTestMessage - отправляет проверочное сообщение в канал стримера. Упоминание показывается,
+// но никого не уведомляет, чтобы проверка не беспокоила подписчиков
+func (d *BotDiscord) SendTestMessage(streamer *model.Streamer, channel model.DiscordChannel) error {
+	sessionCreator, exists := d.sessionCreator(streamer.Name)
+	if !exists {
+		return fmt.Errorf("стример %s не найден", streamer.Name)
+	}
+
+	session, err := sessionCreator()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	_, err = session.ChannelMessageSendComplex(channel.ChannelID, &discordgo.MessageSend{
+		Content:         withPrefix(channel.Prefix, TestMessageContent),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.Log("Discord", logrus.InfoLevel, fmt.Sprintf("Проверочное сообщение отправлено в канал %s стримера %s", channel.ChannelID, streamer.Name))
+	return nil
+}
@@ -0,0 +1,367 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"html"
+	"net/http"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+	"strings"
+	"time"
+)
+
+const (
+	feedSize = 50
+	// Заголовок ленты без текста берется из первых символов поста
+	titleLength = 80
+)
+
+// Handler - раздает ленты RSS 2.0, Atom и JSON Feed по постам стримера
+type Handler struct {
+	storage    *storage.Storage
+	DBHandlers *handlers.DBHandlers
+	publicURL  string
+}
+
+func NewHandler(storage *storage.Storage, DBHandlers *handlers.DBHandlers, publicURL string) *Handler {
+	return &Handler{
+		storage:    storage,
+		DBHandlers: DBHandlers,
+		publicURL:  strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+// Register - регистрирует адреса лент на HTTP сервере
+func (h *Handler) Register(mux interface {
+	Handle(pattern string, handler http.Handler)
+}) {
+	mux.Handle("GET /feeds/{streamer}/rss.xml", http.HandlerFunc(h.serveRSS))
+	mux.Handle("GET /feeds/{streamer}/atom.xml", http.HandlerFunc(h.serveAtom))
+	mux.Handle("GET /feeds/{streamer}/feed.json", http.HandlerFunc(h.serveJSONFeed))
+}
+
+type feedItem struct {
+	id        string
+	title     string
+	link      string
+	content   string
+	text      string
+	media     []Media
+	published time.Time
+	updated   time.Time
+}
+
+// loadFeed - загружает стримера и его последние посты; для стримеров без ленты отвечает 404
+func (h *Handler) loadFeed(w http.ResponseWriter, r *http.Request) (*model.Streamer, []feedItem, bool) {
+	streamer := h.storage.GetStreamerByName(r.PathValue("streamer"))
+	if streamer == nil || len(streamer.DestinationsOf(model.DestinationFeed)) == 0 {
+		http.NotFound(w, r)
+		return nil, nil, false
+	}
+
+	posts, err := h.DBHandlers.PostHandlers.GetLatestPosts(streamer.Name, feedSize)
+	if err != nil {
+		logging.Log("Feed", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения постов ленты %s: %v", streamer.Name, err))
+		http.Error(w, "Ошибка получения ленты", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	items := make([]feedItem, 0, len(posts))
+	for _, post := range posts {
+		items = append(items, toFeedItem(post))
+	}
+
+	return streamer, items, true
+}
+
+func toFeedItem(post modeldb.Post) feedItem {
+	var postMedia []Media
+	_ = json.Unmarshal([]byte(post.Media), &postMedia)
+
+	content := post.HTML
+	for _, attachment := range postMedia {
+		if attachment.Kind == model.AttachmentPhoto || attachment.Kind == model.AttachmentSticker {
+			content += `<p><img src="` + html.EscapeString(attachment.URL) + `" alt=""></p>`
+		} else {
+			content += `<p><a href="` + html.EscapeString(attachment.URL) + `">` + html.EscapeString(attachment.Name) + "</a></p>"
+		}
+	}
+
+	return feedItem{
+		id:        fmt.Sprintf("tag:slm-bot-publisher,2024:%d/%d", post.TelegramChatID, post.TelegramMsgID),
+		title:     buildTitle(post),
+		link:      post.Link,
+		content:   content,
+		text:      post.Text,
+		media:     postMedia,
+		published: post.CreatedAt,
+		updated:   post.UpdatedAt,
+	}
+}
+
+func buildTitle(post modeldb.Post) string {
+	firstLine := strings.TrimSpace(strings.SplitN(post.Text, "\n", 2)[0])
+	if firstLine == "" {
+		if post.RepostFrom != "" {
+			return "Репост из " + post.RepostFrom
+		}
+		return "Пост без текста"
+	}
+
+	runes := []rune(firstLine)
+	if len(runes) > titleLength {
+		return string(runes[:titleLength-1]) + "…"
+	}
+	return firstLine
+}
+
+// feedURL - возвращает абсолютный адрес ленты; без PUBLIC_URL используется адрес из запроса
+func (h *Handler) feedURL(r *http.Request) string {
+	if h.publicURL != "" {
+		return h.publicURL + r.URL.Path
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.Path
+}
+
+func channelLink(streamer *model.Streamer, items []feedItem) string {
+	for _, item := range items {
+		if item.link != "" {
+			return item.link[:strings.LastIndex(item.link, "/")]
+		}
+	}
+	return "https://t.me/"
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Description string        `xml:"description"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+func (h *Handler) serveRSS(w http.ResponseWriter, r *http.Request) {
+	streamer, items, ok := h.loadFeed(w, r)
+	if !ok {
+		return
+	}
+
+	feed := rssFeed{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         streamer.Name,
+			Link:          channelLink(streamer, items),
+			Description:   fmt.Sprintf("Посты Telegram канала %s", streamer.Name),
+			SelfLink:      atomLink{Href: h.feedURL(r), Rel: "self", Type: "application/rss+xml"},
+			LastBuildDate: time.Now().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, item := range items {
+		rss := rssItem{
+			Title:       item.title,
+			Link:        item.link,
+			GUID:        rssGUID{Value: item.id},
+			PubDate:     item.published.Format(time.RFC1123Z),
+			Description: item.content,
+		}
+		// RSS допускает одно вложение на запись, остальные доступны из описания
+		if len(item.media) > 0 {
+			rss.Enclosure = &rssEnclosure{URL: item.media[0].URL, Length: item.media[0].Size, Type: item.media[0].MimeType}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, rss)
+	}
+
+	writeXML(w, "application/rss+xml; charset=utf-8", feed)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Content   atomContent `xml:"content"`
+	Author    atomAuthor  `xml:"author"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+func (h *Handler) serveAtom(w http.ResponseWriter, r *http.Request) {
+	streamer, items, ok := h.loadFeed(w, r)
+	if !ok {
+		return
+	}
+
+	updated := time.Now()
+	if len(items) > 0 {
+		updated = items[0].updated
+	}
+
+	feed := atomFeed{
+		ID:      h.feedURL(r),
+		Title:   streamer.Name,
+		Updated: updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: h.feedURL(r), Rel: "self", Type: "application/atom+xml"},
+			{Href: channelLink(streamer, items), Rel: "alternate"},
+		},
+	}
+
+	for _, item := range items {
+		entry := atomEntry{
+			ID:        item.id,
+			Title:     item.title,
+			Published: item.published.Format(time.RFC3339),
+			Updated:   item.updated.Format(time.RFC3339),
+			Content:   atomContent{Type: "html", Value: item.content},
+			Author:    atomAuthor{Name: streamer.Name},
+		}
+		if item.link != "" {
+			entry.Links = append(entry.Links, atomLink{Href: item.link, Rel: "alternate"})
+		}
+		for _, attachment := range item.media {
+			entry.Links = append(entry.Links, atomLink{Href: attachment.URL, Rel: "enclosure", Type: attachment.MimeType})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	writeXML(w, "application/atom+xml; charset=utf-8", feed)
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+type jsonFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	Title       string `json:"title,omitempty"`
+	SizeInBytes int    `json:"size_in_bytes,omitempty"`
+}
+
+func (h *Handler) serveJSONFeed(w http.ResponseWriter, r *http.Request) {
+	streamer, items, ok := h.loadFeed(w, r)
+	if !ok {
+		return
+	}
+
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       streamer.Name,
+		HomePageURL: channelLink(streamer, items),
+		FeedURL:     h.feedURL(r),
+		Items:       []jsonFeedItem{},
+	}
+
+	for _, item := range items {
+		jsonItem := jsonFeedItem{
+			ID:            item.id,
+			URL:           item.link,
+			Title:         item.title,
+			ContentHTML:   item.content,
+			ContentText:   item.text,
+			DatePublished: item.published.Format(time.RFC3339),
+			DateModified:  item.updated.Format(time.RFC3339),
+		}
+		for _, attachment := range item.media {
+			jsonItem.Attachments = append(jsonItem.Attachments, jsonFeedAttachment{
+				URL:         attachment.URL,
+				MimeType:    attachment.MimeType,
+				Title:       attachment.Name,
+				SizeInBytes: attachment.Size,
+			})
+		}
+		feed.Items = append(feed.Items, jsonItem)
+	}
+
+	w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(feed); err != nil {
+		logging.Log("Feed", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки ленты: %v", err))
+	}
+}
+
+func writeXML(w http.ResponseWriter, contentType string, feed interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(feed); err != nil {
+		logging.Log("Feed", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки ленты: %v", err))
+	}
+}
@@ -0,0 +1,123 @@
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"html"
+	"net/http"
+	"slm-bot-publisher/internal/core/format"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/internal/lib/media"
+	"slm-bot-publisher/logging"
+)
+
+// Media - вложение поста, сохраняемое в базе в виде JSON
+type Media struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Size     int    `json:"size"`
+}
+
+// Publisher - сохраняет посты стримера в базу, из которой строятся ленты
+type Publisher struct {
+	DBHandlers *handlers.DBHandlers
+	mediaStore *media.Store
+}
+
+func NewPublisher(DBHandlers *handlers.DBHandlers, mediaStore *media.Store) *Publisher {
+	return &Publisher{
+		DBHandlers: DBHandlers,
+		mediaStore: mediaStore,
+	}
+}
+
+func (p *Publisher) Publish(streamer *model.Streamer, post *model.Post) {
+	var postMedia []Media
+	for _, attachment := range post.Attachments {
+		if !p.mediaStore.Enabled() {
+			break
+		}
+
+		url, err := p.mediaStore.Save(attachment)
+		if err != nil {
+			logging.Log("Feed", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения вложения %s: %v", attachment.Name, err))
+			continue
+		}
+		postMedia = append(postMedia, Media{
+			Kind:     attachment.Kind,
+			Name:     attachment.Name,
+			URL:      url,
+			MimeType: http.DetectContentType(attachment.Data),
+			Size:     len(attachment.Data),
+		})
+	}
+
+	mediaJSON, err := json.Marshal(postMedia)
+	if err != nil || postMedia == nil {
+		mediaJSON = []byte("[]")
+	}
+
+	postDB := modeldb.Post{
+		StreamerName:   streamer.Name,
+		TelegramChatID: post.ChatID,
+		TelegramMsgID:  post.MessageID,
+		Text:           post.Text,
+		HTML:           buildHTML(post),
+		Media:          string(mediaJSON),
+		Link:           post.Link,
+	}
+	if post.Repost != nil {
+		postDB.RepostFrom = post.Repost.AuthorName
+	}
+
+	if err = p.DBHandlers.PostHandlers.CreatePost(&postDB); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения поста %d для ленты: %v", post.MessageID, err))
+		return
+	}
+	logging.Log("Feed", logrus.InfoLevel, fmt.Sprintf("Пост от %s добавлен в ленту", streamer.Name))
+}
+
+func (p *Publisher) Edit(streamer *model.Streamer, post *model.Post) {
+	postDB := modeldb.Post{
+		Text: post.Text,
+		HTML: buildHTML(post),
+	}
+
+	if err := p.DBHandlers.PostHandlers.UpdatePost(post.ChatID, post.MessageID, postDB); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка изменения поста %d в ленте: %v", post.MessageID, err))
+	}
+}
+
+// Resend - добавляет пост в ленту, если его там еще нет
+func (p *Publisher) Resend(streamer *model.Streamer, post *model.Post) {
+	if _, err := p.DBHandlers.PostHandlers.GetPost(post.ChatID, post.MessageID); err == nil {
+		return
+	}
+	p.Publish(streamer, post)
+}
+
+func (p *Publisher) Delete(streamer *model.Streamer, post *model.Post) {
+	if err := p.DBHandlers.PostHandlers.DeletePost(post.ChatID, post.MessageID); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка удаления поста %d из ленты: %v", post.MessageID, err))
+	}
+}
+
+// buildHTML - форматирует текст поста в HTML для ленты, добавляя подпись источника для репостов
+func buildHTML(post *model.Post) string {
+	content := "<p>" + format.HTML(post.Text, post.Entities) + "</p>"
+
+	if post.Repost != nil && post.Repost.AuthorName != "" {
+		source := html.EscapeString(post.Repost.AuthorName)
+		if post.Repost.Link != "" {
+			source = `<a href="` + html.EscapeString(post.Repost.Link) + `">` + source + "</a>"
+		}
+		content = "<p><em>Переслано: " + source + "</em></p>" + content
+	}
+
+	return content
+}
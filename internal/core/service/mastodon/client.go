@@ -0,0 +1,186 @@
+package mastodon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const (
+	// Сколько раз проверяется готовность медиа, которое сервер обрабатывает асинхронно
+	mediaProcessingAttempts = 10
+	mediaProcessingDelay    = 2 * time.Second
+)
+
+// Client - клиент REST API Mastodon, авторизованный токеном приложения
+type Client struct {
+	instance    string
+	accessToken string
+	httpClient  *http.Client
+}
+
+// Status - статус в ответе API
+type Status struct {
+	ID               string `json:"id"`
+	URL              string `json:"url"`
+	MediaAttachments []struct {
+		ID string `json:"id"`
+	} `json:"media_attachments"`
+}
+
+func NewClient(instance, accessToken string) *Client {
+	return &Client{
+		instance:    strings.TrimSuffix(instance, "/"),
+		accessToken: accessToken,
+		httpClient:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// UploadMedia - загружает вложение с альтернативным текстом и ждет окончания его обработки
+func (c *Client) UploadMedia(data []byte, fileName, contentType, description string) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, strings.ReplaceAll(fileName, `"`, "")))
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return "", err
+	}
+	if _, err = part.Write(data); err != nil {
+		return "", err
+	}
+	if description != "" {
+		if err = writer.WriteField("description", description); err != nil {
+			return "", err
+		}
+	}
+	if err = writer.Close(); err != nil {
+		return "", err
+	}
+
+	var media struct {
+		ID  string  `json:"id"`
+		URL *string `json:"url"`
+	}
+	status, err := c.doRequest(http.MethodPost, "/api/v2/media", writer.FormDataContentType(), body, nil, &media)
+	if err != nil {
+		return "", err
+	}
+
+	// 202 означает, что файл еще обрабатывается и не может быть прикреплен к статусу
+	for attempt := 0; status == http.StatusAccepted && attempt < mediaProcessingAttempts; attempt++ {
+		time.Sleep(mediaProcessingDelay)
+		status, err = c.doRequest(http.MethodGet, "/api/v1/media/"+media.ID, "", nil, nil, &media)
+		if err != nil {
+			return "", err
+		}
+	}
+	if status == http.StatusAccepted {
+		return "", fmt.Errorf("медиа %s не обработано сервером", media.ID)
+	}
+
+	return media.ID, nil
+}
+
+// PostStatus - публикует статус; inReplyToID задается для продолжения треда
+func (c *Client) PostStatus(text string, mediaIDs []string, inReplyToID, visibility, idempotencyKey string) (*Status, error) {
+	payload := map[string]interface{}{
+		"status":     text,
+		"visibility": visibility,
+	}
+	if len(mediaIDs) > 0 {
+		payload["media_ids"] = mediaIDs
+	}
+	if inReplyToID != "" {
+		payload["in_reply_to_id"] = inReplyToID
+	}
+
+	var status Status
+	if err := c.doJSON(http.MethodPost, "/api/v1/statuses", payload, map[string]string{"Idempotency-Key": idempotencyKey}, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// GetStatus - возвращает опубликованный статус
+func (c *Client) GetStatus(statusID string) (*Status, error) {
+	var status Status
+	if _, err := c.doRequest(http.MethodGet, "/api/v1/statuses/"+statusID, "", nil, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// EditStatus - заменяет текст статуса; вложения нужно передать повторно, иначе сервер их открепит
+func (c *Client) EditStatus(statusID, text string, mediaIDs []string) error {
+	payload := map[string]interface{}{
+		"status":    text,
+		"media_ids": mediaIDs,
+	}
+	return c.doJSON(http.MethodPut, "/api/v1/statuses/"+statusID, payload, nil, nil)
+}
+
+// DeleteStatus - удаляет статус
+func (c *Client) DeleteStatus(statusID string) error {
+	_, err := c.doRequest(http.MethodDelete, "/api/v1/statuses/"+statusID, "", nil, nil, nil)
+	return err
+}
+
+func (c *Client) doJSON(method, path string, payload interface{}, headers map[string]string, result interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = c.doRequest(method, path, "application/json", bytes.NewReader(data), headers, result)
+	return err
+}
+
+func (c *Client) doRequest(method, path, contentType string, body io.Reader, headers map[string]string, result interface{}) (int, error) {
+	req, err := http.NewRequest(method, c.instance+path, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for key, value := range headers {
+		if value != "" {
+			req.Header.Set(key, value)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка запроса к Mastodon: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		var mastodonError struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(data, &mastodonError)
+		return resp.StatusCode, fmt.Errorf("Mastodon вернул статус %d: %s", resp.StatusCode, mastodonError.Error)
+	}
+
+	if result != nil {
+		if err = json.Unmarshal(data, result); err != nil {
+			return resp.StatusCode, fmt.Errorf("ошибка декодирования ответа Mastodon: %v", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
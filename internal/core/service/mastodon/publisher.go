@@ -0,0 +1,360 @@
+package mastodon
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/logging"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultCharacterLimit = 500
+	DefaultVisibility     = "public"
+	// Mastodon принимает не больше 4 вложений в одном статусе
+	MediaPerStatus = 4
+	// Ограничение длины альтернативного текста вложения
+	DescriptionLimit = 1500
+)
+
+// Settings - настройки площадки Mastodon в конфиге стримера
+type Settings struct {
+	Instance       string
+	AccessToken    string
+	Visibility     string
+	CharacterLimit int
+}
+
+// Publisher - публикует посты в Mastodon и совместимые с его API серверы
+type Publisher struct {
+	DBHandlers *handlers.DBHandlers
+}
+
+func NewPublisher(DBHandlers *handlers.DBHandlers) *Publisher {
+	return &Publisher{DBHandlers: DBHandlers}
+}
+
+// Publish - публикует пост; длинный текст и вложения сверх лимита уходят ответами в тред
+func (p *Publisher) Publish(streamer *model.Streamer, post *model.Post) {
+	for _, destination := range p.destinations(streamer) {
+		p.publish(streamer, destination, post)
+	}
+}
+
+// Resend - досылает пост на серверы, где его копии нет
+func (p *Publisher) Resend(streamer *model.Streamer, post *model.Post) {
+	for _, destination := range p.destinations(streamer) {
+		messages, err := p.DBHandlers.MessageHandlers.GetChatMessageByID(destination.Instance, post.ChatID, post.MessageID)
+		if err == nil && len(messages) > 0 {
+			continue
+		}
+		p.publish(streamer, destination, post)
+	}
+}
+
+func (p *Publisher) publish(streamer *model.Streamer, destination Settings, post *model.Post) {
+	if err := p.publishToInstance(destination, post); err != nil {
+		logging.Log("Mastodon", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки поста %s на %s: %v", streamer.Name, destination.Instance, err))
+		return
+	}
+	logging.Log("Mastodon", logrus.InfoLevel, fmt.Sprintf("Пост от %s успешно отправлен на %s", streamer.Name, destination.Instance))
+}
+
+func (p *Publisher) publishToInstance(destination Settings, post *model.Post) error {
+	client := NewClient(destination.Instance, destination.AccessToken)
+
+	mediaIDs := uploadAttachments(client, post)
+	chunks := splitText(buildText(post), destination.CharacterLimit)
+
+	statusCount := max(len(chunks), (len(mediaIDs)+MediaPerStatus-1)/MediaPerStatus)
+	// Время публикации в ключе нужно, чтобы повторная отправка поста (/resend) не вернула удаленный статус
+	publishedAt := time.Now().Unix()
+	var statusIDs []string
+	for idx := 0; idx < statusCount; idx++ {
+		text := ""
+		if idx < len(chunks) {
+			text = chunks[idx]
+		}
+		mediaGroup := mediaIDs[min(idx*MediaPerStatus, len(mediaIDs)):min((idx+1)*MediaPerStatus, len(mediaIDs))]
+
+		inReplyToID := ""
+		if len(statusIDs) > 0 {
+			inReplyToID = statusIDs[len(statusIDs)-1]
+		}
+
+		idempotencyKey := fmt.Sprintf("slm-%d-%d-%d-%d", post.ChatID, post.MessageID, publishedAt, idx)
+		status, err := client.PostStatus(text, mediaGroup, inReplyToID, destination.Visibility, idempotencyKey)
+		if err != nil {
+			if len(statusIDs) == 0 {
+				return err
+			}
+			// Начало треда уже опубликовано, поэтому записи о нем все равно сохраняются
+			logging.Log("Mastodon", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки продолжения треда на %s: %v", destination.Instance, err))
+			break
+		}
+		statusIDs = append(statusIDs, status.ID)
+	}
+
+	if len(statusIDs) == 0 {
+		return fmt.Errorf("пост не содержит ни текста, ни вложений")
+	}
+
+	for idx, part := range post.Parts {
+		p.saveMessage(destination.Instance, modeldb.Message{
+			MainPost:             idx == 0,
+			TelegramChatID:       post.ChatID,
+			TelegramMsgID:        part.MessageID,
+			DestinationMsgID:     statusIDs[0],
+			TelegramAttachmentID: part.AttachmentID,
+		})
+	}
+	for _, statusID := range statusIDs[1:] {
+		p.saveReply(destination.Instance, post.ChatID, post.MessageID, statusIDs[0], statusID)
+	}
+
+	return nil
+}
+
+// Edit - изменяет статусы треда; при изменении длины текста лишние ответы удаляются, недостающие добавляются
+func (p *Publisher) Edit(streamer *model.Streamer, post *model.Post) {
+	for _, destination := range p.destinations(streamer) {
+		messages, err := p.DBHandlers.MessageHandlers.GetChatMessageByID(destination.Instance, post.ChatID, post.MessageID)
+		if err != nil || len(messages) == 0 {
+			continue
+		}
+
+		client := NewClient(destination.Instance, destination.AccessToken)
+		statusIDs := threadStatusIDs(messages)
+		chunks := splitText(buildText(post), destination.CharacterLimit)
+
+		for idx, statusID := range statusIDs {
+			status, err := client.GetStatus(statusID)
+			if err != nil {
+				logging.Log("Mastodon", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения статуса %s на %s: %v", statusID, destination.Instance, err))
+				continue
+			}
+
+			var mediaIDs []string
+			for _, media := range status.MediaAttachments {
+				mediaIDs = append(mediaIDs, media.ID)
+			}
+
+			text := ""
+			if idx < len(chunks) {
+				text = chunks[idx]
+			}
+
+			// Ответ без текста и вложений больше не нужен; корневой статус не удаляется, чтобы не потерять тред
+			if text == "" && len(mediaIDs) == 0 && idx > 0 {
+				if err = client.DeleteStatus(statusID); err != nil {
+					logging.Log("Mastodon", logrus.ErrorLevel, fmt.Sprintf("Ошибка удаления статуса %s на %s: %v", statusID, destination.Instance, err))
+					continue
+				}
+				if err = p.DBHandlers.MessageHandlers.DeleteMessageByAttachmentID(destination.Instance, statusID); err != nil {
+					logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Не удалось удалить запись о статусе Mastodon %s", statusID))
+				}
+				continue
+			}
+
+			if err = client.EditStatus(statusID, text, mediaIDs); err != nil {
+				logging.Log("Mastodon", logrus.ErrorLevel, fmt.Sprintf("Ошибка изменения статуса %s на %s: %v", statusID, destination.Instance, err))
+			}
+		}
+
+		lastStatusID := statusIDs[len(statusIDs)-1]
+		for idx := len(statusIDs); idx < len(chunks); idx++ {
+			status, err := client.PostStatus(chunks[idx], nil, lastStatusID, destination.Visibility, "")
+			if err != nil {
+				logging.Log("Mastodon", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки продолжения треда на %s: %v", destination.Instance, err))
+				break
+			}
+			p.saveReply(destination.Instance, post.ChatID, messages[0].TelegramMsgID, statusIDs[0], status.ID)
+			lastStatusID = status.ID
+		}
+
+		logging.Log("Mastodon", logrus.InfoLevel, fmt.Sprintf("Статус %s успешно изменен на %s", statusIDs[0], destination.Instance))
+	}
+}
+
+// Delete - удаляет все статусы треда и записи о них
+func (p *Publisher) Delete(streamer *model.Streamer, post *model.Post) {
+	for _, destination := range p.destinations(streamer) {
+		messages, err := p.DBHandlers.MessageHandlers.GetChatMessageByID(destination.Instance, post.ChatID, post.MessageID)
+		if err != nil || len(messages) == 0 {
+			continue
+		}
+
+		client := NewClient(destination.Instance, destination.AccessToken)
+		statusIDs := threadStatusIDs(messages)
+
+		// Ответы удаляются с конца, чтобы тред не оставался с оборванной серединой
+		for idx := len(statusIDs) - 1; idx >= 0; idx-- {
+			if err = client.DeleteStatus(statusIDs[idx]); err != nil {
+				logging.Log("Mastodon", logrus.ErrorLevel, fmt.Sprintf("Ошибка удаления статуса %s на %s: %v", statusIDs[idx], destination.Instance, err))
+			}
+		}
+
+		if err = p.DBHandlers.MessageHandlers.DeleteChatMessageByID(destination.Instance, post.ChatID, post.MessageID); err != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Не удалось удалить сообщения с ID Mastodon %s", statusIDs[0]))
+		}
+		logging.Log("Mastodon", logrus.InfoLevel, fmt.Sprintf("Пост %d успешно удален с %s", post.MessageID, destination.Instance))
+	}
+}
+
+// saveMessage - сохраняет связь со статусом. Сервер общий для всех стримеров, поэтому записи
+// ищутся по серверу вместе с каналом Telegram
+func (p *Publisher) saveMessage(instance string, messageDB modeldb.Message) {
+	messageDB.Platform = model.DestinationMastodon
+	messageDB.ChannelID = instance
+
+	if err := p.DBHandlers.MessageHandlers.CreateMessage(&messageDB); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения сообщения %d в базу", messageDB.TelegramMsgID))
+	}
+}
+
+// saveReply - сохраняет ответ треда; ID ответа хранится в DestinationAttachmentID, корень треда - в DestinationMsgID
+func (p *Publisher) saveReply(instance string, chatID int64, telegramMsgID int, rootStatusID, statusID string) {
+	p.saveMessage(instance, modeldb.Message{
+		TelegramChatID:          chatID,
+		TelegramMsgID:           telegramMsgID,
+		DestinationMsgID:        rootStatusID,
+		DestinationAttachmentID: statusID,
+	})
+}
+
+// threadStatusIDs - восстанавливает порядок статусов треда по записям в базе
+func threadStatusIDs(messages []modeldb.Message) []string {
+	statusIDs := []string{messages[0].DestinationMsgID}
+	for _, msg := range messages {
+		if msg.DestinationAttachmentID != "" {
+			statusIDs = append(statusIDs, msg.DestinationAttachmentID)
+		}
+	}
+	return statusIDs
+}
+
+// destinations - разбирает настройки всех площадок Mastodon стримера
+func (p *Publisher) destinations(streamer *model.Streamer) []Settings {
+	var settings []Settings
+	for _, destination := range streamer.DestinationsOf(model.DestinationMastodon) {
+		destinationSettings := Settings{
+			Visibility:     DefaultVisibility,
+			CharacterLimit: DefaultCharacterLimit,
+		}
+		if err := json.Unmarshal(destination.Settings, &destinationSettings); err != nil {
+			logging.Log("Mastodon", logrus.ErrorLevel, fmt.Sprintf("Ошибка разбора настроек Mastodon у стримера %s: %v", streamer.Name, err))
+			continue
+		}
+		destinationSettings.Instance = strings.TrimSuffix(destinationSettings.Instance, "/")
+		settings = append(settings, destinationSettings)
+	}
+	return settings
+}
+
+// uploadAttachments - загружает вложения, подписывая их текстом поста; вложения с ошибкой пропускаются
+func uploadAttachments(client *Client, post *model.Post) []string {
+	description := []rune(strings.TrimSpace(post.Text))
+	if len(description) > DescriptionLimit {
+		description = append(description[:DescriptionLimit-1], '…')
+	}
+
+	var mediaIDs []string
+	for _, attachment := range post.Attachments {
+		mediaID, err := client.UploadMedia(attachment.Data, attachment.Name, contentType(attachment), string(description))
+		if err != nil {
+			logging.Log("Mastodon", logrus.ErrorLevel, fmt.Sprintf("Ошибка загрузки вложения %s: %v", attachment.Name, err))
+			continue
+		}
+		mediaIDs = append(mediaIDs, mediaID)
+	}
+	return mediaIDs
+}
+
+// buildText - собирает текст статуса: заголовок репоста, текст поста и ссылку на оригинал
+func buildText(post *model.Post) string {
+	text := post.Text
+
+	if post.Repost != nil && post.Repost.AuthorName != "" {
+		header := fmt.Sprintf("Переслано из %s", post.Repost.AuthorName)
+		if post.Repost.Type == model.RepostOriginUser || post.Repost.Type == model.RepostOriginHiddenUser {
+			header = fmt.Sprintf("Переслано от %s", post.Repost.AuthorName)
+		}
+		text = header + "\n\n" + text
+	}
+
+	if post.Link != "" {
+		text = strings.TrimSpace(text + "\n\nОригинальный пост: " + post.Link)
+	}
+
+	return text
+}
+
+// splitText - делит текст на части не длиннее лимита, разрывая по абзацам и пробелам; части нумеруются
+func splitText(text string, limit int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if limit <= 0 {
+		limit = DefaultCharacterLimit
+	}
+
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return []string{text}
+	}
+
+	// Место под номер части вида " (12/34)"
+	chunkLimit := limit - len(" (00/00)")
+
+	var chunks []string
+	for len(runes) > 0 {
+		if len(runes) <= chunkLimit {
+			chunks = append(chunks, string(runes))
+			break
+		}
+
+		cut := lastBreak(runes[:chunkLimit])
+		chunks = append(chunks, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+
+	for idx := range chunks {
+		chunks[idx] = fmt.Sprintf("%s (%d/%d)", chunks[idx], idx+1, len(chunks))
+	}
+	return chunks
+}
+
+// lastBreak - ищет место разрыва во второй половине части: сначала перенос строки, затем пробел
+func lastBreak(runes []rune) int {
+	for _, separator := range []rune{'\n', ' '} {
+		for idx := len(runes) - 1; idx > len(runes)/2; idx-- {
+			if runes[idx] == separator {
+				return idx
+			}
+		}
+	}
+	return len(runes)
+}
+
+func contentType(attachment model.Attachment) string {
+	switch attachment.Kind {
+	case model.AttachmentPhoto:
+		return "image/jpeg"
+	case model.AttachmentSticker:
+		return "image/webp"
+	case model.AttachmentVideo, model.AttachmentVideoNote:
+		return "video/mp4"
+	case model.AttachmentAudio:
+		return "audio/mpeg"
+	case model.AttachmentVoice:
+		return "audio/ogg"
+	default:
+		return http.DetectContentType(attachment.Data)
+	}
+}
@@ -0,0 +1,313 @@
+package mastodon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/database/dbtest"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+type fakeStatus struct {
+	ID          string
+	Account     string
+	Text        string
+	MediaIDs    []string
+	InReplyToID string
+	Deleted     bool
+}
+
+// fakeMastodon - локальный сервер Mastodon; аккаунт определяется токеном доступа
+type fakeMastodon struct {
+	server *httptest.Server
+
+	mutex    sync.Mutex
+	counter  int
+	statuses map[string]*fakeStatus
+	order    []string
+	deleted  []string
+}
+
+func newFakeMastodon(t *testing.T) *fakeMastodon {
+	fake := &fakeMastodon{statuses: make(map[string]*fakeStatus)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v2/media", fake.authorized(func(w http.ResponseWriter, r *http.Request, account string) {
+		fake.counter++
+		writeTestJSON(w, map[string]string{"id": fmt.Sprintf("media%d", fake.counter)})
+	}))
+	mux.HandleFunc("POST /api/v1/statuses", fake.authorized(func(w http.ResponseWriter, r *http.Request, account string) {
+		var payload struct {
+			Status      string   `json:"status"`
+			MediaIDs    []string `json:"media_ids"`
+			InReplyToID string   `json:"in_reply_to_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+
+		fake.counter++
+		status := &fakeStatus{ID: fmt.Sprint(fake.counter), Account: account, Text: payload.Status, MediaIDs: payload.MediaIDs, InReplyToID: payload.InReplyToID}
+		fake.statuses[status.ID] = status
+		fake.order = append(fake.order, status.ID)
+		fake.writeStatus(w, status)
+	}))
+	mux.HandleFunc("GET /api/v1/statuses/{id}", fake.owned(func(w http.ResponseWriter, r *http.Request, status *fakeStatus) {
+		fake.writeStatus(w, status)
+	}))
+	mux.HandleFunc("PUT /api/v1/statuses/{id}", fake.owned(func(w http.ResponseWriter, r *http.Request, status *fakeStatus) {
+		var payload struct {
+			Status   string   `json:"status"`
+			MediaIDs []string `json:"media_ids"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		status.Text, status.MediaIDs = payload.Status, payload.MediaIDs
+		fake.writeStatus(w, status)
+	}))
+	mux.HandleFunc("DELETE /api/v1/statuses/{id}", fake.owned(func(w http.ResponseWriter, r *http.Request, status *fakeStatus) {
+		status.Deleted = true
+		fake.deleted = append(fake.deleted, status.ID)
+		fake.writeStatus(w, status)
+	}))
+
+	fake.server = httptest.NewServer(mux)
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+func (f *fakeMastodon) authorized(next func(w http.ResponseWriter, r *http.Request, account string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+
+		account, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || account == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next(w, r, account)
+	}
+}
+
+// owned - как и Mastodon, не дает читать, менять и удалять чужие или удаленные статусы
+func (f *fakeMastodon) owned(next func(w http.ResponseWriter, r *http.Request, status *fakeStatus)) http.HandlerFunc {
+	return f.authorized(func(w http.ResponseWriter, r *http.Request, account string) {
+		status, exists := f.statuses[r.PathValue("id")]
+		if !exists || status.Deleted || status.Account != account {
+			w.WriteHeader(http.StatusNotFound)
+			writeTestJSON(w, map[string]string{"error": "Record not found"})
+			return
+		}
+		next(w, r, status)
+	})
+}
+
+func (f *fakeMastodon) writeStatus(w http.ResponseWriter, status *fakeStatus) {
+	media := make([]map[string]string, 0, len(status.MediaIDs))
+	for _, mediaID := range status.MediaIDs {
+		media = append(media, map[string]string{"id": mediaID})
+	}
+	writeTestJSON(w, map[string]interface{}{"id": status.ID, "url": f.server.URL + "/@test/" + status.ID, "media_attachments": media})
+}
+
+// thread - возвращает неудаленные статусы аккаунта в порядке публикации
+func (f *fakeMastodon) thread(account string) []*fakeStatus {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var thread []*fakeStatus
+	for _, statusID := range f.order {
+		if status := f.statuses[statusID]; status.Account == account && !status.Deleted {
+			thread = append(thread, status)
+		}
+	}
+	return thread
+}
+
+func (f *fakeMastodon) streamer(name string, chatID int64, account string, limit int) *model.Streamer {
+	settings, _ := json.Marshal(Settings{Instance: f.server.URL + "/", AccessToken: account, CharacterLimit: limit})
+	return &model.Streamer{
+		Name:              name,
+		TelegramChannelID: chatID,
+		Destinations:      []model.Destination{{Type: model.DestinationMastodon, Settings: settings}},
+	}
+}
+
+func writeTestJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func testPost(chatID int64, msgID int, text string, photos int) *model.Post {
+	post := &model.Post{ChatID: chatID, MessageID: msgID, Parts: []model.PostPart{{MessageID: msgID}}, Text: text}
+	for idx := 0; idx < photos; idx++ {
+		post.Attachments = append(post.Attachments, model.Attachment{Kind: model.AttachmentPhoto, Name: fmt.Sprintf("photo%d.jpg", idx), MessageID: msgID, Data: []byte("jpeg")})
+	}
+	return post
+}
+
+// words - текст из count слов, который не помещается в один статус
+func words(count int) string {
+	return strings.TrimSpace(strings.Repeat("слово ", count))
+}
+
+func TestSplitText(t *testing.T) {
+	if chunks := splitText("  короткий текст ", 500); len(chunks) != 1 || chunks[0] != "короткий текст" {
+		t.Fatalf("короткий текст не должен делиться: %q", chunks)
+	}
+	if chunks := splitText("", 500); chunks != nil {
+		t.Fatalf("пустой текст не должен давать статусов: %q", chunks)
+	}
+
+	text := words(100)
+	chunks := splitText(text, 100)
+	if len(chunks) < 2 {
+		t.Fatalf("длинный текст должен делиться, получено %d частей", len(chunks))
+	}
+
+	var joined []string
+	for idx, chunk := range chunks {
+		if length := utf8.RuneCountInString(chunk); length > 100 {
+			t.Fatalf("часть %d длиной %d превышает лимит", idx, length)
+		}
+		suffix := fmt.Sprintf(" (%d/%d)", idx+1, len(chunks))
+		if !strings.HasSuffix(chunk, suffix) {
+			t.Fatalf("часть %q не пронумерована", chunk)
+		}
+		joined = append(joined, strings.TrimSuffix(chunk, suffix))
+	}
+	if strings.Join(joined, " ") != text {
+		t.Fatalf("текст должен делиться по пробелам без потери слов")
+	}
+}
+
+func TestSplitTextWithoutSpaces(t *testing.T) {
+	chunks := splitText(strings.Repeat("я", 250), 100)
+	for idx, chunk := range chunks {
+		if length := utf8.RuneCountInString(chunk); length > 100 {
+			t.Fatalf("часть %d длиной %d превышает лимит", idx, length)
+		}
+	}
+}
+
+func TestPublishThreadsLongPost(t *testing.T) {
+	fake := newFakeMastodon(t)
+	publisher := NewPublisher(dbtest.New(t))
+
+	publisher.Publish(fake.streamer("Test", -100, "account", 100), testPost(-100, 1, words(60), 6))
+
+	thread := fake.thread("account")
+	if len(thread) < 3 {
+		t.Fatalf("длинный пост должен уйти тредом, отправлено статусов %d", len(thread))
+	}
+	for idx, status := range thread[1:] {
+		if status.InReplyToID != thread[idx].ID {
+			t.Fatalf("статус %s должен отвечать на %s, а отвечает на %q", status.ID, thread[idx].ID, status.InReplyToID)
+		}
+	}
+	if len(thread[0].MediaIDs) != MediaPerStatus || len(thread[1].MediaIDs) != 2 {
+		t.Fatalf("вложения должны делиться по %d на статус: %v, %v", MediaPerStatus, thread[0].MediaIDs, thread[1].MediaIDs)
+	}
+
+	messages, err := publisher.DBHandlers.MessageHandlers.GetChatMessageByID(fake.server.URL, -100, 1)
+	if err != nil || len(threadStatusIDs(messages)) != len(thread) {
+		t.Fatalf("в базе должен сохраниться весь тред: %v %v", messages, err)
+	}
+}
+
+func TestEditShrinksAndGrowsThread(t *testing.T) {
+	fake := newFakeMastodon(t)
+	publisher := NewPublisher(dbtest.New(t))
+	streamer := fake.streamer("Test", -100, "account", 100)
+
+	publisher.Publish(streamer, testPost(-100, 1, words(60), 0))
+	initial := len(fake.thread("account"))
+
+	publisher.Edit(streamer, testPost(-100, 1, "Короткий текст", 0))
+	thread := fake.thread("account")
+	if len(thread) != 1 || thread[0].Text != "Короткий текст" {
+		t.Fatalf("после сокращения должен остаться один статус, осталось %d из %d", len(thread), initial)
+	}
+	messages, _ := publisher.DBHandlers.MessageHandlers.GetChatMessageByID(fake.server.URL, -100, 1)
+	if len(threadStatusIDs(messages)) != 1 {
+		t.Fatalf("записи об удаленных ответах должны удаляться: %+v", messages)
+	}
+
+	publisher.Edit(streamer, testPost(-100, 1, words(60), 0))
+	thread = fake.thread("account")
+	if len(thread) != initial || thread[len(thread)-1].InReplyToID != thread[len(thread)-2].ID {
+		t.Fatalf("после удлинения тред должен продолжиться ответами, статусов %d", len(thread))
+	}
+}
+
+func TestDeleteRemovesThread(t *testing.T) {
+	fake := newFakeMastodon(t)
+	publisher := NewPublisher(dbtest.New(t))
+	streamer := fake.streamer("Test", -100, "account", 100)
+
+	publisher.Publish(streamer, testPost(-100, 1, words(60), 0))
+	published := fake.thread("account")
+	publisher.Delete(streamer, testPost(-100, 1, "", 0))
+
+	if len(fake.thread("account")) != 0 {
+		t.Fatalf("все статусы треда должны быть удалены")
+	}
+	if fake.deleted[0] != published[len(published)-1].ID || fake.deleted[len(fake.deleted)-1] != published[0].ID {
+		t.Fatalf("ответы должны удаляться с конца треда: %v", fake.deleted)
+	}
+	if messages, _ := publisher.DBHandlers.MessageHandlers.GetChatMessageByID(fake.server.URL, -100, 1); len(messages) != 0 {
+		t.Fatalf("записи о треде не удалены")
+	}
+}
+
+func TestStreamersOnSameInstanceAreIsolated(t *testing.T) {
+	fake := newFakeMastodon(t)
+	publisher := NewPublisher(dbtest.New(t))
+	first := fake.streamer("First", -100, "first", 500)
+	second := fake.streamer("Second", -200, "second", 500)
+
+	// У постов разных каналов Telegram совпадают ID сообщений
+	publisher.Publish(second, testPost(-200, 1, "Пост второго", 0))
+	publisher.Publish(first, testPost(-100, 1, "Пост первого", 0))
+
+	publisher.Edit(first, testPost(-100, 1, "Изменен первым", 0))
+	publisher.Delete(first, testPost(-100, 1, "", 0))
+
+	thread := fake.thread("second")
+	if len(thread) != 1 || thread[0].Text != "Пост второго" {
+		t.Fatalf("статус второго стримера не должен меняться: %+v", thread)
+	}
+	if messages, _ := publisher.DBHandlers.MessageHandlers.GetChatMessageByID(fake.server.URL, -200, 1); len(messages) != 1 {
+		t.Fatalf("запись второго стримера не должна удаляться")
+	}
+
+	publisher.Edit(second, testPost(-200, 1, "Изменен вторым", 0))
+	if thread = fake.thread("second"); thread[0].Text != "Изменен вторым" {
+		t.Fatalf("второй стример должен менять свой статус: %+v", thread[0])
+	}
+}
+
+func TestResendSkipsPublishedPost(t *testing.T) {
+	fake := newFakeMastodon(t)
+	publisher := NewPublisher(dbtest.New(t))
+	streamer := fake.streamer("Test", -100, "account", 500)
+
+	publisher.Publish(streamer, testPost(-100, 1, "Пост", 0))
+	publisher.Resend(streamer, testPost(-100, 1, "Пост", 0))
+	if thread := fake.thread("account"); len(thread) != 1 {
+		t.Fatalf("пост с копией не должен отправляться повторно: %+v", thread)
+	}
+
+	// Пост с тем же ID из другого канала Telegram копией не считается
+	other := fake.streamer("Other", -200, "other", 500)
+	publisher.Resend(other, testPost(-200, 1, "Пост другого", 0))
+	if thread := fake.thread("other"); len(thread) != 1 || thread[0].Text != "Пост другого" {
+		t.Fatalf("пост без копии должен быть отправлен: %+v", thread)
+	}
+}
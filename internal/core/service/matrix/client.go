@@ -0,0 +1,116 @@
+package matrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Client - клиент Matrix client-server API, авторизованный токеном доступа
+type Client struct {
+	homeserver  string
+	accessToken string
+	httpClient  *http.Client
+}
+
+var transactionCounter atomic.Int64
+
+func NewClient(homeserver, accessToken string) *Client {
+	return &Client{
+		homeserver:  strings.TrimSuffix(homeserver, "/"),
+		accessToken: accessToken,
+		httpClient:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Upload - загружает файл в репозиторий медиа и возвращает его mxc:// адрес
+func (c *Client) Upload(data []byte, fileName, contentType string) (string, error) {
+	path := "/_matrix/media/v3/upload?filename=" + url.QueryEscape(fileName)
+
+	var response struct {
+		ContentURI string `json:"content_uri"`
+	}
+	if err := c.doRequest(http.MethodPost, path, contentType, bytes.NewReader(data), &response); err != nil {
+		return "", err
+	}
+
+	return response.ContentURI, nil
+}
+
+// SendMessage - отправляет событие m.room.message в комнату и возвращает ID события
+func (c *Client) SendMessage(roomID string, content map[string]interface{}) (string, error) {
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/send/m.room.message/%s", url.PathEscape(roomID), newTransactionID())
+
+	payload, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		EventID string `json:"event_id"`
+	}
+	if err = c.doRequest(http.MethodPut, path, "application/json", bytes.NewReader(payload), &response); err != nil {
+		return "", err
+	}
+
+	return response.EventID, nil
+}
+
+// Redact - скрывает содержимое события в комнате
+func (c *Client) Redact(roomID, eventID, reason string) error {
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/redact/%s/%s", url.PathEscape(roomID), url.PathEscape(eventID), newTransactionID())
+
+	payload, err := json.Marshal(map[string]string{"reason": reason})
+	if err != nil {
+		return err
+	}
+
+	return c.doRequest(http.MethodPut, path, "application/json", bytes.NewReader(payload), nil)
+}
+
+func (c *Client) doRequest(method, path, contentType string, body io.Reader, result interface{}) error {
+	req, err := http.NewRequest(method, c.homeserver+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к Matrix: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var matrixError struct {
+			ErrCode string `json:"errcode"`
+			Error   string `json:"error"`
+		}
+		_ = json.Unmarshal(data, &matrixError)
+		return fmt.Errorf("Matrix вернул статус %d: %s %s", resp.StatusCode, matrixError.ErrCode, matrixError.Error)
+	}
+
+	if result != nil {
+		if err = json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("ошибка декодирования ответа Matrix: %v", err)
+		}
+	}
+	return nil
+}
+
+// newTransactionID - возвращает уникальный ID транзакции, чтобы сервер не принял повтор запроса за новое событие
+func newTransactionID() string {
+	return fmt.Sprintf("slm%d.%d", time.Now().UnixNano(), transactionCounter.Add(1))
+}
@@ -0,0 +1,306 @@
+package matrix
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"html"
+	"net/http"
+	"slm-bot-publisher/internal/core/format"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/logging"
+)
+
+// Settings - настройки площадки Matrix в конфиге стримера
+type Settings struct {
+	Homeserver  string
+	AccessToken string
+	Rooms       []string
+}
+
+// Publisher - зеркалирует посты в комнаты Matrix
+type Publisher struct {
+	DBHandlers *handlers.DBHandlers
+}
+
+func NewPublisher(DBHandlers *handlers.DBHandlers) *Publisher {
+	return &Publisher{DBHandlers: DBHandlers}
+}
+
+// Publish - отправляет текст поста и его вложения в комнаты Matrix
+func (p *Publisher) Publish(streamer *model.Streamer, post *model.Post) {
+	for _, destination := range p.destinations(streamer) {
+		p.publishToRooms(streamer, destination, destination.Rooms, post)
+	}
+}
+
+// Resend - досылает пост в комнаты, где его копии нет
+func (p *Publisher) Resend(streamer *model.Streamer, post *model.Post) {
+	for _, destination := range p.destinations(streamer) {
+		var missing []string
+		for _, roomID := range destination.Rooms {
+			messages, err := p.DBHandlers.MessageHandlers.GetChatMessageByID(roomID, post.ChatID, post.MessageID)
+			if err != nil || len(messages) == 0 {
+				missing = append(missing, roomID)
+			}
+		}
+		if len(missing) > 0 {
+			p.publishToRooms(streamer, destination, missing, post)
+		}
+	}
+}
+
+func (p *Publisher) publishToRooms(streamer *model.Streamer, destination Settings, rooms []string, post *model.Post) {
+	client := NewClient(destination.Homeserver, destination.AccessToken)
+
+	// Медиа загружаются один раз на сервер и переиспользуются во всех комнатах
+	contentURIs := uploadAttachments(client, post.Attachments)
+
+	for _, roomID := range rooms {
+		if err := p.publishToRoom(client, roomID, post, contentURIs); err != nil {
+			logging.Log("Matrix", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки поста %s в комнату %s: %v", streamer.Name, roomID, err))
+			continue
+		}
+		logging.Log("Matrix", logrus.InfoLevel, fmt.Sprintf("Пост от %s успешно отправлен в комнату %s", streamer.Name, roomID))
+	}
+}
+
+func (p *Publisher) publishToRoom(client *Client, roomID string, post *model.Post, contentURIs []string) error {
+	var mainEventID string
+
+	if content := buildTextContent(post); content != nil {
+		eventID, err := client.SendMessage(roomID, content)
+		if err != nil {
+			return err
+		}
+		mainEventID = eventID
+	}
+
+	// ID события с первым вложением каждого сообщения Telegram
+	attachmentEvents := make(map[int]string)
+	for idx, attachment := range post.Attachments {
+		if contentURIs[idx] == "" {
+			continue
+		}
+
+		eventID, err := client.SendMessage(roomID, buildMediaContent(attachment, contentURIs[idx]))
+		if err != nil {
+			logging.Log("Matrix", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки вложения %s в комнату %s: %v", attachment.Name, roomID, err))
+			continue
+		}
+		if mainEventID == "" {
+			mainEventID = eventID
+		}
+		if _, exists := attachmentEvents[attachment.MessageID]; !exists {
+			attachmentEvents[attachment.MessageID] = eventID
+		}
+	}
+
+	if mainEventID == "" {
+		return fmt.Errorf("пост не содержит ни текста, ни вложений")
+	}
+
+	for idx, part := range post.Parts {
+		messageDB := modeldb.Message{
+			Platform:                model.DestinationMatrix,
+			MainPost:                idx == 0,
+			ChannelID:               roomID,
+			TelegramChatID:          post.ChatID,
+			TelegramMsgID:           part.MessageID,
+			DestinationMsgID:        mainEventID,
+			TelegramAttachmentID:    part.AttachmentID,
+			DestinationAttachmentID: attachmentEvents[part.MessageID],
+		}
+
+		if err := p.DBHandlers.MessageHandlers.CreateMessage(&messageDB); err != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения сообщения %d в базу", messageDB.TelegramMsgID))
+		}
+	}
+
+	return nil
+}
+
+// Edit - заменяет текст опубликованного поста через отношение m.replace
+func (p *Publisher) Edit(streamer *model.Streamer, post *model.Post) {
+	for _, destination := range p.destinations(streamer) {
+		client := NewClient(destination.Homeserver, destination.AccessToken)
+
+		for _, roomID := range destination.Rooms {
+			messages, err := p.DBHandlers.MessageHandlers.GetChatMessageByID(roomID, post.ChatID, post.MessageID)
+			if err != nil || len(messages) == 0 {
+				continue
+			}
+			if isMediaOnly(messages) {
+				logging.Log("Matrix", logrus.DebugLevel, fmt.Sprintf("Пост %d в комнате %s опубликован без текста, изменение пропущено", post.MessageID, roomID))
+				continue
+			}
+
+			newContent := buildTextContent(post)
+			if newContent == nil {
+				continue
+			}
+
+			content := map[string]interface{}{
+				"msgtype":        "m.text",
+				"body":           "* " + newContent["body"].(string),
+				"format":         "org.matrix.custom.html",
+				"formatted_body": "* " + newContent["formatted_body"].(string),
+				"m.new_content":  newContent,
+				"m.relates_to": map[string]string{
+					"rel_type": "m.replace",
+					"event_id": messages[0].DestinationMsgID,
+				},
+			}
+
+			if _, err = client.SendMessage(roomID, content); err != nil {
+				logging.Log("Matrix", logrus.ErrorLevel, fmt.Sprintf("Ошибка изменения события %s в комнате %s: %v", messages[0].DestinationMsgID, roomID, err))
+				continue
+			}
+			logging.Log("Matrix", logrus.InfoLevel, fmt.Sprintf("Событие %s успешно изменено в комнате %s", messages[0].DestinationMsgID, roomID))
+		}
+	}
+}
+
+// Delete - скрывает все события поста через redaction и удаляет записи о них
+func (p *Publisher) Delete(streamer *model.Streamer, post *model.Post) {
+	for _, destination := range p.destinations(streamer) {
+		client := NewClient(destination.Homeserver, destination.AccessToken)
+
+		for _, roomID := range destination.Rooms {
+			messages, err := p.DBHandlers.MessageHandlers.GetChatMessageByID(roomID, post.ChatID, post.MessageID)
+			if err != nil || len(messages) == 0 {
+				continue
+			}
+
+			eventIDs := []string{messages[0].DestinationMsgID}
+			for _, msg := range messages {
+				if msg.DestinationAttachmentID != "" && msg.DestinationAttachmentID != messages[0].DestinationMsgID {
+					eventIDs = append(eventIDs, msg.DestinationAttachmentID)
+				}
+			}
+
+			for _, eventID := range eventIDs {
+				if err = client.Redact(roomID, eventID, "Пост удален в Telegram"); err != nil {
+					logging.Log("Matrix", logrus.ErrorLevel, fmt.Sprintf("Ошибка удаления события %s в комнате %s: %v", eventID, roomID, err))
+				}
+			}
+
+			if err = p.DBHandlers.MessageHandlers.DeleteChatMessageByID(roomID, post.ChatID, post.MessageID); err != nil {
+				logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Не удалось удалить сообщения с ID Matrix %s", messages[0].DestinationMsgID))
+			}
+			logging.Log("Matrix", logrus.InfoLevel, fmt.Sprintf("Пост %d успешно удален из комнаты %s", post.MessageID, roomID))
+		}
+	}
+}
+
+// isMediaOnly - проверяет, что главным событием поста стало вложение. Текстовое событие m.replace
+// не может заменить событие с медиа, поэтому такие посты не изменяются
+func isMediaOnly(messages []modeldb.Message) bool {
+	for _, msg := range messages {
+		if msg.DestinationAttachmentID == msg.DestinationMsgID {
+			return true
+		}
+	}
+	return false
+}
+
+// destinations - разбирает настройки всех площадок Matrix стримера
+func (p *Publisher) destinations(streamer *model.Streamer) []Settings {
+	var settings []Settings
+	for _, destination := range streamer.DestinationsOf(model.DestinationMatrix) {
+		var destinationSettings Settings
+		if err := json.Unmarshal(destination.Settings, &destinationSettings); err != nil {
+			logging.Log("Matrix", logrus.ErrorLevel, fmt.Sprintf("Ошибка разбора настроек Matrix у стримера %s: %v", streamer.Name, err))
+			continue
+		}
+		settings = append(settings, destinationSettings)
+	}
+	return settings
+}
+
+// uploadAttachments - загружает вложения и возвращает их mxc:// адреса; при ошибке адрес остается пустым
+func uploadAttachments(client *Client, attachments []model.Attachment) []string {
+	contentURIs := make([]string, len(attachments))
+	for idx, attachment := range attachments {
+		contentURI, err := client.Upload(attachment.Data, attachment.Name, contentType(attachment))
+		if err != nil {
+			logging.Log("Matrix", logrus.ErrorLevel, fmt.Sprintf("Ошибка загрузки вложения %s: %v", attachment.Name, err))
+			continue
+		}
+		contentURIs[idx] = contentURI
+	}
+	return contentURIs
+}
+
+// buildTextContent - создает содержимое текстового события; для поста без текста и ссылки возвращает nil
+func buildTextContent(post *model.Post) map[string]interface{} {
+	body := post.Text
+	formattedBody := format.HTML(post.Text, post.Entities)
+
+	if post.Repost != nil && post.Repost.AuthorName != "" {
+		header := fmt.Sprintf("Переслано из %s", post.Repost.AuthorName)
+		if post.Repost.Type == model.RepostOriginUser || post.Repost.Type == model.RepostOriginHiddenUser {
+			header = fmt.Sprintf("Переслано от %s", post.Repost.AuthorName)
+		}
+		body = header + "\n" + body
+		formattedBody = "<strong>" + html.EscapeString(header) + "</strong><br>" + formattedBody
+	}
+
+	if post.Link != "" {
+		body += "\n\nОригинальный пост: " + post.Link
+		formattedBody += `<br><br>Оригинальный пост: <a href="` + html.EscapeString(post.Link) + `">` + html.EscapeString(post.Link) + "</a>"
+	}
+
+	if body == "" {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"msgtype":        "m.text",
+		"body":           body,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formattedBody,
+	}
+}
+
+// buildMediaContent - создает содержимое события с вложением
+func buildMediaContent(attachment model.Attachment, contentURI string) map[string]interface{} {
+	msgType := "m.file"
+	switch attachment.Kind {
+	case model.AttachmentPhoto, model.AttachmentSticker:
+		msgType = "m.image"
+	case model.AttachmentVideo, model.AttachmentVideoNote:
+		msgType = "m.video"
+	case model.AttachmentAudio, model.AttachmentVoice:
+		msgType = "m.audio"
+	}
+
+	return map[string]interface{}{
+		"msgtype": msgType,
+		"body":    attachment.Name,
+		"url":     contentURI,
+		"info": map[string]interface{}{
+			"mimetype": contentType(attachment),
+			"size":     len(attachment.Data),
+		},
+	}
+}
+
+func contentType(attachment model.Attachment) string {
+	switch attachment.Kind {
+	case model.AttachmentPhoto:
+		return "image/jpeg"
+	case model.AttachmentSticker:
+		return "image/webp"
+	case model.AttachmentVideo, model.AttachmentVideoNote:
+		return "video/mp4"
+	case model.AttachmentAudio:
+		return "audio/mpeg"
+	case model.AttachmentVoice:
+		return "audio/ogg"
+	default:
+		return http.DetectContentType(attachment.Data)
+	}
+}
@@ -0,0 +1,247 @@
+package matrix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/database/dbtest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testAccessToken = "matrix-token"
+
+type sentEvent struct {
+	RoomID  string
+	EventID string
+	Content map[string]interface{}
+}
+
+// fakeHomeserver - локальный сервер Matrix, запоминающий загрузки, события и redaction
+type fakeHomeserver struct {
+	server *httptest.Server
+
+	mutex    sync.Mutex
+	counter  int
+	uploads  []string
+	events   []sentEvent
+	redacted []string
+}
+
+func newFakeHomeserver(t *testing.T) *fakeHomeserver {
+	fake := &fakeHomeserver{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /_matrix/media/v3/upload", fake.authorized(func(w http.ResponseWriter, r *http.Request) {
+		fake.uploads = append(fake.uploads, r.URL.Query().Get("filename"))
+		writeTestJSON(w, map[string]string{"content_uri": fmt.Sprintf("mxc://test/%d", len(fake.uploads))})
+	}))
+	mux.HandleFunc("PUT /_matrix/client/v3/rooms/{room}/send/m.room.message/{txn}", fake.authorized(func(w http.ResponseWriter, r *http.Request) {
+		var content map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&content); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		fake.counter++
+		event := sentEvent{RoomID: r.PathValue("room"), EventID: fmt.Sprintf("$event%d", fake.counter), Content: content}
+		fake.events = append(fake.events, event)
+		writeTestJSON(w, map[string]string{"event_id": event.EventID})
+	}))
+	mux.HandleFunc("PUT /_matrix/client/v3/rooms/{room}/redact/{event}/{txn}", fake.authorized(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		fake.redacted = append(fake.redacted, r.PathValue("event"))
+		writeTestJSON(w, map[string]string{"event_id": "$redaction"})
+	}))
+
+	fake.server = httptest.NewServer(mux)
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+func (f *fakeHomeserver) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer "+testAccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeTestJSON(w, map[string]string{"errcode": "M_UNKNOWN_TOKEN", "error": "Invalid access token"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (f *fakeHomeserver) streamer(rooms ...string) *model.Streamer {
+	settings, _ := json.Marshal(Settings{Homeserver: f.server.URL, AccessToken: testAccessToken, Rooms: rooms})
+	return &model.Streamer{
+		Name:         "Test",
+		Destinations: []model.Destination{{Type: model.DestinationMatrix, Settings: settings}},
+	}
+}
+
+func writeTestJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func textPost(msgID int, text string) *model.Post {
+	return &model.Post{ChatID: -100, MessageID: msgID, Parts: []model.PostPart{{MessageID: msgID}}, Text: text}
+}
+
+func photoPost(msgID int, text string) *model.Post {
+	post := textPost(msgID, text)
+	post.Parts[0].AttachmentID = "photo"
+	post.Attachments = []model.Attachment{{Kind: model.AttachmentPhoto, Name: "photo.jpg", MessageID: msgID, Data: []byte("jpeg")}}
+	return post
+}
+
+func TestPublishTextWithPhoto(t *testing.T) {
+	fake := newFakeHomeserver(t)
+	publisher := NewPublisher(dbtest.New(t))
+
+	publisher.Publish(fake.streamer("!room:test"), photoPost(1, "Привет"))
+
+	if len(fake.uploads) != 1 || fake.uploads[0] != "photo.jpg" {
+		t.Fatalf("ожидалась загрузка фото, получено %v", fake.uploads)
+	}
+	if len(fake.events) != 2 {
+		t.Fatalf("ожидались текст и фото, отправлено событий %d", len(fake.events))
+	}
+	if text := fake.events[0].Content; text["msgtype"] != "m.text" || text["body"] != "Привет" {
+		t.Fatalf("неверное текстовое событие: %v", text)
+	}
+	if image := fake.events[1].Content; image["msgtype"] != "m.image" || image["url"] != "mxc://test/1" {
+		t.Fatalf("неверное событие с фото: %v", image)
+	}
+
+	messages, err := publisher.DBHandlers.MessageHandlers.GetMessageByID("!room:test", 1)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("связь поста не сохранена: %v", err)
+	}
+	if messages[0].DestinationMsgID != "$event1" || messages[0].DestinationAttachmentID != "$event2" {
+		t.Fatalf("неверная связь поста: %+v", messages[0])
+	}
+}
+
+func TestEditReplacesTextEvent(t *testing.T) {
+	fake := newFakeHomeserver(t)
+	publisher := NewPublisher(dbtest.New(t))
+	streamer := fake.streamer("!room:test")
+
+	publisher.Publish(streamer, photoPost(1, "Старый текст"))
+	publisher.Edit(streamer, textPost(1, "Новый текст"))
+
+	if len(fake.events) != 3 {
+		t.Fatalf("ожидалось событие изменения, всего событий %d", len(fake.events))
+	}
+	edit := fake.events[2].Content
+	relation, _ := edit["m.relates_to"].(map[string]interface{})
+	if relation["rel_type"] != "m.replace" || relation["event_id"] != "$event1" {
+		t.Fatalf("изменение должно заменять текстовое событие: %v", relation)
+	}
+	newContent, _ := edit["m.new_content"].(map[string]interface{})
+	if newContent["body"] != "Новый текст" || !strings.HasPrefix(edit["body"].(string), "* ") {
+		t.Fatalf("неверное содержимое изменения: %v", edit)
+	}
+}
+
+func TestEditSkipsMediaOnlyPost(t *testing.T) {
+	fake := newFakeHomeserver(t)
+	publisher := NewPublisher(dbtest.New(t))
+	streamer := fake.streamer("!room:test")
+
+	publisher.Publish(streamer, photoPost(1, ""))
+	if len(fake.events) != 1 {
+		t.Fatalf("ожидалось одно событие с фото, отправлено %d", len(fake.events))
+	}
+
+	publisher.Edit(streamer, textPost(1, "Подпись"))
+	if len(fake.events) != 1 {
+		t.Fatalf("текстовое изменение отправлено для поста без текста: %v", fake.events[1].Content)
+	}
+}
+
+func TestDeleteRedactsAllEvents(t *testing.T) {
+	fake := newFakeHomeserver(t)
+	publisher := NewPublisher(dbtest.New(t))
+	streamer := fake.streamer("!first:test", "!second:test")
+
+	publisher.Publish(streamer, photoPost(1, "Текст"))
+	publisher.Delete(streamer, textPost(1, ""))
+
+	if len(fake.redacted) != 4 {
+		t.Fatalf("ожидалось скрытие текста и фото в двух комнатах, скрыто %v", fake.redacted)
+	}
+	for _, roomID := range []string{"!first:test", "!second:test"} {
+		if messages, _ := publisher.DBHandlers.MessageHandlers.GetMessageByID(roomID, 1); len(messages) != 0 {
+			t.Fatalf("связь поста в комнате %s не удалена", roomID)
+		}
+	}
+}
+
+func TestClientReportsMatrixError(t *testing.T) {
+	fake := newFakeHomeserver(t)
+	client := NewClient(fake.server.URL, "wrong")
+
+	_, err := client.SendMessage("!room:test", map[string]interface{}{"msgtype": "m.text", "body": "test"})
+	if err == nil || !strings.Contains(err.Error(), "M_UNKNOWN_TOKEN") {
+		t.Fatalf("ожидалась ошибка Matrix с кодом, получено %v", err)
+	}
+}
+
+func TestResendOnlyToRoomsWithoutCopy(t *testing.T) {
+	fake := newFakeHomeserver(t)
+	publisher := NewPublisher(dbtest.New(t))
+
+	publisher.Publish(fake.streamer("!first:test"), textPost(1, "Пост"))
+	publisher.Resend(fake.streamer("!first:test", "!second:test"), textPost(1, "Пост"))
+
+	if len(fake.events) != 2 || fake.events[1].RoomID != "!second:test" {
+		t.Fatalf("пост должен дойти только до комнаты без копии, отправлено %+v", fake.events)
+	}
+
+	publisher.Resend(fake.streamer("!first:test", "!second:test"), textPost(1, "Пост"))
+	if len(fake.events) != 2 {
+		t.Fatalf("повторная отправка продублировала пост: %+v", fake.events)
+	}
+}
+
+func TestEditAndDeleteKeepOtherChannelPosts(t *testing.T) {
+	fake := newFakeHomeserver(t)
+	publisher := NewPublisher(dbtest.New(t))
+	first := fake.streamer("!room:test")
+	second := fake.streamer("!room:test")
+	second.Name = "Other"
+
+	// Два канала Telegram с одинаковым ID поста публикуются в одну комнату
+	publisher.Publish(first, textPost(1, "Первый"))
+	otherPost := textPost(1, "Второй")
+	otherPost.ChatID = -200
+	publisher.Publish(second, otherPost)
+
+	edited := textPost(1, "Второй изменен")
+	edited.ChatID = -200
+	publisher.Edit(second, edited)
+
+	relation, _ := fake.events[2].Content["m.relates_to"].(map[string]interface{})
+	if relation["event_id"] != "$event2" {
+		t.Fatalf("изменение должно заменять пост своего канала: %v", relation)
+	}
+
+	publisher.Delete(second, edited)
+	if len(fake.redacted) != 1 || fake.redacted[0] != "$event2" {
+		t.Fatalf("удаление должно скрывать только пост своего канала: %v", fake.redacted)
+	}
+	if messages, _ := publisher.DBHandlers.MessageHandlers.GetChatMessageByID("!room:test", -100, 1); len(messages) != 1 {
+		t.Fatalf("связь поста другого канала удалена")
+	}
+	if messages, _ := publisher.DBHandlers.MessageHandlers.GetChatMessageByID("!room:test", -200, 1); len(messages) != 0 {
+		t.Fatalf("связь удаленного поста осталась")
+	}
+}
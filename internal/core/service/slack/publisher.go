@@ -0,0 +1,273 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"slm-bot-publisher/internal/core/format"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/media"
+	"slm-bot-publisher/logging"
+	"strings"
+	"time"
+)
+
+const (
+	// Ограничение Slack на длину текста в блоке section
+	sectionTextLimit = 3000
+	// Ограничение Slack на количество блоков в сообщении
+	blocksLimit = 50
+	postColor   = "#1b6906"
+)
+
+// Settings - настройки входящего вебхука Slack или Mattermost в конфиге стримера
+type Settings struct {
+	WebhookURL string
+	Username   string
+	IconURL    string
+	Channel    string
+}
+
+// Publisher - отправляет посты во входящие вебхуки Slack или Mattermost.
+// Входящие вебхуки не позволяют менять и удалять сообщения, поэтому правки и удаления не зеркалируются
+type Publisher struct {
+	destinationType string
+	mediaStore      *media.Store
+	httpClient      *http.Client
+}
+
+func NewPublisher(destinationType string, mediaStore *media.Store) *Publisher {
+	return &Publisher{
+		destinationType: destinationType,
+		mediaStore:      mediaStore,
+		httpClient:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type mediaLink struct {
+	attachment model.Attachment
+	url        string
+}
+
+// Publish - отправляет пост во все вебхуки стримера
+func (p *Publisher) Publish(streamer *model.Streamer, post *model.Post) {
+	mediaLinks := p.saveMedia(post.Attachments)
+
+	for _, destination := range p.destinations(streamer) {
+		var payload map[string]interface{}
+		if p.destinationType == model.DestinationMattermost {
+			payload = buildMattermostPayload(post, mediaLinks)
+		} else {
+			payload = buildSlackPayload(post, mediaLinks)
+		}
+
+		if destination.Username != "" {
+			payload["username"] = destination.Username
+		}
+		if destination.IconURL != "" {
+			payload["icon_url"] = destination.IconURL
+		}
+		if destination.Channel != "" {
+			payload["channel"] = destination.Channel
+		}
+
+		if err := p.send(destination.WebhookURL, payload); err != nil {
+			logging.Log("Webhook", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки поста %s в %s: %v", streamer.Name, p.destinationType, err))
+			continue
+		}
+		logging.Log("Webhook", logrus.InfoLevel, fmt.Sprintf("Пост от %s успешно отправлен в %s", streamer.Name, p.destinationType))
+	}
+}
+
+func (p *Publisher) Edit(streamer *model.Streamer, post *model.Post) {
+	logging.Log("Webhook", logrus.DebugLevel, fmt.Sprintf("Входящие вебхуки %s не поддерживают изменение сообщений, пост %d не изменен", p.destinationType, post.MessageID))
+}
+
+func (p *Publisher) Delete(streamer *model.Streamer, post *model.Post) {
+	logging.Log("Webhook", logrus.DebugLevel, fmt.Sprintf("Входящие вебхуки %s не поддерживают удаление сообщений, пост %d не удален", p.destinationType, post.MessageID))
+}
+
+func (p *Publisher) send(webhookURL string, payload map[string]interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.httpClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		response, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("статус %d: %s", resp.StatusCode, strings.TrimSpace(string(response)))
+	}
+	return nil
+}
+
+// saveMedia - сохраняет вложения в хранилище медиа, чтобы сослаться на них из сообщения
+func (p *Publisher) saveMedia(attachments []model.Attachment) []mediaLink {
+	if !p.mediaStore.Enabled() {
+		return nil
+	}
+
+	var links []mediaLink
+	for _, attachment := range attachments {
+		url, err := p.mediaStore.Save(attachment)
+		if err != nil {
+			logging.Log("Webhook", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения вложения %s: %v", attachment.Name, err))
+			continue
+		}
+		links = append(links, mediaLink{attachment: attachment, url: url})
+	}
+	return links
+}
+
+// destinations - разбирает настройки всех вебхуков стримера своего типа
+func (p *Publisher) destinations(streamer *model.Streamer) []Settings {
+	var settings []Settings
+	for _, destination := range streamer.DestinationsOf(p.destinationType) {
+		var destinationSettings Settings
+		if err := json.Unmarshal(destination.Settings, &destinationSettings); err != nil {
+			logging.Log("Webhook", logrus.ErrorLevel, fmt.Sprintf("Ошибка разбора настроек %s у стримера %s: %v", p.destinationType, streamer.Name, err))
+			continue
+		}
+		settings = append(settings, destinationSettings)
+	}
+	return settings
+}
+
+// buildSlackPayload - собирает сообщение Block Kit: текст, изображения и ссылки на остальные вложения
+func buildSlackPayload(post *model.Post, mediaLinks []mediaLink) map[string]interface{} {
+	text := format.Mrkdwn(post.Text, post.Entities)
+	if post.Repost != nil {
+		text = "_" + repostHeader(post.Repost) + "_\n" + text
+	}
+
+	var blocks []map[string]interface{}
+	if strings.TrimSpace(text) != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": truncate(text, sectionTextLimit)},
+		})
+	}
+
+	var files []string
+	for _, link := range mediaLinks {
+		if isImage(link.attachment) && len(blocks) < blocksLimit-2 {
+			blocks = append(blocks, map[string]interface{}{
+				"type":      "image",
+				"image_url": link.url,
+				"alt_text":  altText(post, link.attachment),
+			})
+			continue
+		}
+		files = append(files, fmt.Sprintf("<%s|%s>", link.url, link.attachment.Name))
+	}
+
+	var context []string
+	if len(files) > 0 {
+		context = append(context, "Вложения: "+strings.Join(files, ", "))
+	}
+	if post.Link != "" {
+		context = append(context, fmt.Sprintf("<%s|Оригинальный пост>", post.Link))
+	}
+	if len(context) > 0 {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "context",
+			"elements": []map[string]string{
+				{"type": "mrkdwn", "text": strings.Join(context, " • ")},
+			},
+		})
+	}
+
+	fallback := post.Text
+	if fallback == "" {
+		fallback = "Новый пост"
+	}
+
+	return map[string]interface{}{
+		"text":   truncate(fallback, sectionTextLimit),
+		"blocks": blocks,
+	}
+}
+
+// buildMattermostPayload - собирает сообщение с вложениями Mattermost; каждое изображение идет отдельным вложением
+func buildMattermostPayload(post *model.Post, mediaLinks []mediaLink) map[string]interface{} {
+	text := format.Markdown(post.Text, post.Entities)
+
+	var images []string
+	var files []string
+	for _, link := range mediaLinks {
+		if isImage(link.attachment) {
+			images = append(images, link.url)
+			continue
+		}
+		files = append(files, fmt.Sprintf("[%s](%s)", link.attachment.Name, link.url))
+	}
+	if len(files) > 0 {
+		text += "\n\nВложения: " + strings.Join(files, ", ")
+	}
+
+	attachment := map[string]interface{}{
+		"fallback": post.Text,
+		"color":    postColor,
+		"text":     text,
+	}
+	if post.Repost != nil {
+		attachment["author_name"] = repostHeader(post.Repost)
+		if post.Repost.AuthorLink != "" {
+			attachment["author_link"] = post.Repost.AuthorLink
+		}
+	}
+	if post.Link != "" {
+		attachment["footer"] = "Оригинальный пост: " + post.Link
+	}
+	if len(images) > 0 {
+		attachment["image_url"] = images[0]
+	}
+
+	attachments := []map[string]interface{}{attachment}
+	for _, image := range images[min(1, len(images)):] {
+		attachments = append(attachments, map[string]interface{}{
+			"fallback":  "Изображение",
+			"color":     postColor,
+			"image_url": image,
+		})
+	}
+
+	return map[string]interface{}{
+		"attachments": attachments,
+	}
+}
+
+func repostHeader(repost *model.RepostOrigin) string {
+	if repost.Type == model.RepostOriginUser || repost.Type == model.RepostOriginHiddenUser {
+		return "Переслано от " + repost.AuthorName
+	}
+	return "Переслано из " + repost.AuthorName
+}
+
+func isImage(attachment model.Attachment) bool {
+	return attachment.Kind == model.AttachmentPhoto || attachment.Kind == model.AttachmentSticker
+}
+
+// altText - описание изображения для программ чтения с экрана, берется из подписи поста
+func altText(post *model.Post, attachment model.Attachment) string {
+	if post.Text != "" {
+		return truncate(post.Text, 2000)
+	}
+	return attachment.Name
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
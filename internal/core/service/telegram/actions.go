@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/logging"
+)
+
+// ResendPost - досылает пост канала вместе с альбомом на площадки, где его копии нет, как команда /resend.
+// Bot API не отдает старые сообщения, поэтому пост пересылается в служебный чат viaChatID
+func (t *BotTelegram) ResendPost(streamer *model.Streamer, msgID int, viaChatID int64, silent bool) error {
+	channel, err := getChannel(t.Bot, streamer)
+	if err != nil {
+		return err
+	}
+
+	message, err := forwardMessage(t.Bot, viaChatID, channel, msgID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения сообщения %d: %v", msgID, err)
+	}
+
+	post := buildResendPost(t.Bot, t.DBHandlers, t.token, t.channelCache, channel, message, viaChatID)
+	post.Silent = silent
+	t.publishers.Resend(streamer, post)
+
+	logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Пост %d от %s отправлен повторно (без упоминания: %t)", msgID, streamer.Name, silent))
+	return nil
+}
+
+// DeletePost - удаляет пост из канала и все его копии на площадках, как команда /delete
+func (t *BotTelegram) DeletePost(streamer *model.Streamer, msgID int) {
+	deletePost(t.Bot, t.publishers, t.DBHandlers, streamer, msgID)
+	logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Пост %d от %s удален", msgID, streamer.Name))
+}
+
+// RetryDelivery - повторяет неудачную отправку поста в канал Discord; пустой channelID означает все каналы.
+// Сообщения поста получаются пересылкой в служебный чат viaChatID
+func (t *BotTelegram) RetryDelivery(streamer *model.Streamer, channelID string, msgIDs []int, viaChatID int64) error {
+	discord, exists := t.publishers.Get(model.DestinationDiscord)
+	if !exists {
+		return fmt.Errorf("площадка Discord не зарегистрирована")
+	}
+
+	target := *streamer
+	if channelID != "" {
+		target.DiscordChannels = nil
+		for _, channel := range streamer.DiscordChannels {
+			if channel.ChannelID == channelID {
+				// Задержка канала уже прошла при первой попытке
+				channel.Delay = ""
+				target.DiscordChannels = append(target.DiscordChannels, channel)
+			}
+		}
+		if len(target.DiscordChannels) == 0 {
+			return fmt.Errorf("канал %s не настроен у стримера %s", channelID, streamer.Name)
+		}
+	}
+
+	channel, err := getChannel(t.Bot, streamer)
+	if err != nil {
+		return err
+	}
+	group := fetchPost(t.Bot, viaChatID, channel, msgIDs)
+	if len(group) == 0 {
+		return fmt.Errorf("сообщения поста %d не получены", msgIDs[0])
+	}
+
+	post := buildPost(group, t.token)
+	if isForwarded(group[0].ChannelPost) {
+		post.Repost = buildRepostOrigin(group[0].ChannelPost, t.token, t.channelCache)
+	}
+	discord.Publish(&target, post)
+
+	logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Повторена отправка поста %d от %s в Discord", post.MessageID, streamer.Name))
+	return nil
+}
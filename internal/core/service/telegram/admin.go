@@ -0,0 +1,387 @@
+package telegram
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/core/pause"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// AdminFailureCount - сколько последних ошибок показывает админка
+	AdminFailureCount = 10
+	// AdminFailureLength - ошибки длиннее обрезаются, чтобы список поместился в одно сообщение
+	AdminFailureLength = 300
+)
+
+// DiscordActions - действия с Discord, доступные из админки и команд канала
+type DiscordActions interface {
+	SendTestMessage(streamer *model.Streamer, channel model.DiscordChannel) error
+	SchedulePost(streamer *model.Streamer, post *model.Post, sendAt time.Time) (int, error)
+}
+
+// adminConsole - админка в личных сообщениях бота. Стримеры адресуются в кнопках по ID канала Telegram,
+// каналы - по ID канала Discord: номера в списке сдвигаются после удаления, а данные кнопки ограничены 64 байтами
+type adminConsole struct {
+	bot     *tgbotapi.BotAPI
+	storage *storage.Storage
+	discord DiscordActions
+	pause   *pause.Controller
+	admins  map[int64]bool
+	// awaitingPrefix - админы, от которых ожидается новое упоминание для канала
+	awaitingPrefix map[int64]channelRef
+	mutex          sync.Mutex
+}
+
+type channelRef struct {
+	streamer string
+	// channel - ID канала Discord
+	channel string
+}
+
+func newAdminConsole(bot *tgbotapi.BotAPI, storage *storage.Storage, discord DiscordActions, pause *pause.Controller, adminIDs []int64) *adminConsole {
+	admins := make(map[int64]bool)
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
+
+	return &adminConsole{
+		bot:            bot,
+		storage:        storage,
+		discord:        discord,
+		pause:          pause,
+		admins:         admins,
+		awaitingPrefix: make(map[int64]channelRef),
+	}
+}
+
+// handleMessage - отвечает на личное сообщение: принимает ожидаемое упоминание или показывает список стримеров
+func (a *adminConsole) handleMessage(message *tgbotapi.Message) {
+	if message.From == nil || !a.admins[message.From.ID] {
+		logging.Log("Telegram", logrus.WarnLevel, fmt.Sprintf("Попытка доступа к админке от пользователя %d", message.Chat.ID))
+		return
+	}
+
+	a.mutex.Lock()
+	ref, awaiting := a.awaitingPrefix[message.From.ID]
+	delete(a.awaitingPrefix, message.From.ID)
+	a.mutex.Unlock()
+
+	if awaiting && message.Text != "/cancel" {
+		a.setPrefix(message, ref)
+		return
+	}
+
+	text, markup := a.streamersView()
+	a.send(message.Chat.ID, text, &markup)
+}
+
+func (a *adminConsole) setPrefix(message *tgbotapi.Message, ref channelRef) {
+	prefix := strings.TrimSpace(message.Text)
+	if prefix == "-" {
+		prefix = ""
+	}
+	if _, err := strconv.ParseUint(prefix, 10, 64); prefix != "" && prefix != "@everyone" && prefix != "@here" && err != nil {
+		a.mutex.Lock()
+		a.awaitingPrefix[message.From.ID] = ref
+		a.mutex.Unlock()
+		a.send(message.Chat.ID, "Упоминание должно быть ID роли, @everyone или @here. /cancel - отмена", nil)
+		return
+	}
+
+	found := false
+	err := a.storage.Update(ref.streamer, func(streamer *model.Streamer) {
+		if idx := channelIndex(streamer, ref.channel); idx >= 0 {
+			streamer.DiscordChannels[idx].Prefix = prefix
+			found = true
+		}
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("канал %s не найден", ref.channel)
+	}
+	if err != nil {
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка изменения упоминания канала %s: %v", ref.streamer, err))
+		a.send(message.Chat.ID, fmt.Sprintf("Не удалось сохранить: %v", err), nil)
+		return
+	}
+	logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Пользователь %d изменил упоминание канала %s стримера %s", message.From.ID, ref.channel, ref.streamer))
+
+	text, markup := a.channelView(ref)
+	a.send(message.Chat.ID, text, &markup)
+}
+
+// handleCallback - обрабатывает нажатие кнопки и перерисовывает сообщение админки
+func (a *adminConsole) handleCallback(callback *tgbotapi.CallbackQuery) {
+	if !a.admins[callback.From.ID] || callback.Message == nil {
+		a.answer(callback, "Нет доступа")
+		return
+	}
+
+	action, args := parseCallbackData(callback.Data)
+	var ref channelRef
+	if len(args) > 0 {
+		telegramID, err := strconv.ParseInt(args[0], 10, 64)
+		var streamer *model.Streamer
+		if err == nil {
+			streamer = a.storage.GetStreamerByTelegramID(telegramID)
+		}
+		if streamer == nil {
+			a.answer(callback, "Стример не найден, откройте список заново")
+			return
+		}
+		ref.streamer = streamer.Name
+		if len(args) > 1 {
+			ref.channel = args[1]
+			if channelIndex(streamer, ref.channel) < 0 {
+				a.answer(callback, "Канал не найден, откройте список заново")
+				return
+			}
+		}
+	}
+
+	notice := ""
+	var text string
+	var markup tgbotapi.InlineKeyboardMarkup
+
+	switch action {
+	case "s":
+		text, markup = a.streamerView(ref.streamer)
+	case "st":
+		notice = a.togglePause(ref.streamer, "")
+		text, markup = a.streamerView(ref.streamer)
+	case "c":
+		text, markup = a.channelView(ref)
+	case "ct":
+		notice = a.togglePause(ref.streamer, ref.channel)
+		text, markup = a.channelView(ref)
+	case "cp":
+		a.mutex.Lock()
+		a.awaitingPrefix[callback.From.ID] = ref
+		a.mutex.Unlock()
+		a.answer(callback, "")
+		a.send(callback.Message.Chat.ID, "Отправьте ID роли, @everyone или @here. «-» убирает упоминание, /cancel - отмена", nil)
+		return
+	case "cs":
+		a.answer(callback, a.sendTest(ref))
+		return
+	case "f":
+		text, markup = failuresView()
+	default:
+		text, markup = a.streamersView()
+	}
+
+	a.answer(callback, notice)
+	edit := tgbotapi.NewEditMessageTextAndMarkup(callback.Message.Chat.ID, callback.Message.MessageID, text, markup)
+	if _, err := a.bot.Request(edit); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка обновления сообщения админки: %v", err))
+	}
+}
+
+// togglePause - ставит на паузу или возобновляет стримера либо канал и возвращает текст уведомления
+func (a *adminConsole) togglePause(name, channelID string) string {
+	streamer := a.storage.GetStreamerByName(name)
+	if streamer == nil {
+		return "Стример не найден"
+	}
+
+	paused := !streamer.Paused
+	if channelID != "" {
+		paused = !isChannelPaused(streamer, channelID)
+	}
+
+	if err := a.pause.SetPaused(name, channelID, paused); err != nil {
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка изменения паузы %s из админки: %v", name, err))
+		return "Не удалось сохранить изменения"
+	}
+	logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Пауза %s %s изменена из админки: %t", name, channelID, paused))
+
+	if !paused && streamer.PauseMode == model.PauseQueue {
+		return "Зеркалирование возобновлено, отложенные посты отправляются"
+	}
+	return ""
+}
+
+func (a *adminConsole) sendTest(ref channelRef) string {
+	streamer := a.storage.GetStreamerByName(ref.streamer)
+	if streamer == nil {
+		return "Стример не найден"
+	}
+	idx := channelIndex(streamer, ref.channel)
+	if idx < 0 {
+		return "Канал не найден"
+	}
+
+	if err := a.discord.SendTestMessage(streamer, streamer.DiscordChannels[idx]); err != nil {
+		logging.Log("Discord", logrus.ErrorLevel, fmt.Sprintf("Ошибка проверочной отправки в канал %s: %v", ref.channel, err))
+		return "Ошибка отправки: " + err.Error()
+	}
+	return "Проверочное сообщение отправлено"
+}
+
+func (a *adminConsole) streamersView() (string, tgbotapi.InlineKeyboardMarkup) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, streamer := range a.storage.All() {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(streamer.Name+pauseMark(streamer.Paused), fmt.Sprintf("s:%d", streamer.TelegramChannelID)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Последние ошибки", "f")))
+
+	return "Стримеры:", tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (a *adminConsole) streamerView(name string) (string, tgbotapi.InlineKeyboardMarkup) {
+	streamer := a.storage.GetStreamerByName(name)
+	if streamer == nil {
+		return a.streamersView()
+	}
+
+	var text strings.Builder
+	mirroring := "работает"
+	if streamer.Paused {
+		mirroring = "на паузе"
+	}
+	text.WriteString(fmt.Sprintf("Стример %s\nЗеркалирование: %s\nПлощадки: %s", streamer.Name, mirroring, strings.Join(streamer.DestinationTypes(), ", ")))
+	if len(streamer.DiscordChannels) > 0 {
+		text.WriteString("\nКаналы Discord:")
+	}
+
+	toggle := "Приостановить зеркалирование"
+	if streamer.Paused {
+		toggle = "Возобновить зеркалирование"
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(toggle, fmt.Sprintf("st:%d", streamer.TelegramChannelID))),
+	}
+	for channelIdx, channel := range streamer.DiscordChannels {
+		text.WriteString(fmt.Sprintf("\n%d. %s", channelIdx+1, describeChannel(channel)))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d. %s%s", channelIdx+1, channel.ChannelID, pauseMark(channel.Paused)), fmt.Sprintf("c:%d:%s", streamer.TelegramChannelID, channel.ChannelID)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("« Стримеры", "m")))
+
+	return text.String(), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (a *adminConsole) channelView(ref channelRef) (string, tgbotapi.InlineKeyboardMarkup) {
+	streamer := a.storage.GetStreamerByName(ref.streamer)
+	if streamer == nil {
+		return a.streamersView()
+	}
+	idx := channelIndex(streamer, ref.channel)
+	if idx < 0 {
+		return a.streamerView(ref.streamer)
+	}
+	channel := streamer.DiscordChannels[idx]
+
+	text := fmt.Sprintf("Стример %s, канал %s\n%s\nПравил маршрутизации: %d, преобразований текста: %d",
+		streamer.Name, channel.ChannelID, describeChannel(channel), len(channel.Rules), len(channel.Rewrite))
+
+	toggle := "Приостановить канал"
+	if channel.Paused {
+		toggle = "Возобновить канал"
+	}
+	data := fmt.Sprintf("%d:%s", streamer.TelegramChannelID, channel.ChannelID)
+	markup := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(toggle, "ct:"+data)),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Изменить упоминание", "cp:"+data),
+			tgbotapi.NewInlineKeyboardButtonData("Проверить отправку", "cs:"+data),
+		),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("« "+streamer.Name, fmt.Sprintf("s:%d", streamer.TelegramChannelID))),
+	)
+
+	return text, markup
+}
+
+func failuresView() (string, tgbotapi.InlineKeyboardMarkup) {
+	recent := logging.RecentFailures(AdminFailureCount)
+
+	var text strings.Builder
+	if len(recent) == 0 {
+		text.WriteString("Ошибок с момента запуска не было")
+	} else {
+		text.WriteString("Последние ошибки:")
+	}
+	for _, failure := range recent {
+		message := []rune(failure.Message)
+		if len(message) > AdminFailureLength {
+			message = append(message[:AdminFailureLength-1], '…')
+		}
+		text.WriteString(fmt.Sprintf("\n\n%s [%s]: %s", failure.Time.Format("2006-01-02 15:04:05"), failure.Module, string(message)))
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Обновить", "f"),
+		tgbotapi.NewInlineKeyboardButtonData("« Стримеры", "m"),
+	))
+	return text.String(), markup
+}
+
+func (a *adminConsole) send(chatID int64, text string, markup *tgbotapi.InlineKeyboardMarkup) {
+	message := tgbotapi.NewMessage(chatID, text)
+	if markup != nil {
+		message.ReplyMarkup = *markup
+	}
+	if _, err := a.bot.Send(message); err != nil {
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки сообщения админки: %v", err))
+	}
+}
+
+func (a *adminConsole) answer(callback *tgbotapi.CallbackQuery, text string) {
+	if _, err := a.bot.Request(tgbotapi.NewCallback(callback.ID, text)); err != nil {
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка ответа на нажатие кнопки: %v", err))
+	}
+}
+
+// parseCallbackData - разбирает данные кнопки вида "действие:канал Telegram:канал Discord"
+func parseCallbackData(data string) (string, []string) {
+	parts := strings.Split(data, ":")
+	return parts[0], parts[1:]
+}
+
+func describeChannel(channel model.DiscordChannel) string {
+	prefix := channel.Prefix
+	if prefix == "" {
+		prefix = "нет"
+	}
+	state := "работает"
+	if channel.Paused {
+		state = "на паузе"
+	}
+	return fmt.Sprintf("отправка %s, упоминание %s", state, prefix)
+}
+
+func pauseMark(paused bool) string {
+	if paused {
+		return " (пауза)"
+	}
+	return ""
+}
+
+// channelIndex - возвращает номер канала Discord у стримера или -1, если канала нет
+func channelIndex(streamer *model.Streamer, channelID string) int {
+	for idx, channel := range streamer.DiscordChannels {
+		if channel.ChannelID == channelID {
+			return idx
+		}
+	}
+	return -1
+}
+
+func isChannelPaused(streamer *model.Streamer, channelID string) bool {
+	for _, channel := range streamer.DiscordChannels {
+		if channel.ChannelID == channelID {
+			return channel.Paused
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"slm-bot-publisher/internal/lib/cache"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	"sort"
+	"time"
+)
+
+// albumTTL - сколько помнится состав альбомов; более старые альбомы восстанавливаются по копиям в базе
+const albumTTL = 7 * 24 * time.Hour
+
+// recentAlbums - ID сообщений недавних альбомов по каждому их сообщению. Bot API не отдает альбом
+// по одному сообщению, а при пересылке ID группы теряется, поэтому состав запоминается при получении
+var recentAlbums = cache.New[sentMessageKey, []int](albumTTL)
+
+// rememberAlbum - запоминает состав полученного альбома
+func rememberAlbum(updates []tgbotapi.Update) {
+	if len(updates) < 2 {
+		return
+	}
+
+	msgIDs := make([]int, 0, len(updates))
+	for _, update := range updates {
+		msgIDs = append(msgIDs, update.ChannelPost.MessageID)
+	}
+	sort.Ints(msgIDs)
+
+	recentAlbums.Prune()
+	chatID := updates[0].ChannelPost.Chat.ID
+	for _, msgID := range msgIDs {
+		recentAlbums.Set(sentMessageKey{chatID: chatID, msgID: msgID}, msgIDs)
+	}
+}
+
+// albumMessageIDs - возвращает ID всех сообщений поста, в который входит msgID, по возрастанию.
+// Если альбом не запомнен, его состав берется из копий поста в базе
+func albumMessageIDs(DBHandlers *handlers.DBHandlers, chatID int64, msgID int) []int {
+	if msgIDs, exists := recentAlbums.Get(sentMessageKey{chatID: chatID, msgID: msgID}); exists {
+		return msgIDs
+	}
+
+	seen := map[int]bool{msgID: true}
+	msgIDs := []int{msgID}
+	messages, err := DBHandlers.MessageHandlers.GetMessagesByTelegramID(chatID, msgID)
+	if err == nil {
+		for _, message := range messages {
+			if !seen[message.TelegramMsgID] {
+				seen[message.TelegramMsgID] = true
+				msgIDs = append(msgIDs, message.TelegramMsgID)
+			}
+		}
+	}
+	sort.Ints(msgIDs)
+	return msgIDs
+}
@@ -0,0 +1,211 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/core/publisher"
+	"slm-bot-publisher/internal/lib/cache"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+	"time"
+)
+
+const (
+	// LocalFilePrefix - ID файла из экспорта Telegram Desktop: файл читается с диска, а не скачивается
+	LocalFilePrefix = "local:"
+	// FetchInterval - пауза между пересылками при получении сообщений по ID
+	FetchInterval = 100 * time.Millisecond
+)
+
+// Backfill - перенос старых постов канала. Посты превращаются в синтезированные обновления Telegram
+// и проходят через обычные обработчики, поэтому публикуются так же, как новые
+type Backfill struct {
+	Bot        *tgbotapi.BotAPI
+	Storage    *storage.Storage
+	Publishers *publisher.Registry
+	DBHandlers *handlers.DBHandlers
+	Token      string
+	// Interval - пауза между постами, чтобы не упираться в ограничения Discord
+	Interval     time.Duration
+	channelCache *cache.Cache[int64, *model.ChannelInfo]
+}
+
+func NewBackfill(token string, storage *storage.Storage, publishers *publisher.Registry, DBHandlers *handlers.DBHandlers, interval time.Duration) (*Backfill, error) {
+	bot, err := tgbotapi.NewBotAPI(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Backfill{
+		Bot:          bot,
+		Storage:      storage,
+		Publishers:   publishers,
+		DBHandlers:   DBHandlers,
+		Token:        token,
+		Interval:     interval,
+		channelCache: cache.New[int64, *model.ChannelInfo](time.Hour),
+	}, nil
+}
+
+// FetchRange - получает посты канала с ID от fromID до toID. Bot API не отдает старые сообщения,
+// поэтому каждое пересылается в служебный чат viaChatID, откуда копия сразу удаляется
+func (b *Backfill) FetchRange(streamer *model.Streamer, fromID, toID int, viaChatID int64) ([]tgbotapi.Update, error) {
+	channel, err := b.channel(streamer)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []tgbotapi.Update
+	for msgID := fromID; msgID <= toID; msgID++ {
+		message, err := b.forward(viaChatID, channel, msgID)
+		time.Sleep(FetchInterval)
+		if err != nil {
+			// Удаленные и служебные сообщения переслать нельзя
+			logging.Log("Telegram", logrus.DebugLevel, fmt.Sprintf("Сообщение %d пропущено: %v", msgID, err))
+			continue
+		}
+		updates = append(updates, tgbotapi.Update{ChannelPost: message})
+	}
+
+	return updates, nil
+}
+
+func (b *Backfill) forward(viaChatID int64, channel *tgbotapi.Chat, msgID int) (*tgbotapi.Message, error) {
+	return forwardMessage(b.Bot, viaChatID, channel, msgID)
+}
+
+// Run - публикует посты по порядку, пропуская уже перенесенные. Возвращает число опубликованных и пропущенных постов
+func (b *Backfill) Run(streamer *model.Streamer, updates []tgbotapi.Update) (int, int) {
+	groupAlbums(updates)
+
+	published, skipped := 0, 0
+	for start := 0; start < len(updates); {
+		end := start + 1
+		groupID := updates[start].ChannelPost.MediaGroupID
+		for groupID != "" && end < len(updates) && updates[end].ChannelPost.MediaGroupID == groupID {
+			end++
+		}
+		group := updates[start:end]
+		start = end
+
+		if b.isMirrored(group) {
+			skipped++
+			continue
+		}
+
+		first := group[0].ChannelPost
+		logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Перенос поста %d от %s", first.MessageID, streamer.Name))
+		switch {
+		case isForwarded(first):
+			HandleTelegramRepostUpdate(group, b.Storage, b.Publishers, b.Token, b.channelCache)
+		case groupID != "":
+			HandleTelegramUpdateGroup(group, b.Storage, b.Publishers, b.Token, b.DBHandlers)
+		default:
+			HandleTelegramUpdate(group[0], b.Storage, b.Publishers, b.Token, b.DBHandlers)
+		}
+		published++
+
+		time.Sleep(b.Interval)
+	}
+
+	return published, skipped
+}
+
+// isMirrored - проверяет, есть ли у поста копии на площадках
+func (b *Backfill) isMirrored(group []tgbotapi.Update) bool {
+	for _, update := range group {
+		deliveries, err := b.DBHandlers.MessageHandlers.GetDeliveries(update.ChannelPost.Chat.ID, update.ChannelPost.MessageID)
+		if err == nil && len(deliveries) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Backfill) channel(streamer *model.Streamer) (*tgbotapi.Chat, error) {
+	return getChannel(b.Bot, streamer)
+}
+
+// groupAlbums - восстанавливает альбомы там, где ID группы медиа потерян: и пересылка по одному,
+// и экспорт Telegram Desktop его не сохраняют. Альбомом считаются идущие подряд сообщения
+// с вложениями, опубликованные в одну секунду. Репосты не группируются: после пересылки в служебный чат
+// у них остается только время пересылки или время оригинала, но не время репоста в канале
+func groupAlbums(updates []tgbotapi.Update) {
+	for start := 0; start < len(updates); {
+		first := updates[start].ChannelPost
+		end := start + 1
+		for end < len(updates) && isSameAlbum(first, updates[end].ChannelPost) {
+			end++
+		}
+
+		if end-start > 1 {
+			groupID := fmt.Sprintf("backfill-%d", first.MessageID)
+			for idx := start; idx < end; idx++ {
+				updates[idx].ChannelPost.MediaGroupID = groupID
+			}
+		}
+		start = end
+	}
+}
+
+func isSameAlbum(first, message *tgbotapi.Message) bool {
+	if first.MediaGroupID != "" || message.MediaGroupID != "" {
+		return false
+	}
+	if isForwarded(first) || isForwarded(message) {
+		return false
+	}
+	return first.Date == message.Date && hasMedia(first) && hasMedia(message)
+}
+
+func hasMedia(message *tgbotapi.Message) bool {
+	found := false
+	processMedia(message, func(kind, fileID, fileName string) {
+		found = true
+	})
+	return found
+}
+
+// forwardMessage - получает сообщение канала по ID: пересылает его в служебный чат viaChatID
+// и сразу удаляет копию. Сообщение восстанавливается так, будто пришло из канала
+func forwardMessage(bot *tgbotapi.BotAPI, viaChatID int64, channel *tgbotapi.Chat, msgID int) (*tgbotapi.Message, error) {
+	resp, err := bot.Request(tgbotapi.NewForward(viaChatID, channel.ID, msgID))
+	if err != nil {
+		return nil, err
+	}
+
+	var message tgbotapi.Message
+	if err = json.Unmarshal(resp.Result, &message); err != nil {
+		return nil, err
+	}
+	var raw rawMessage
+	if err = json.Unmarshal(resp.Result, &raw); err != nil {
+		return nil, err
+	}
+	applyForwardOrigin(&message, raw.ForwardOrigin)
+	DeletePostFromChannel(viaChatID, message.MessageID, bot)
+
+	// У собственного поста канала источником пересылки указан сам канал; у репоста - его автор
+	if message.ForwardFromChat != nil && message.ForwardFromChat.ID == channel.ID && message.ForwardFromMessageID == msgID {
+		message.Date = message.ForwardDate
+		message.ForwardFromChat, message.ForwardFromMessageID, message.ForwardSignature, message.ForwardDate = nil, 0, "", 0
+	}
+
+	message.MessageID = msgID
+	message.Chat = channel
+	message.SenderChat = channel
+	message.From = nil
+	return &message, nil
+}
+
+func getChannel(bot *tgbotapi.BotAPI, streamer *model.Streamer) (*tgbotapi.Chat, error) {
+	chat, err := bot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: streamer.TelegramChannelID}})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения канала %d: %v", streamer.TelegramChannelID, err)
+	}
+	return &chat, nil
+}
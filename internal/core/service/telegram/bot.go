@@ -8,7 +8,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"slm-bot-publisher/config"
-	"slm-bot-publisher/internal/core/service/discord"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/core/pause"
+	"slm-bot-publisher/internal/core/publisher"
+	"slm-bot-publisher/internal/lib/cache"
 	"slm-bot-publisher/internal/lib/database/handlers"
 	"slm-bot-publisher/internal/lib/storage"
 	"slm-bot-publisher/logging"
@@ -28,42 +31,56 @@ type BotTelegram struct {
 	updateGroupMutex     sync.Mutex
 	updateHandler        func(update tgbotapi.Update)
 	updateRepostHandler  func(updates []tgbotapi.Update)
-	updateEditHandler    func(update tgbotapi.Update, DBHandlers *handlers.DBHandlers)
+	updateEditHandler    func(update tgbotapi.Update)
 	updateGroupHandler   func(updates []tgbotapi.Update)
 	commandHandler       func(update tgbotapi.Update, DBHandlers *handlers.DBHandlers)
+	commentHandler       func(update tgbotapi.Update)
+	admin                *adminConsole
 	flushInterval        time.Duration
 	updateGroupFlushTime time.Duration
 	DBHandlers           *handlers.DBHandlers
+	channelCache         *cache.Cache[int64, *model.ChannelInfo]
+	publishers           *publisher.Registry
+	token                string
 }
 
-func NewTelegramBot(config *config.Config, storage *storage.Storage, discordBot *discord.BotDiscord, flushInterval, updateGroupFlushTime time.Duration, DBHandlers *handlers.DBHandlers) *BotTelegram {
+func NewTelegramBot(config *config.Config, storage *storage.Storage, publishers *publisher.Registry, pauseController *pause.Controller, comments CommentPublisher, discord DiscordActions, flushInterval, updateGroupFlushTime, channelCacheTTL time.Duration, DBHandlers *handlers.DBHandlers) *BotTelegram {
 	bot, err := tgbotapi.NewBotAPI(config.TelegramToken)
 	if err != nil {
 		logging.Log("Telegram", logrus.PanicLevel, fmt.Sprintf("%v", err))
 	}
 	logging.Log("Telegram", logrus.InfoLevel, "Успешное подключение к боту Telegram")
 
+	channelCache := cache.New[int64, *model.ChannelInfo](channelCacheTTL)
+
 	bt := &BotTelegram{
 		Bot:          bot,
 		updateGroups: make(map[string]*UpdateGroup),
 		updateHandler: func(update tgbotapi.Update) {
-			HandleTelegramUpdate(update, storage, discordBot, config.TelegramToken)
+			HandleTelegramUpdate(update, storage, publishers, config.TelegramToken, DBHandlers)
 		},
 		updateRepostHandler: func(updates []tgbotapi.Update) {
-			HandleTelegramRepostUpdate(updates, storage, discordBot, config.TelegramToken)
+			HandleTelegramRepostUpdate(updates, storage, publishers, config.TelegramToken, channelCache)
 		},
-		updateEditHandler: func(update tgbotapi.Update, DBHandlers *handlers.DBHandlers) {
-			HandleTelegramEditUpdate(update, storage, discordBot, DBHandlers)
+		updateEditHandler: func(update tgbotapi.Update) {
+			HandleTelegramEditUpdate(update, storage, publishers, DBHandlers, channelCache)
 		},
 		updateGroupHandler: func(updates []tgbotapi.Update) {
-			HandleTelegramUpdateGroup(updates, storage, discordBot, config.TelegramToken)
+			HandleTelegramUpdateGroup(updates, storage, publishers, config.TelegramToken, DBHandlers)
 		},
 		commandHandler: func(update tgbotapi.Update, DBHandlers *handlers.DBHandlers) {
-			HandleTelegramCommand(update, storage, discordBot, config.TelegramToken, DBHandlers)
+			HandleTelegramCommand(update, storage, publishers, pauseController, discord, config.TelegramToken, DBHandlers, channelCache, config.AdminIDs)
+		},
+		commentHandler: func(update tgbotapi.Update) {
+			HandleTelegramComment(update, storage, comments, config.TelegramToken, bot.Self.ID, DBHandlers, channelCache)
 		},
+		admin:                newAdminConsole(bot, storage, discord, pauseController, config.AdminIDs),
 		flushInterval:        flushInterval,
 		updateGroupFlushTime: updateGroupFlushTime,
 		DBHandlers:           DBHandlers,
+		channelCache:         channelCache,
+		publishers:           publishers,
+		token:                config.TelegramToken,
 	}
 
 	go bt.startFlushRoutine()
@@ -87,11 +104,15 @@ func (t *BotTelegram) flushQueue() {
 	for id, group := range t.updateGroups {
 		if now.Sub(group.Timestamp) >= t.updateGroupFlushTime {
 			logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Получено новое сообщение с канала %s", group.Updates[0].ChannelPost.Chat.Title))
-			if group.Updates[0].ChannelPost.ForwardFromChat != nil {
-				t.updateRepostHandler(group.Updates)
-			} else {
-				t.updateGroupHandler(group.Updates)
-			}
+			rememberAlbum(group.Updates)
+			updates := group.Updates
+			runAfterBridgeSends(updates[0].ChannelPost.Chat.ID, func() {
+				if isForwarded(updates[0].ChannelPost) {
+					t.updateRepostHandler(updates)
+				} else {
+					t.updateGroupHandler(updates)
+				}
+			})
 			delete(t.updateGroups, id)
 		}
 	}
@@ -117,7 +138,7 @@ func (t *BotTelegram) ListenUpdates() {
 	u.Timeout = 60
 	logging.Log("Telegram", logrus.InfoLevel, "Начинается прослушка сообщений...")
 
-	updates := t.Bot.GetUpdatesChan(u)
+	updates := t.getUpdatesChan(u)
 	for update := range updates {
 		switch {
 		case update.ChannelPost != nil:
@@ -126,25 +147,34 @@ func (t *BotTelegram) ListenUpdates() {
 			if strings.HasPrefix(channelPost.Text, "/") {
 				logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Получена команда %s с канала %s", channelPost.Text, channelPost.Chat.Title))
 				t.commandHandler(update, t.DBHandlers)
-			} else if channelPost.ForwardFrom == nil && channelPost.ForwardFromChat == nil {
+			} else if !isForwarded(channelPost) {
 				if channelPost.MediaGroupID != "" {
 					t.appendQueue(update)
 				} else {
 					logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Получено новое сообщение с канала %s", channelPost.Chat.Title))
-					t.updateHandler(update)
+					runAfterBridgeSends(channelPost.Chat.ID, func() { t.updateHandler(update) })
 				}
-			} else if channelPost.ForwardFromChat != nil {
+			} else {
 				if channelPost.MediaGroupID != "" {
 					t.appendQueue(update)
 				} else {
 					logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Получено новое сообщение с канала %s", channelPost.Chat.Title))
-					t.updateRepostHandler([]tgbotapi.Update{update})
+					runAfterBridgeSends(channelPost.Chat.ID, func() { t.updateRepostHandler([]tgbotapi.Update{update}) })
 				}
 			}
 
 		case update.EditedChannelPost != nil:
 			logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Отредактирован пост %d с канала %s", update.EditedChannelPost.MessageID, update.EditedChannelPost.Chat.Title))
-			t.updateEditHandler(update, t.DBHandlers)
+			runAfterBridgeSends(update.EditedChannelPost.Chat.ID, func() { t.updateEditHandler(update) })
+
+		case update.CallbackQuery != nil:
+			t.admin.handleCallback(update.CallbackQuery)
+
+		case update.Message != nil && update.Message.Chat.IsPrivate():
+			t.admin.handleMessage(update.Message)
+
+		case update.Message != nil && update.Message.Chat.IsSuperGroup():
+			t.commentHandler(update)
 		}
 	}
 }
@@ -192,71 +222,117 @@ func GetFileFromTelegram(fileID string, token string) []byte {
 	return dataPhoto
 }
 
-func GetFileURLFromTelegram(fileID string, token string) string {
-	filePathURL := fmt.Sprintf("https://api.telegram.org/bot%s/getFile?file_id=%s", token, fileID)
-	resp, err := http.Get(filePathURL)
+// GetRepostChannelInfo - получает название, имя пользователя и аватар канала, из которого сделан репост
+func GetRepostChannelInfo(chatID int64, token string) *model.ChannelInfo {
+	chatInfoURL := fmt.Sprintf("https://api.telegram.org/bot%s/getChat?chat_id=%d", token, chatID)
+
+	resp, err := http.Get(chatInfoURL)
 	if err != nil {
-		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения информации о файле: %v", err))
-		return ""
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка при запросе информации о канале: %v", err))
+		return nil
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Не удалось получить путь к файлу: статус %d", resp.StatusCode))
-		return ""
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Не удалось получить информацию о канале: статус %d", resp.StatusCode))
+		return nil
 	}
 
-	var fileData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&fileData); err != nil {
+	var chatData map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&chatData); err != nil {
 		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка декодирования ответа: %v", err))
-		return ""
+		return nil
 	}
 
-	filePath, ok := fileData["result"].(map[string]interface{})["file_path"].(string)
+	chatInfo, ok := chatData["result"].(map[string]interface{})
+	if !ok {
+		logging.Log("Telegram", logrus.ErrorLevel, "Не удалось получить информацию о канале из ответа")
+		return nil
+	}
+
+	channelInfo := &model.ChannelInfo{}
+	channelInfo.Title, _ = chatInfo["title"].(string)
+	channelInfo.UserName, _ = chatInfo["username"].(string)
+
+	photoInfo, ok := chatInfo["photo"].(map[string]interface{})
 	if !ok {
-		logging.Log("Telegram", logrus.ErrorLevel, "Не удалось получить путь к файлу из ответа")
-		return ""
+		logging.Log("Telegram", logrus.InfoLevel, "Аватар канала не найден")
+		return channelInfo
 	}
 
-	fileURL := fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", token, filePath)
-	return fileURL
+	largestAvatarFileID, ok := photoInfo["big_file_id"].(string)
+	if !ok {
+		logging.Log("Telegram", logrus.InfoLevel, "Не удалось получить большой аватар канала")
+		return channelInfo
+	}
+
+	// Скачиваем сам файл, чтобы не передавать в Discord ссылку с токеном бота
+	channelInfo.Avatar = GetFileFromTelegram(largestAvatarFileID, token)
+
+	return channelInfo
 }
 
-func GetRepostChannelAvatar(chatID int64, token string) string {
-	chatInfoURL := fmt.Sprintf("https://api.telegram.org/bot%s/getChat?chat_id=%d", token, chatID)
+// getCachedChannelInfo - возвращает информацию о канале из кэша, запрашивая её у Telegram при отсутствии
+func getCachedChannelInfo(chatID int64, token string, channelCache *cache.Cache[int64, *model.ChannelInfo]) *model.ChannelInfo {
+	if channelInfo, exists := channelCache.Get(chatID); exists {
+		return channelInfo
+	}
 
-	resp, err := http.Get(chatInfoURL)
+	channelInfo := GetRepostChannelInfo(chatID, token)
+	if channelInfo != nil {
+		channelCache.Set(chatID, channelInfo)
+	}
+
+	return channelInfo
+}
+
+// GetUserAvatar - скачивает последний аватар пользователя, если он доступен боту
+func GetUserAvatar(userID int64, token string) []byte {
+	photosURL := fmt.Sprintf("https://api.telegram.org/bot%s/getUserProfilePhotos?user_id=%d&limit=1", token, userID)
+
+	resp, err := http.Get(photosURL)
 	if err != nil {
-		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка при запросе информации о канале: %v", err))
-		return ""
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка при запросе аватара пользователя: %v", err))
+		return nil
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Не удалось получить информацию о канале: статус %d", resp.StatusCode))
-		return ""
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Не удалось получить аватар пользователя: статус %d", resp.StatusCode))
+		return nil
 	}
 
-	var chatData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&chatData); err != nil {
+	var photosData struct {
+		Result tgbotapi.UserProfilePhotos `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&photosData); err != nil {
 		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка декодирования ответа: %v", err))
-		return ""
+		return nil
 	}
 
-	chatInfo, ok := chatData["result"].(map[string]interface{})
-	if !ok || chatInfo["photo"] == nil {
-		logging.Log("Telegram", logrus.InfoLevel, "Аватар канала не найден")
-		return ""
+	if len(photosData.Result.Photos) == 0 || len(photosData.Result.Photos[0]) == 0 {
+		logging.Log("Telegram", logrus.InfoLevel, "Аватар пользователя не найден")
+		return nil
 	}
 
-	photoInfo := chatInfo["photo"].(map[string]interface{})
-	largestAvatarFileID, ok := photoInfo["big_file_id"].(string)
-	if !ok {
-		logging.Log("Telegram", logrus.InfoLevel, "Не удалось получить большой аватар канала")
-		return ""
+	sizes := photosData.Result.Photos[0]
+	return GetFileFromTelegram(sizes[len(sizes)-1].FileID, token)
+}
+
+// getCachedUserInfo - возвращает имя и аватар пользователя из кэша, запрашивая аватар у Telegram при отсутствии
+func getCachedUserInfo(user *tgbotapi.User, token string, channelCache *cache.Cache[int64, *model.ChannelInfo]) *model.ChannelInfo {
+	if userInfo, exists := channelCache.Get(user.ID); exists {
+		return userInfo
+	}
+
+	userInfo := &model.ChannelInfo{
+		Title:    strings.TrimSpace(user.FirstName + " " + user.LastName),
+		UserName: user.UserName,
+		Avatar:   GetUserAvatar(user.ID, token),
 	}
+	channelCache.Set(user.ID, userInfo)
 
-	return GetFileURLFromTelegram(largestAvatarFileID, token)
+	return userInfo
 }
 
 func DeletePostFromChannel(chatID int64, msgID int, bot *tgbotapi.BotAPI) {
@@ -0,0 +1,116 @@
+package telegram
+
+import (
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/cache"
+	"sync"
+	"time"
+)
+
+const (
+	// bridgeSendTTL - сколько помнятся сообщения, отправленные мостом; за это время связь успевает попасть в базу
+	bridgeSendTTL = 10 * time.Minute
+	// bridgeSendWait - сколько отложенный обработчик поста ждет завершения отправки моста в тот же канал
+	bridgeSendWait = 2 * time.Minute
+)
+
+type sentMessageKey struct {
+	chatID int64
+	msgID  int
+}
+
+// pendingChat - незавершенные отправки моста в канал; done закрывается, когда они заканчиваются
+type pendingChat struct {
+	count int
+	done  chan struct{}
+}
+
+// bridgeSends - отправки обратного моста в каналы Telegram. channel_post о сообщении моста может прийти
+// раньше, чем SendPost вернет его ID и мост сохранит связь в базу, поэтому обработка постов канала
+// откладывается до окончания отправок в этот канал, а затем сверяется со списком отправленных сообщений.
+// deferred - последний отложенный обработчик канала; следующие обработчики канала ждут его, чтобы не менять порядок постов
+var bridgeSends = struct {
+	sync.Mutex
+	chats    map[int64]*pendingChat
+	deferred map[int64]chan struct{}
+	sent     *cache.Cache[sentMessageKey, struct{}]
+}{
+	chats:    make(map[int64]*pendingChat),
+	deferred: make(map[int64]chan struct{}),
+	sent:     cache.New[sentMessageKey, struct{}](bridgeSendTTL),
+}
+
+// beginBridgeSend - отмечает начало отправки моста в канал
+func beginBridgeSend(chatID int64) {
+	bridgeSends.Lock()
+	defer bridgeSends.Unlock()
+
+	chat, exists := bridgeSends.chats[chatID]
+	if !exists {
+		chat = &pendingChat{done: make(chan struct{})}
+		bridgeSends.chats[chatID] = chat
+	}
+	chat.count++
+}
+
+// finishBridgeSend - запоминает отправленные сообщения и снимает отметку об отправке
+func finishBridgeSend(chatID int64, parts []model.PostPart) {
+	bridgeSends.Lock()
+	defer bridgeSends.Unlock()
+
+	for _, part := range parts {
+		bridgeSends.sent.Set(sentMessageKey{chatID: chatID, msgID: part.MessageID}, struct{}{})
+	}
+
+	chat := bridgeSends.chats[chatID]
+	chat.count--
+	if chat.count == 0 {
+		close(chat.done)
+		delete(bridgeSends.chats, chatID)
+	}
+}
+
+// runAfterBridgeSends - обрабатывает пост канала сразу, если мост ничего туда не отправляет. Иначе обработка
+// уходит в отдельную горутину и ждет окончания отправок, не задерживая получение обновлений других каналов
+func runAfterBridgeSends(chatID int64, handle func()) {
+	bridgeSends.Lock()
+	chat, pending := bridgeSends.chats[chatID]
+	previous, queued := bridgeSends.deferred[chatID]
+	if !pending && !queued {
+		bridgeSends.Unlock()
+		handle()
+		return
+	}
+
+	done := make(chan struct{})
+	bridgeSends.deferred[chatID] = done
+	bridgeSends.Unlock()
+
+	go func() {
+		defer func() {
+			bridgeSends.Lock()
+			if bridgeSends.deferred[chatID] == done {
+				delete(bridgeSends.deferred, chatID)
+			}
+			bridgeSends.Unlock()
+			close(done)
+		}()
+
+		if queued {
+			<-previous
+		}
+		if pending {
+			select {
+			case <-chat.done:
+			case <-time.After(bridgeSendWait):
+			}
+		}
+		handle()
+	}()
+}
+
+// isBridgeSent - проверяет, что сообщение отправлено мостом. Вызывается из обработчиков, запущенных через runAfterBridgeSends
+func isBridgeSent(chatID int64, msgID int) bool {
+	_, sent := bridgeSends.sent.Get(sentMessageKey{chatID: chatID, msgID: msgID})
+	return sent
+}
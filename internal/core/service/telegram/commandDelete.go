@@ -0,0 +1,43 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/core/publisher"
+	"slm-bot-publisher/internal/lib/database/handlers"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:          "/delete",
+		Help:          "удалить пост из канала и все его копии на площадках",
+		RequiresReply: true,
+		Handler:       commandTelegramDelete,
+	})
+}
+
+func commandTelegramDelete(ctx *CommandContext) {
+	deletePost(ctx.Bot, ctx.Publishers, ctx.DBHandlers, ctx.Streamer, ctx.Update.ChannelPost.ReplyToMessage.MessageID)
+}
+
+// deletePost - удаляет копии поста на площадках и сам пост вместе с остальными сообщениями альбома
+func deletePost(bot *tgbotapi.BotAPI, publishers *publisher.Registry, DBHandlers *handlers.DBHandlers, streamer *model.Streamer, deleteMsgID int) {
+	chatID := streamer.TelegramChannelID
+
+	// Сообщения альбома нужно найти до удаления копий, пока записи о них есть в базе
+	telegramMsgIDs := []int{deleteMsgID}
+	messages, err := DBHandlers.MessageHandlers.GetMessagesByTelegramID(chatID, deleteMsgID)
+	if err == nil {
+		for _, msg := range messages {
+			if msg.TelegramMsgID != deleteMsgID {
+				telegramMsgIDs = append(telegramMsgIDs, msg.TelegramMsgID)
+			}
+		}
+	}
+
+	publishers.Delete(streamer, &model.Post{ChatID: chatID, MessageID: deleteMsgID})
+
+	for _, msgID := range telegramMsgIDs {
+		DeletePostFromChannel(chatID, msgID, bot)
+	}
+}
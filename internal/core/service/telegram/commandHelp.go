@@ -0,0 +1,35 @@
+package telegram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:    "/help",
+		Help:    "список команд канала",
+		Handler: commandTelegramHelp,
+	})
+}
+
+func commandTelegramHelp(ctx *CommandContext) {
+	names := make([]string, 0, len(commandsTelegram))
+	for name := range commandsTelegram {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var help strings.Builder
+	help.WriteString("Команды канала:")
+	for _, name := range names {
+		command := commandsTelegram[name]
+		help.WriteString(fmt.Sprintf("\n%s - %s", name, command.Help))
+		if command.RequiresReply {
+			help.WriteString(" (ответом на пост)")
+		}
+	}
+
+	ctx.reply(help.String())
+}
@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/logging"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	registerCommand(Command{
+		Name: "/pause",
+		Help: "приостановить зеркалирование; с номером или ID канала Discord - только для этого канала",
+		Handler: func(ctx *CommandContext) {
+			setPaused(ctx, true)
+		},
+	})
+	registerCommand(Command{
+		Name: "/resume",
+		Help: "возобновить зеркалирование; с номером или ID канала Discord - только для этого канала",
+		Handler: func(ctx *CommandContext) {
+			setPaused(ctx, false)
+		},
+	})
+}
+
+func setPaused(ctx *CommandContext, paused bool) {
+	channelID, err := commandChannelID(ctx.Streamer, ctx.Update.ChannelPost.Text)
+	if err != nil {
+		ctx.reply(err.Error())
+		return
+	}
+
+	if err = ctx.Pause.SetPaused(ctx.Streamer.Name, channelID, paused); err != nil {
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка изменения паузы %s: %v", ctx.Streamer.Name, err))
+		ctx.reply("не удалось изменить паузу: " + err.Error())
+		return
+	}
+
+	target := "Зеркалирование"
+	if channelID != "" {
+		target = "Зеркалирование в канал " + channelID
+	}
+
+	var status string
+	switch {
+	case !paused:
+		status = target + " возобновлено"
+	case ctx.Streamer.PauseMode == model.PauseQueue:
+		status = target + " на паузе, новые посты будут отправлены после /resume"
+	default:
+		status = target + " на паузе, новые посты не будут отправлены"
+	}
+	ctx.reply(status)
+}
+
+// commandChannelID - возвращает канал Discord из аргумента команды: номер по порядку или ID.
+// Без аргумента команда относится ко всему стримеру
+func commandChannelID(streamer *model.Streamer, text string) (string, error) {
+	args := strings.Fields(text)
+	if len(args) < 2 {
+		return "", nil
+	}
+
+	for _, channel := range streamer.DiscordChannels {
+		if channel.ChannelID == args[1] {
+			return channel.ChannelID, nil
+		}
+	}
+	if number, err := strconv.Atoi(args[1]); err == nil && number >= 1 && number <= len(streamer.DiscordChannels) {
+		return streamer.DiscordChannels[number-1].ChannelID, nil
+	}
+
+	return "", fmt.Errorf("канал %s не найден", args[1])
+}
@@ -0,0 +1,27 @@
+package telegram
+
+import "slm-bot-publisher/internal/core/model"
+
+func init() {
+	registerCommand(Command{
+		Name:          "/pin",
+		Help:          "закрепить копии поста в Discord",
+		RequiresReply: true,
+		Handler: func(ctx *CommandContext) {
+			pinPost(ctx, true)
+		},
+	})
+	registerCommand(Command{
+		Name:          "/unpin",
+		Help:          "открепить копии поста в Discord",
+		RequiresReply: true,
+		Handler: func(ctx *CommandContext) {
+			pinPost(ctx, false)
+		},
+	})
+}
+
+func pinPost(ctx *CommandContext, pinned bool) {
+	reply := ctx.Update.ChannelPost.ReplyToMessage
+	ctx.Publishers.Pin(ctx.Streamer, &model.Post{ChatID: reply.Chat.ID, MessageID: reply.MessageID}, pinned)
+}
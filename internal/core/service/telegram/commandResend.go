@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/cache"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	"slm-bot-publisher/logging"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:          "/resend",
+		Help:          "дослать пост на площадки, куда он не дошел",
+		RequiresReply: true,
+		Handler: func(ctx *CommandContext) {
+			resendPost(ctx, false)
+		},
+	})
+	registerCommand(Command{
+		Name:          "/silent",
+		Help:          "то же, что /resend, а в каналах Discord с упоминанием роли заменить копию на копию без упоминания",
+		RequiresReply: true,
+		Handler: func(ctx *CommandContext) {
+			resendPost(ctx, true)
+		},
+	})
+}
+
+// resendPost - досылает пост вместе со всем альбомом туда, где его копии нет.
+// Остальные сообщения альбома получаются пересылкой в личный чат первого администратора
+func resendPost(ctx *CommandContext, silent bool) {
+	var viaChatID int64
+	if len(ctx.AdminIDs) > 0 {
+		viaChatID = ctx.AdminIDs[0]
+	}
+
+	reply := ctx.Update.ChannelPost.ReplyToMessage
+	post := buildResendPost(ctx.Bot, ctx.DBHandlers, ctx.Token, ctx.ChannelCache, reply.Chat, reply, viaChatID)
+	post.Silent = silent
+	ctx.Publishers.Resend(ctx.Streamer, post)
+
+	logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Пост %d от %s отправлен повторно (без упоминания: %t)", post.MessageID, ctx.Streamer.Name, silent))
+}
+
+// buildResendPost - собирает пост для повторной отправки из сообщения и остальных сообщений его альбома.
+// Bot API не отдает сообщения канала по ID, поэтому они пересылаются в служебный чат viaChatID
+func buildResendPost(bot *tgbotapi.BotAPI, DBHandlers *handlers.DBHandlers, token string, channelCache *cache.Cache[int64, *model.ChannelInfo], channel *tgbotapi.Chat, message *tgbotapi.Message, viaChatID int64) *model.Post {
+	group := []tgbotapi.Update{{ChannelPost: message}}
+
+	msgIDs := albumMessageIDs(DBHandlers, channel.ID, message.MessageID)
+	switch {
+	case len(msgIDs) < 2:
+	case viaChatID == 0:
+		logging.Log("Telegram", logrus.WarnLevel, fmt.Sprintf("Без служебного чата альбом поста %d не собрать, отправляется одно сообщение", message.MessageID))
+	default:
+		if album := fetchPost(bot, viaChatID, channel, msgIDs); len(album) > 0 {
+			group = album
+		}
+	}
+
+	post := buildPost(group, token)
+	if isForwarded(group[0].ChannelPost) {
+		post.Repost = buildRepostOrigin(group[0].ChannelPost, token, channelCache)
+	}
+	return post
+}
@@ -0,0 +1,23 @@
+package telegram
+
+import (
+	"fmt"
+	"slm-bot-publisher/internal/core/routing"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:          "/route",
+		Help:          "показать, в какие каналы Discord попадет пост и почему, ничего не отправляя",
+		RequiresReply: true,
+		Handler:       commandTelegramRoute,
+	})
+}
+
+// commandTelegramRoute - пишет объяснение маршрутизации поста в лог и отправляет его администраторам
+func commandTelegramRoute(ctx *CommandContext) {
+	reply := ctx.Update.ChannelPost.ReplyToMessage
+	post := buildDryRunPost(reply)
+
+	ctx.reply(fmt.Sprintf("маршрутизация поста %d:\n%s", reply.MessageID, routing.Explain(ctx.Streamer.DiscordChannels, post)))
+}
@@ -0,0 +1,82 @@
+package telegram
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/logging"
+	"strings"
+	"time"
+)
+
+// Форматы времени для /schedule; время без даты относится к ближайшему такому моменту
+var scheduleLayouts = []string{"02.01.2006 15:04", "02.01 15:04", "15:04"}
+
+func init() {
+	registerCommand(Command{
+		Name:          "/schedule",
+		Help:          "отправить пост в Discord позже: /schedule 18:30, /schedule 25.12 18:30 или /schedule 2h",
+		RequiresReply: true,
+		Handler:       commandTelegramSchedule,
+	})
+}
+
+func commandTelegramSchedule(ctx *CommandContext) {
+	args := strings.Fields(ctx.Update.ChannelPost.Text)[1:]
+	sendAt, err := parseScheduleTime(strings.Join(args, " "), time.Now())
+	if err != nil {
+		ctx.reply(err.Error())
+		return
+	}
+
+	post := buildReplyPost(ctx)
+	channels, err := ctx.Discord.SchedulePost(ctx.Streamer, post, sendAt)
+	if err != nil {
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка планирования поста %d: %v", post.MessageID, err))
+		ctx.reply(fmt.Sprintf("не удалось запланировать пост %d", post.MessageID))
+		return
+	}
+
+	ctx.reply(fmt.Sprintf("пост %d будет отправлен в Discord %s, каналов: %d", post.MessageID, sendAt.Format("02.01.2006 15:04"), channels))
+}
+
+// parseScheduleTime - разбирает время отправки: длительность от текущего момента или дату и время по часовому поясу бота
+func parseScheduleTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("укажите время: /schedule 18:30, /schedule 25.12 18:30 или /schedule 2h")
+	}
+
+	if delay, err := time.ParseDuration(value); err == nil {
+		if delay <= 0 {
+			return time.Time{}, fmt.Errorf("задержка должна быть положительной")
+		}
+		return now.Add(delay), nil
+	}
+
+	for _, layout := range scheduleLayouts {
+		parsed, err := time.ParseInLocation(layout, value, now.Location())
+		if err != nil {
+			continue
+		}
+
+		sendAt := parsed
+		switch layout {
+		case "15:04":
+			sendAt = time.Date(now.Year(), now.Month(), now.Day(), parsed.Hour(), parsed.Minute(), 0, 0, now.Location())
+			if !sendAt.After(now) {
+				sendAt = sendAt.AddDate(0, 0, 1)
+			}
+		case "02.01 15:04":
+			sendAt = time.Date(now.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), 0, 0, now.Location())
+			if !sendAt.After(now) {
+				sendAt = sendAt.AddDate(1, 0, 0)
+			}
+		}
+
+		if !sendAt.After(now) {
+			return time.Time{}, fmt.Errorf("время %s уже прошло", value)
+		}
+		return sendAt, nil
+	}
+
+	return time.Time{}, fmt.Errorf("не удалось разобрать время %q", value)
+}
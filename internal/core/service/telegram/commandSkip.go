@@ -0,0 +1,35 @@
+package telegram
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/logging"
+	"sync"
+)
+
+// skippedChannels - каналы, следующий пост которых не копируется на площадки
+var skippedChannels sync.Map
+
+func init() {
+	registerCommand(Command{
+		Name:    "/skip",
+		Help:    "не копировать на площадки следующий пост канала",
+		Handler: commandTelegramSkip,
+	})
+}
+
+func commandTelegramSkip(ctx *CommandContext) {
+	chatID := ctx.Update.ChannelPost.Chat.ID
+	skippedChannels.Store(chatID, true)
+
+	ctx.reply("следующий пост не будет скопирован на площадки")
+}
+
+// consumeSkip - проверяет, отмечен ли канал командой /skip, и снимает отметку
+func consumeSkip(chatID int64) bool {
+	_, skipped := skippedChannels.LoadAndDelete(chatID)
+	if skipped {
+		logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Пост канала %d пропущен по команде /skip", chatID))
+	}
+	return skipped
+}
@@ -0,0 +1,93 @@
+package telegram
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/core/routing"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/logging"
+	"strings"
+	"time"
+)
+
+func init() {
+	registerCommand(Command{
+		Name:          "/status",
+		Help:          "показать, на какие площадки доставлен пост",
+		RequiresReply: true,
+		Handler:       commandTelegramStatus,
+	})
+}
+
+// commandTelegramStatus - отправляет администраторам список копий поста по площадкам.
+// Для каналов Discord без копии указывается решение правил маршрутизации
+func commandTelegramStatus(ctx *CommandContext) {
+	reply := ctx.Update.ChannelPost.ReplyToMessage
+
+	deliveries, err := ctx.DBHandlers.MessageHandlers.GetDeliveries(reply.Chat.ID, reply.MessageID)
+	if err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения копий поста %d: %v", reply.MessageID, err))
+		return
+	}
+
+	byPlatform := make(map[string][]modeldb.Message)
+	for _, delivery := range deliveries {
+		byPlatform[delivery.Platform] = append(byPlatform[delivery.Platform], delivery)
+	}
+
+	var status strings.Builder
+	status.WriteString(fmt.Sprintf("Доставка поста %d:", reply.MessageID))
+
+	for _, destinationType := range ctx.Streamer.DestinationTypes() {
+		if destinationType == model.DestinationDiscord {
+			scheduled, err := ctx.DBHandlers.ScheduleHandlers.GetScheduledPostsByTelegramID(reply.Chat.ID, reply.MessageID)
+			if err != nil {
+				logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения отложенных отправок поста %d: %v", reply.MessageID, err))
+			}
+			writeDiscordStatus(&status, ctx.Streamer, reply.MessageID, byPlatform[model.DestinationDiscord], scheduled, buildDryRunPost(reply))
+			continue
+		}
+
+		if len(byPlatform[destinationType]) == 0 {
+			status.WriteString(fmt.Sprintf("\n%s: нет записей о доставке", destinationType))
+			continue
+		}
+		for _, delivery := range byPlatform[destinationType] {
+			status.WriteString(fmt.Sprintf("\n%s %s: %s", destinationType, delivery.ChannelID, delivery.DestinationMsgID))
+		}
+	}
+
+	ctx.reply(status.String())
+}
+
+func writeDiscordStatus(status *strings.Builder, streamer *model.Streamer, msgID int, deliveries []modeldb.Message, scheduled []modeldb.ScheduledPost, post *model.Post) {
+	for _, channel := range streamer.DiscordChannels {
+		delivered := ""
+		for _, delivery := range deliveries {
+			if delivery.ChannelID == channel.ChannelID && delivery.TelegramMsgID == msgID {
+				delivered = delivery.DestinationMsgID
+				break
+			}
+		}
+
+		var sendAt time.Time
+		for _, scheduledPost := range scheduled {
+			if scheduledPost.ChannelID == channel.ChannelID {
+				sendAt = scheduledPost.SendAt
+				break
+			}
+		}
+
+		switch decision := routing.Evaluate(channel.Rules, post); {
+		case delivered != "":
+			status.WriteString(fmt.Sprintf("\ndiscord %s: сообщение %s", channel.ChannelID, delivered))
+		case !sendAt.IsZero():
+			status.WriteString(fmt.Sprintf("\ndiscord %s: запланирован на %s", channel.ChannelID, sendAt.Local().Format("02.01.2006 15:04")))
+		case !decision.Send:
+			status.WriteString(fmt.Sprintf("\ndiscord %s: не отправлен, %s", channel.ChannelID, decision.Reason))
+		default:
+			status.WriteString(fmt.Sprintf("\ndiscord %s: не доставлен", channel.ChannelID))
+		}
+	}
+}
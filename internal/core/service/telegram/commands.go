@@ -0,0 +1,129 @@
+package telegram
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/core/pause"
+	"slm-bot-publisher/internal/core/publisher"
+	"slm-bot-publisher/internal/lib/cache"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+	"strings"
+)
+
+// CommandContext - данные, доступные обработчику команды канала
+type CommandContext struct {
+	Update       tgbotapi.Update
+	Streamer     *model.Streamer
+	Bot          *tgbotapi.BotAPI
+	Publishers   *publisher.Registry
+	Pause        *pause.Controller
+	Discord      DiscordActions
+	DBHandlers   *handlers.DBHandlers
+	Token        string
+	ChannelCache *cache.Cache[int64, *model.ChannelInfo]
+	// AdminIDs - кому отправляются ответы на команды
+	AdminIDs []int64
+}
+
+type CommandHandler func(ctx *CommandContext)
+
+// Command - команда канала. Команды регистрируются в init() своих файлов через registerCommand
+type Command struct {
+	Name string
+	// Help - описание для /help
+	Help string
+	// RequiresReply - команда работает только ответом на пост
+	RequiresReply bool
+	Handler       CommandHandler
+}
+
+var commandsTelegram = make(map[string]Command)
+
+func registerCommand(command Command) {
+	if _, exists := commandsTelegram[command.Name]; exists {
+		panic(fmt.Sprintf("команда %s зарегистрирована дважды", command.Name))
+	}
+	commandsTelegram[command.Name] = command
+}
+
+func HandleTelegramCommand(update tgbotapi.Update, storage *storage.Storage, publishers *publisher.Registry, pauseController *pause.Controller, discord DiscordActions, token string, DBHandlers *handlers.DBHandlers, channelCache *cache.Cache[int64, *model.ChannelInfo], adminIDs []int64) {
+	streamer := storage.GetStreamerByTelegramID(update.ChannelPost.Chat.ID)
+	currentMsgID := update.ChannelPost.MessageID
+
+	if streamer != nil {
+		name := strings.Split(update.ChannelPost.Text, " ")[0]
+		// В каналах команда может прийти с упоминанием бота: /delete@slm_bot
+		name, _, _ = strings.Cut(name, "@")
+
+		if command, exists := commandsTelegram[name]; exists {
+			bot, err := tgbotapi.NewBotAPI(token)
+			if err != nil {
+				logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка при подключении к боту: %v", err))
+				return
+			}
+			DeletePostFromChannel(update.ChannelPost.Chat.ID, currentMsgID, bot)
+
+			if command.RequiresReply && update.ChannelPost.ReplyToMessage == nil {
+				logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Команда %s должна быть ответом на пост", name))
+				return
+			}
+
+			command.Handler(&CommandContext{
+				Update:       update,
+				Streamer:     streamer,
+				Bot:          bot,
+				Publishers:   publishers,
+				Pause:        pauseController,
+				Discord:      discord,
+				DBHandlers:   DBHandlers,
+				Token:        token,
+				ChannelCache: channelCache,
+				AdminIDs:     adminIDs,
+			})
+		} else {
+			logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Неизвестная команда: %s", name))
+		}
+	}
+}
+
+// reply - пишет ответ на команду в лог и отправляет его администраторам из ADMIN_IDS в личные сообщения.
+// В канал ответы не публикуются: их увидят подписчики, а бот получит их как новые посты канала
+func (ctx *CommandContext) reply(text string) {
+	text = fmt.Sprintf("%s: %s", ctx.Streamer.Name, text)
+	logging.Log("Telegram", logrus.InfoLevel, text)
+
+	for _, adminID := range ctx.AdminIDs {
+		message := tgbotapi.NewMessage(adminID, text)
+		message.DisableNotification = true
+		if _, err := ctx.Bot.Send(message); err != nil {
+			logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка отправки ответа на команду администратору %d: %v", adminID, err))
+		}
+	}
+}
+
+// buildReplyPost - собирает пост из сообщения, на которое ответили командой, с учетом репоста
+func buildReplyPost(ctx *CommandContext) *model.Post {
+	reply := ctx.Update.ChannelPost.ReplyToMessage
+
+	post := buildPost([]tgbotapi.Update{{ChannelPost: reply}}, ctx.Token)
+	if isForwarded(reply) {
+		post.Repost = buildRepostOrigin(reply, ctx.Token, ctx.ChannelCache)
+	}
+	return post
+}
+
+// buildDryRunPost - собирает пост для проверки правил: достаточно типов вложений, сами файлы не скачиваются
+func buildDryRunPost(message *tgbotapi.Message) *model.Post {
+	post := buildPostText(message)
+	processMedia(message, func(kind, fileID, fileName string) {
+		post.Attachments = append(post.Attachments, model.Attachment{Kind: kind, Name: fileName, FileID: fileID})
+	})
+	if isForwarded(message) {
+		post.Repost = &model.RepostOrigin{}
+	}
+	return post
+}
@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/cache"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+	"strings"
+)
+
+// CommentPublisher - площадка, принимающая комментарии из группы обсуждения канала
+type CommentPublisher interface {
+	PublishComment(streamer *model.Streamer, comment *model.Comment)
+}
+
+// HandleTelegramComment - запоминает копии постов в группе обсуждения и переносит комментарии к ним
+func HandleTelegramComment(update tgbotapi.Update, storage *storage.Storage, comments CommentPublisher, token string, botID int64, DBHandlers *handlers.DBHandlers, channelCache *cache.Cache[int64, *model.ChannelInfo]) {
+	message := update.Message
+
+	// Ответы из Discord публикует сам бот, их переносить не нужно
+	if message.From != nil && message.From.ID == botID {
+		return
+	}
+
+	if message.IsAutomaticForward && message.ForwardFromChat != nil {
+		saveDiscussionPost(message, storage, DBHandlers)
+		return
+	}
+
+	reply := message.ReplyToMessage
+	if reply == nil {
+		return
+	}
+
+	var chatID int64
+	var postMsgID int
+	if reply.IsAutomaticForward && reply.ForwardFromChat != nil {
+		chatID, postMsgID = reply.ForwardFromChat.ID, reply.ForwardFromMessageID
+	} else if parent, err := DBHandlers.CommentHandlers.GetCommentByDiscussionMsg(message.Chat.ID, reply.MessageID); err == nil {
+		chatID, postMsgID = parent.TelegramChatID, parent.TelegramMsgID
+	} else {
+		return
+	}
+
+	streamer := storage.GetStreamerByTelegramID(chatID)
+	if !commentsEnabled(streamer, message.Chat.ID) {
+		return
+	}
+
+	author := commentAuthor(message, token, channelCache)
+	textPost := buildPostText(message)
+	comment := &model.Comment{
+		TelegramChatID:   chatID,
+		TelegramMsgID:    postMsgID,
+		DiscussionChatID: message.Chat.ID,
+		DiscussionMsgID:  message.MessageID,
+		AuthorName:       author.Title,
+		AuthorAvatar:     author.Avatar,
+		Text:             textPost.Text,
+		Entities:         textPost.Entities,
+		Attachments:      collectAttachments(message, token),
+	}
+
+	if !reply.IsAutomaticForward {
+		comment.ReplyToText = strings.TrimSpace(reply.Text + reply.Caption)
+		// У ответов из Discord имя автора уже стоит в начале текста.
+		// Имя передается как есть, площадка сама экранирует его в своей разметке
+		if reply.From == nil || reply.From.ID != botID {
+			comment.ReplyToAuthor = commentAuthorName(reply)
+		}
+	}
+
+	comments.PublishComment(streamer, comment)
+}
+
+func saveDiscussionPost(message *tgbotapi.Message, storage *storage.Storage, DBHandlers *handlers.DBHandlers) {
+	streamer := storage.GetStreamerByTelegramID(message.ForwardFromChat.ID)
+	if !commentsEnabled(streamer, message.Chat.ID) {
+		return
+	}
+
+	discussionPost := modeldb.DiscussionPost{
+		TelegramChatID:   message.ForwardFromChat.ID,
+		TelegramMsgID:    message.ForwardFromMessageID,
+		DiscussionChatID: message.Chat.ID,
+		DiscussionMsgID:  message.MessageID,
+	}
+	if err := DBHandlers.CommentHandlers.CreateDiscussionPost(&discussionPost); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения копии поста %d в группе обсуждения: %v", discussionPost.TelegramMsgID, err))
+	}
+}
+
+// commentsEnabled - проверяет, что у стримера включен перенос комментариев из этой группы обсуждения
+func commentsEnabled(streamer *model.Streamer, discussionChatID int64) bool {
+	return streamer != nil && streamer.CommentBridge != nil && streamer.CommentBridge.DiscussionChatID == discussionChatID
+}
+
+// commentAuthor - возвращает имя и аватар автора; комментарий может быть написан от имени канала или группы
+func commentAuthor(message *tgbotapi.Message, token string, channelCache *cache.Cache[int64, *model.ChannelInfo]) *model.ChannelInfo {
+	if message.SenderChat != nil {
+		if channelInfo := getCachedChannelInfo(message.SenderChat.ID, token, channelCache); channelInfo != nil {
+			return channelInfo
+		}
+		return &model.ChannelInfo{Title: message.SenderChat.Title}
+	}
+	if message.From != nil {
+		return getCachedUserInfo(message.From, token, channelCache)
+	}
+	return &model.ChannelInfo{}
+}
+
+func commentAuthorName(message *tgbotapi.Message) string {
+	if message.SenderChat != nil {
+		return message.SenderChat.Title
+	}
+	if message.From != nil {
+		return strings.TrimSpace(message.From.FirstName + " " + message.From.LastName)
+	}
+	return ""
+}
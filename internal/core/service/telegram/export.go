@@ -0,0 +1,193 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/logging"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportFile - result.json из экспорта истории канала в Telegram Desktop
+type exportFile struct {
+	Messages []exportMessage `json:"messages"`
+}
+
+type exportMessage struct {
+	ID            int            `json:"id"`
+	Type          string         `json:"type"`
+	Date          string         `json:"date"`
+	DateUnixtime  string         `json:"date_unixtime"`
+	ForwardedFrom string         `json:"forwarded_from"`
+	TextEntities  []exportEntity `json:"text_entities"`
+	Photo         string         `json:"photo"`
+	File          string         `json:"file"`
+	FileName      string         `json:"file_name"`
+	MediaType     string         `json:"media_type"`
+}
+
+type exportEntity struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Href     string `json:"href"`
+	Language string `json:"language"`
+}
+
+// Типы сущностей экспорта, отличающиеся от Bot API, и типы, которые переносятся без изменений
+var exportEntityTypes = map[string]string{
+	"link":          "url",
+	"phone":         "phone_number",
+	"bold":          "bold",
+	"italic":        "italic",
+	"underline":     "underline",
+	"strikethrough": "strikethrough",
+	"spoiler":       "spoiler",
+	"code":          "code",
+	"pre":           "pre",
+	"text_link":     "text_link",
+	"mention":       "mention",
+	"hashtag":       "hashtag",
+	"cashtag":       "cashtag",
+	"email":         "email",
+	"bot_command":   "bot_command",
+	"blockquote":    "blockquote",
+	"custom_emoji":  "custom_emoji",
+}
+
+// LoadExport - читает экспорт Telegram Desktop и превращает сообщения в обновления канала.
+// Вложения ссылаются на файлы рядом с result.json и читаются с диска при публикации
+func (b *Backfill) LoadExport(streamer *model.Streamer, path string) ([]tgbotapi.Update, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var export exportFile
+	if err = json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("ошибка чтения экспорта: %v", err)
+	}
+
+	channel, err := b.channel(streamer)
+	if err != nil {
+		return nil, err
+	}
+
+	exportDir := filepath.Dir(path)
+	var updates []tgbotapi.Update
+	for _, exported := range export.Messages {
+		if exported.Type != "message" {
+			continue
+		}
+
+		message := exported.toMessage(channel, exportDir)
+		if message.Text == "" && message.Caption == "" && !hasMedia(message) {
+			logging.Log("Telegram", logrus.DebugLevel, fmt.Sprintf("Сообщение %d экспорта пропущено: нет текста и поддерживаемых вложений", exported.ID))
+			continue
+		}
+		updates = append(updates, tgbotapi.Update{ChannelPost: message})
+	}
+
+	return updates, nil
+}
+
+func (e exportMessage) toMessage(channel *tgbotapi.Chat, exportDir string) *tgbotapi.Message {
+	message := &tgbotapi.Message{
+		MessageID:  e.ID,
+		Chat:       channel,
+		SenderChat: channel,
+		Date:       e.unixDate(),
+	}
+
+	if e.ForwardedFrom != "" {
+		message.ForwardSenderName = e.ForwardedFrom
+		message.ForwardDate = message.Date
+	}
+
+	if e.Photo != "" {
+		message.Photo = []tgbotapi.PhotoSize{{FileID: localFileID(exportDir, e.Photo)}}
+	}
+	if e.File != "" {
+		if strings.HasPrefix(e.File, "(File not included") {
+			logging.Log("Telegram", logrus.WarnLevel, fmt.Sprintf("Файл сообщения %d не входит в экспорт", e.ID))
+		} else {
+			e.attachFile(message, localFileID(exportDir, e.File))
+		}
+	}
+
+	text, entities := e.text()
+	if hasMedia(message) {
+		message.Caption, message.CaptionEntities = text, entities
+	} else {
+		message.Text, message.Entities = text, entities
+	}
+
+	return message
+}
+
+func (e exportMessage) attachFile(message *tgbotapi.Message, fileID string) {
+	fileName := e.FileName
+	if fileName == "" {
+		fileName = filepath.Base(e.File)
+	}
+
+	switch e.MediaType {
+	case "video_file":
+		message.Video = &tgbotapi.Video{FileID: fileID, FileName: fileName}
+	case "video_message":
+		message.VideoNote = &tgbotapi.VideoNote{FileID: fileID}
+	case "voice_message":
+		message.Voice = &tgbotapi.Voice{FileID: fileID}
+	case "audio_file":
+		message.Audio = &tgbotapi.Audio{FileID: fileID, FileName: fileName}
+	case "sticker":
+		message.Sticker = &tgbotapi.Sticker{FileID: fileID}
+	default:
+		// Анимации и прочие файлы публикуются документами
+		message.Document = &tgbotapi.Document{FileID: fileID, FileName: fileName}
+	}
+}
+
+// text - собирает текст из частей экспорта и считает смещения сущностей в единицах UTF-16, как в Bot API
+func (e exportMessage) text() (string, []tgbotapi.MessageEntity) {
+	var text strings.Builder
+	var entities []tgbotapi.MessageEntity
+
+	offset := 0
+	for _, part := range e.TextEntities {
+		length := utf16Length(part.Text)
+		if entityType, known := exportEntityTypes[part.Type]; known && length > 0 {
+			entities = append(entities, tgbotapi.MessageEntity{
+				Type:     entityType,
+				Offset:   offset,
+				Length:   length,
+				URL:      part.Href,
+				Language: part.Language,
+			})
+		}
+		text.WriteString(part.Text)
+		offset += length
+	}
+
+	return text.String(), entities
+}
+
+func (e exportMessage) unixDate() int {
+	if unix, err := strconv.Atoi(e.DateUnixtime); err == nil {
+		return unix
+	}
+	// В старых экспортах есть только локальное время без часового пояса
+	if date, err := time.ParseInLocation("2006-01-02T15:04:05", e.Date, time.Local); err == nil {
+		return int(date.Unix())
+	}
+	return 0
+}
+
+func localFileID(exportDir, file string) string {
+	return LocalFilePrefix + filepath.Join(exportDir, file)
+}
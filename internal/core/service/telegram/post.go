@@ -0,0 +1,271 @@
+package telegram
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"os"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/cache"
+	"slm-bot-publisher/logging"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// buildPost - собирает независимый от площадки пост из одного сообщения или альбома
+func buildPost(updates []tgbotapi.Update, token string) *model.Post {
+	first := updates[0].ChannelPost
+	post := buildPostText(first)
+
+	for _, update := range updates {
+		channelPost := update.ChannelPost
+
+		// Подпись альбома может оказаться не у первого сообщения
+		if post.Text == "" {
+			textPost := buildPostText(channelPost)
+			post.Text, post.Entities = textPost.Text, textPost.Entities
+		}
+
+		attachments := collectAttachments(channelPost, token)
+		part := model.PostPart{MessageID: channelPost.MessageID}
+		if len(attachments) > 0 {
+			part.AttachmentID = attachments[0].FileID
+		}
+
+		post.Attachments = append(post.Attachments, attachments...)
+		post.Parts = append(post.Parts, part)
+	}
+
+	return post
+}
+
+// buildPostText - собирает пост только из текста сообщения, без скачивания вложений
+func buildPostText(channelPost *tgbotapi.Message) *model.Post {
+	text, entities := channelPost.Text, channelPost.Entities
+	if text == "" {
+		text, entities = channelPost.Caption, channelPost.CaptionEntities
+	}
+
+	return &model.Post{
+		ChatID:       channelPost.Chat.ID,
+		ChatUserName: channelPost.Chat.UserName,
+		MessageID:    channelPost.MessageID,
+		Text:         text,
+		Entities:     convertEntities(text, entities),
+		Link:         buildRepostLink(channelPost.Chat.UserName, channelPost.MessageID),
+	}
+}
+
+// convertEntities - переводит смещения сущностей Telegram из единиц UTF-16 в руны
+func convertEntities(text string, entities []tgbotapi.MessageEntity) []model.TextEntity {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	// utf16ToRune[i] - номер руны, которой принадлежит i-я единица UTF-16
+	runes := []rune(text)
+	utf16ToRune := make([]int, 0, len(runes)+1)
+	for idx, r := range runes {
+		for range utf16.RuneLen(r) {
+			utf16ToRune = append(utf16ToRune, idx)
+		}
+	}
+	utf16ToRune = append(utf16ToRune, len(runes))
+
+	var converted []model.TextEntity
+	for _, entity := range entities {
+		start := entity.Offset
+		end := entity.Offset + entity.Length
+		if start < 0 || end >= len(utf16ToRune) || start > end {
+			continue
+		}
+
+		textEntity := model.TextEntity{
+			Type:     entity.Type,
+			Offset:   utf16ToRune[start],
+			Length:   utf16ToRune[end] - utf16ToRune[start],
+			URL:      entity.URL,
+			Language: entity.Language,
+		}
+		if entity.User != nil {
+			textEntity.UserName = entity.User.UserName
+			textEntity.FirstName = entity.User.FirstName
+		}
+		converted = append(converted, textEntity)
+	}
+
+	return converted
+}
+
+func collectAttachments(channelPost *tgbotapi.Message, token string) []model.Attachment {
+	var attachments []model.Attachment
+
+	addAttachment := func(kind, fileID, fileName string) {
+		data := downloadFile(fileID, token)
+		if len(data) > 0 {
+			attachments = append(attachments, model.Attachment{
+				Kind:      kind,
+				Name:      fileName,
+				FileID:    fileID,
+				MessageID: channelPost.MessageID,
+				Data:      data,
+			})
+		}
+	}
+
+	processMedia(channelPost, addAttachment)
+
+	return attachments
+}
+
+// downloadFile - скачивает файл из Telegram; файлы экспорта Telegram Desktop читаются с диска
+func downloadFile(fileID, token string) []byte {
+	path, local := strings.CutPrefix(fileID, LocalFilePrefix)
+	if !local {
+		return GetFileFromTelegram(fileID, token)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка чтения файла экспорта: %v", err))
+		return nil
+	}
+	return data
+}
+
+// buildRepostOrigin - определяет автора пересланного сообщения: канал, группу, пользователя или скрытого пользователя
+func buildRepostOrigin(channelPost *tgbotapi.Message, token string, channelCache *cache.Cache[int64, *model.ChannelInfo]) *model.RepostOrigin {
+	return newRepostOrigin(channelPost, token, channelCache, true)
+}
+
+// buildEditedRepostOrigin - определяет автора пересланного сообщения при правке.
+// Правка меняет только текст, поэтому аватар не скачивается, а сведения об авторе берутся из кэша, если они там есть
+func buildEditedRepostOrigin(channelPost *tgbotapi.Message, channelCache *cache.Cache[int64, *model.ChannelInfo]) *model.RepostOrigin {
+	return newRepostOrigin(channelPost, "", channelCache, false)
+}
+
+func newRepostOrigin(channelPost *tgbotapi.Message, token string, channelCache *cache.Cache[int64, *model.ChannelInfo], download bool) *model.RepostOrigin {
+	repostOrigin := &model.RepostOrigin{}
+
+	if channelPost.ForwardDate != 0 {
+		repostOrigin.Date = time.Unix(int64(channelPost.ForwardDate), 0)
+	}
+
+	switch {
+	case channelPost.ForwardFromChat != nil:
+		chat := channelPost.ForwardFromChat
+		repostOrigin.Type = model.RepostOriginChat
+		if chat.IsChannel() {
+			repostOrigin.Type = model.RepostOriginChannel
+			repostOrigin.Link = buildRepostLink(chat.UserName, channelPost.ForwardFromMessageID)
+		}
+		repostOrigin.AuthorName = chat.Title
+		repostOrigin.AuthorLink = buildProfileLink(chat.UserName)
+
+		if channelInfo := lookupChannelInfo(chat.ID, token, channelCache, download); channelInfo != nil {
+			repostOrigin.AuthorAvatar = channelInfo.Avatar
+			if channelInfo.Title != "" {
+				repostOrigin.AuthorName = channelInfo.Title
+			}
+		}
+
+		if channelPost.ForwardSignature != "" {
+			repostOrigin.AuthorName = fmt.Sprintf("%s (%s)", repostOrigin.AuthorName, channelPost.ForwardSignature)
+		}
+	case channelPost.ForwardFrom != nil:
+		user := channelPost.ForwardFrom
+		repostOrigin.Type = model.RepostOriginUser
+		repostOrigin.AuthorName = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		repostOrigin.AuthorLink = buildProfileLink(user.UserName)
+
+		if userInfo := lookupUserInfo(user, token, channelCache, download); userInfo != nil {
+			repostOrigin.AuthorName = userInfo.Title
+			repostOrigin.AuthorAvatar = userInfo.Avatar
+			repostOrigin.AuthorLink = buildProfileLink(userInfo.UserName)
+		}
+	default:
+		// Пользователь скрыл свой профиль в пересылках, известна только подпись
+		repostOrigin.Type = model.RepostOriginHiddenUser
+		repostOrigin.AuthorName = channelPost.ForwardSenderName
+	}
+
+	if repostOrigin.AuthorName == "" {
+		repostOrigin.AuthorName = "неизвестного автора"
+	}
+
+	return repostOrigin
+}
+
+func lookupChannelInfo(chatID int64, token string, channelCache *cache.Cache[int64, *model.ChannelInfo], download bool) *model.ChannelInfo {
+	if !download {
+		channelInfo, _ := channelCache.Get(chatID)
+		return channelInfo
+	}
+	return getCachedChannelInfo(chatID, token, channelCache)
+}
+
+func lookupUserInfo(user *tgbotapi.User, token string, channelCache *cache.Cache[int64, *model.ChannelInfo], download bool) *model.ChannelInfo {
+	if !download {
+		userInfo, _ := channelCache.Get(user.ID)
+		return userInfo
+	}
+	return getCachedUserInfo(user, token, channelCache)
+}
+
+func buildRepostLink(username string, messageID int) string {
+	if username != "" && messageID != 0 {
+		return fmt.Sprintf("https://t.me/%s/%d", username, messageID)
+	}
+	return ""
+}
+
+func buildProfileLink(username string) string {
+	if username != "" {
+		return fmt.Sprintf("https://t.me/%s", username)
+	}
+	return ""
+}
+
+func processMedia(channelPost *tgbotapi.Message, addAttachment func(kind, fileID, fileName string)) {
+	if channelPost.Photo != nil && len(channelPost.Photo) > 0 {
+		largestPhoto := channelPost.Photo[len(channelPost.Photo)-1]
+		// Имя уникально в пределах альбома, чтобы на фото можно было сослаться из embed
+		addAttachment(model.AttachmentPhoto, largestPhoto.FileID, fmt.Sprintf("photo_%d.jpg", channelPost.MessageID))
+	}
+
+	// Обрабатываем видео
+	if channelPost.Video != nil {
+		addAttachment(model.AttachmentVideo, channelPost.Video.FileID, "video.mp4")
+	}
+
+	// Обрабатываем видеокружки (VideoNote)
+	if channelPost.VideoNote != nil {
+		addAttachment(model.AttachmentVideoNote, channelPost.VideoNote.FileID, "videonote.mp4")
+	}
+
+	// Обрабатываем аудио
+	if channelPost.Audio != nil {
+		addAttachment(model.AttachmentAudio, channelPost.Audio.FileID, "audio.mp3")
+	}
+
+	// Обрабатываем голосовые сообщения
+	if channelPost.Voice != nil {
+		addAttachment(model.AttachmentVoice, channelPost.Voice.FileID, "voice.ogg")
+	}
+
+	// Обрабатываем документы
+	if channelPost.Document != nil {
+		addAttachment(model.AttachmentDocument, channelPost.Document.FileID, channelPost.Document.FileName)
+	}
+
+	// Обрабатываем анимации (GIF) - временно не работает корректно
+	//if channelPost.Animation != nil {
+	//	addAttachment(model.AttachmentDocument, channelPost.Animation.FileID, "animation.gif")
+	//}
+
+	// Обрабатываем стикеры
+	if channelPost.Sticker != nil {
+		addAttachment(model.AttachmentSticker, channelPost.Sticker.FileID, "sticker.webp")
+	}
+}
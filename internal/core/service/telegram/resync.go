@@ -0,0 +1,174 @@
+package telegram
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/logging"
+	"sort"
+	"time"
+)
+
+// ResyncOptions - какие посты из истории заново публикуются в канал Discord
+type ResyncOptions struct {
+	ChannelID string
+	// Last - сколько последних постов переносить; 0 - без ограничения
+	Last int
+	// Since - переносить посты, опубликованные не раньше этого момента; нулевое значение - без ограничения
+	Since time.Time
+	// ViaChatID - служебный чат, через который пересылаются сообщения
+	ViaChatID int64
+	// Force - публиковать заново и те посты, у которых в канале уже есть копия
+	Force bool
+}
+
+// Resync - заново публикует в один канал Discord посты стримера, записанные в базе.
+// Остальные каналы не затрагиваются. Возвращает число опубликованных постов
+func (b *Backfill) Resync(streamer *model.Streamer, options ResyncOptions) (int, error) {
+	var target *model.DiscordChannel
+	for _, channel := range streamer.DiscordChannels {
+		if channel.ChannelID == options.ChannelID {
+			target = &channel
+			break
+		}
+	}
+	if target == nil {
+		return 0, fmt.Errorf("канал %s не настроен у стримера %s", options.ChannelID, streamer.Name)
+	}
+
+	discord, exists := b.Publishers.Get(model.DestinationDiscord)
+	if !exists {
+		return 0, fmt.Errorf("площадка Discord не зарегистрирована")
+	}
+
+	posts, err := b.recordedPosts(streamer)
+	if err != nil {
+		return 0, err
+	}
+	channel, err := b.channel(streamer)
+	if err != nil {
+		return 0, err
+	}
+
+	// Посты перебираются от новых к старым, пока не набрано Last или не достигнуто Since
+	var selected [][]tgbotapi.Update
+	for idx := len(posts) - 1; idx >= 0; idx-- {
+		if options.Last > 0 && len(posts)-1-idx >= options.Last {
+			break
+		}
+
+		mainID := posts[idx][0]
+		if !options.Force && b.hasCopy(options.ChannelID, mainID) {
+			continue
+		}
+
+		group := fetchPost(b.Bot, options.ViaChatID, channel, posts[idx])
+		if len(group) == 0 {
+			continue
+		}
+		if !options.Since.IsZero() && int64(group[0].ChannelPost.Date) < options.Since.Unix() {
+			break
+		}
+		selected = append(selected, group)
+	}
+
+	// Пост публикуется без задержки канала и только в выбранный канал
+	channelStreamer := *streamer
+	resyncChannel := *target
+	resyncChannel.Delay = ""
+	channelStreamer.DiscordChannels = []model.DiscordChannel{resyncChannel}
+
+	for idx := len(selected) - 1; idx >= 0; idx-- {
+		group := selected[idx]
+		first := group[0].ChannelPost
+
+		if options.Force {
+			if err = b.DBHandlers.MessageHandlers.DeleteMessageByID(options.ChannelID, first.MessageID); err == nil {
+				logging.Log("Database", logrus.InfoLevel, fmt.Sprintf("Старая копия поста %d в канале %s забыта", first.MessageID, options.ChannelID))
+			}
+		}
+
+		post := buildPost(group, b.Token)
+		if isForwarded(first) {
+			post.Repost = buildRepostOrigin(first, b.Token, b.channelCache)
+		}
+
+		logging.Log("Telegram", logrus.InfoLevel, fmt.Sprintf("Повторная публикация поста %d от %s в канал %s", post.MessageID, streamer.Name, options.ChannelID))
+		discord.Publish(&channelStreamer, post)
+		time.Sleep(b.Interval)
+	}
+
+	return len(selected), nil
+}
+
+// recordedPosts - возвращает ID сообщений Telegram каждого поста с копиями, от старых к новым.
+// Первым идет ID основного сообщения, за ним остальные сообщения альбома
+func (b *Backfill) recordedPosts(streamer *model.Streamer) ([][]int, error) {
+	var channelIDs []string
+	for _, channel := range streamer.DiscordChannels {
+		channelIDs = append(channelIDs, channel.ChannelID)
+	}
+
+	messages, err := b.DBHandlers.MessageHandlers.GetOutgoingMessages(streamer.TelegramChannelID, channelIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Сообщения альбома объединены одной копией на площадке
+	type copyKey struct{ channelID, destinationMsgID string }
+	mainIDs := make(map[copyKey]int)
+	for _, message := range messages {
+		key := copyKey{message.ChannelID, message.DestinationMsgID}
+		if mainID, exists := mainIDs[key]; !exists || message.TelegramMsgID < mainID {
+			mainIDs[key] = message.TelegramMsgID
+		}
+	}
+
+	parts := make(map[int]map[int]bool)
+	for _, message := range messages {
+		mainID := mainIDs[copyKey{message.ChannelID, message.DestinationMsgID}]
+		if parts[mainID] == nil {
+			parts[mainID] = make(map[int]bool)
+		}
+		parts[mainID][message.TelegramMsgID] = true
+	}
+
+	var posts [][]int
+	for mainID, partIDs := range parts {
+		post := []int{mainID}
+		for partID := range partIDs {
+			if partID != mainID {
+				post = append(post, partID)
+			}
+		}
+		sort.Ints(post[1:])
+		posts = append(posts, post)
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i][0] < posts[j][0] })
+
+	return posts, nil
+}
+
+func (b *Backfill) hasCopy(channelID string, msgID int) bool {
+	messages, err := b.DBHandlers.MessageHandlers.GetMessageByID(channelID, msgID)
+	return err == nil && len(messages) > 0
+}
+
+// fetchPost - получает сообщения поста через пересылку; сообщения альбома получают общий ID группы
+func fetchPost(bot *tgbotapi.BotAPI, viaChatID int64, channel *tgbotapi.Chat, msgIDs []int) []tgbotapi.Update {
+	var group []tgbotapi.Update
+	for _, msgID := range msgIDs {
+		message, err := forwardMessage(bot, viaChatID, channel, msgID)
+		time.Sleep(FetchInterval)
+		if err != nil {
+			logging.Log("Telegram", logrus.WarnLevel, fmt.Sprintf("Сообщение %d не получено: %v", msgID, err))
+			continue
+		}
+		if len(msgIDs) > 1 {
+			message.MediaGroupID = fmt.Sprintf("resync-%d", msgIDs[0])
+		}
+		group = append(group, tgbotapi.Update{ChannelPost: message})
+	}
+	return group
+}
@@ -0,0 +1,226 @@
+package telegram
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"slm-bot-publisher/internal/core/model"
+	"unicode/utf16"
+)
+
+const (
+	// Лимиты Telegram в единицах UTF-16
+	CaptionLimit = 1024
+	// В одном альбоме может быть не больше 10 вложений
+	MediaGroupLimit = 10
+)
+
+// SendPost - публикует пост площадки в канал Telegram и возвращает ID созданных сообщений.
+// Первое сообщение содержит текст поста, поэтому именно его нужно изменять при правках
+func (t *BotTelegram) SendPost(chatID int64, post *model.Post) ([]model.PostPart, error) {
+	beginBridgeSend(chatID)
+	parts, err := t.sendPost(chatID, post)
+	finishBridgeSend(chatID, parts)
+	return parts, err
+}
+
+func (t *BotTelegram) sendPost(chatID int64, post *model.Post) ([]model.PostPart, error) {
+	entities := toTelegramEntities(post.Text, post.Entities)
+	captionFits := utf16Length(post.Text) <= CaptionLimit
+
+	// Одно вложение отправляется вместе с текстом, иначе текст уходит отдельным сообщением перед вложениями
+	if len(post.Attachments) == 1 && captionFits {
+		sent, err := t.Bot.Send(buildMediaConfig(chatID, post.Attachments[0], post.Text, entities))
+		if err != nil {
+			return nil, err
+		}
+		return []model.PostPart{sentPart(sent)}, nil
+	}
+
+	var parts []model.PostPart
+	if post.Text != "" {
+		message := tgbotapi.NewMessage(chatID, post.Text)
+		message.Entities = entities
+		sent, err := t.Bot.Send(message)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, sentPart(sent))
+	}
+
+	// Telegram не смешивает в альбоме фото и видео с документами, поэтому они отправляются разными группами
+	var visual, documents []model.Attachment
+	for _, attachment := range post.Attachments {
+		if attachment.Kind == model.AttachmentPhoto || attachment.Kind == model.AttachmentVideo {
+			visual = append(visual, attachment)
+		} else {
+			documents = append(documents, attachment)
+		}
+	}
+
+	for _, group := range [][]model.Attachment{visual, documents} {
+		for start := 0; start < len(group); start += MediaGroupLimit {
+			sentParts, err := t.sendMediaGroup(chatID, group[start:min(start+MediaGroupLimit, len(group))])
+			if err != nil {
+				if len(parts) == 0 {
+					return nil, err
+				}
+				return parts, fmt.Errorf("пост отправлен частично: %v", err)
+			}
+			parts = append(parts, sentParts...)
+		}
+	}
+
+	return parts, nil
+}
+
+func (t *BotTelegram) sendMediaGroup(chatID int64, attachments []model.Attachment) ([]model.PostPart, error) {
+	if len(attachments) == 1 {
+		sent, err := t.Bot.Send(buildMediaConfig(chatID, attachments[0], "", nil))
+		if err != nil {
+			return nil, err
+		}
+		return []model.PostPart{sentPart(sent)}, nil
+	}
+
+	var media []interface{}
+	for _, attachment := range attachments {
+		file := tgbotapi.FileBytes{Name: attachment.Name, Bytes: attachment.Data}
+		switch attachment.Kind {
+		case model.AttachmentPhoto:
+			media = append(media, tgbotapi.NewInputMediaPhoto(file))
+		case model.AttachmentVideo:
+			media = append(media, tgbotapi.NewInputMediaVideo(file))
+		default:
+			media = append(media, tgbotapi.NewInputMediaDocument(file))
+		}
+	}
+
+	sentMessages, err := t.Bot.SendMediaGroup(tgbotapi.NewMediaGroup(chatID, media))
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []model.PostPart
+	for _, sent := range sentMessages {
+		parts = append(parts, sentPart(sent))
+	}
+	return parts, nil
+}
+
+// EditPost - заменяет текст или подпись сообщения, созданного SendPost
+func (t *BotTelegram) EditPost(chatID int64, part model.PostPart, post *model.Post) error {
+	entities := toTelegramEntities(post.Text, post.Entities)
+
+	if part.AttachmentID != "" {
+		edit := tgbotapi.NewEditMessageCaption(chatID, part.MessageID, post.Text)
+		edit.CaptionEntities = entities
+		_, err := t.Bot.Request(edit)
+		return err
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, part.MessageID, post.Text)
+	edit.Entities = entities
+	_, err := t.Bot.Request(edit)
+	return err
+}
+
+// SendComment - отправляет комментарий ответом на сообщение в группе обсуждения и возвращает его ID
+func (t *BotTelegram) SendComment(chatID int64, replyToMsgID int, post *model.Post) (int, error) {
+	message := tgbotapi.NewMessage(chatID, post.Text)
+	message.Entities = toTelegramEntities(post.Text, post.Entities)
+	message.ReplyToMessageID = replyToMsgID
+	message.AllowSendingWithoutReply = true
+
+	sent, err := t.Bot.Send(message)
+	if err != nil {
+		return 0, err
+	}
+	return sent.MessageID, nil
+}
+
+// DeleteMessage - удаляет сообщение из канала Telegram
+func (t *BotTelegram) DeleteMessage(chatID int64, msgID int) {
+	DeletePostFromChannel(chatID, msgID, t.Bot)
+}
+
+func buildMediaConfig(chatID int64, attachment model.Attachment, caption string, entities []tgbotapi.MessageEntity) tgbotapi.Chattable {
+	file := tgbotapi.FileBytes{Name: attachment.Name, Bytes: attachment.Data}
+
+	switch attachment.Kind {
+	case model.AttachmentPhoto:
+		photo := tgbotapi.NewPhoto(chatID, file)
+		photo.Caption, photo.CaptionEntities = caption, entities
+		return photo
+	case model.AttachmentVideo:
+		video := tgbotapi.NewVideo(chatID, file)
+		video.Caption, video.CaptionEntities = caption, entities
+		return video
+	case model.AttachmentAudio:
+		audio := tgbotapi.NewAudio(chatID, file)
+		audio.Caption, audio.CaptionEntities = caption, entities
+		return audio
+	default:
+		document := tgbotapi.NewDocument(chatID, file)
+		document.Caption, document.CaptionEntities = caption, entities
+		return document
+	}
+}
+
+// sentPart - возвращает ID отправленного сообщения и ID файла его вложения
+func sentPart(sent tgbotapi.Message) model.PostPart {
+	part := model.PostPart{MessageID: sent.MessageID}
+
+	switch {
+	case len(sent.Photo) > 0:
+		part.AttachmentID = sent.Photo[len(sent.Photo)-1].FileID
+	case sent.Video != nil:
+		part.AttachmentID = sent.Video.FileID
+	case sent.Audio != nil:
+		part.AttachmentID = sent.Audio.FileID
+	case sent.Document != nil:
+		part.AttachmentID = sent.Document.FileID
+	}
+
+	return part
+}
+
+// toTelegramEntities - переводит смещения сущностей из рун обратно в единицы UTF-16
+func toTelegramEntities(text string, entities []model.TextEntity) []tgbotapi.MessageEntity {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	// runeToUTF16[i] - смещение i-й руны в единицах UTF-16
+	runes := []rune(text)
+	runeToUTF16 := make([]int, len(runes)+1)
+	for idx, r := range runes {
+		runeToUTF16[idx+1] = runeToUTF16[idx] + utf16.RuneLen(r)
+	}
+
+	var converted []tgbotapi.MessageEntity
+	for _, entity := range entities {
+		start := entity.Offset
+		end := entity.Offset + entity.Length
+		if start < 0 || end > len(runes) || start >= end {
+			continue
+		}
+
+		converted = append(converted, tgbotapi.MessageEntity{
+			Type:     entity.Type,
+			Offset:   runeToUTF16[start],
+			Length:   runeToUTF16[end] - runeToUTF16[start],
+			URL:      entity.URL,
+			Language: entity.Language,
+		})
+	}
+
+	return converted
+}
+
+func utf16Length(text string) int {
+	length := 0
+	for _, r := range text {
+		length += utf16.RuneLen(r)
+	}
+	return length
+}
@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/logging"
+	"time"
+)
+
+// messageOrigin - поле forward_origin из новых версий Bot API, которого нет в tgbotapi
+type messageOrigin struct {
+	Type            string         `json:"type"`
+	Date            int            `json:"date"`
+	SenderUser      *tgbotapi.User `json:"sender_user"`
+	SenderUserName  string         `json:"sender_user_name"`
+	SenderChat      *tgbotapi.Chat `json:"sender_chat"`
+	Chat            *tgbotapi.Chat `json:"chat"`
+	MessageID       int            `json:"message_id"`
+	AuthorSignature string         `json:"author_signature"`
+}
+
+type rawMessage struct {
+	ForwardOrigin  *messageOrigin `json:"forward_origin"`
+	ReplyToMessage *rawMessage    `json:"reply_to_message"`
+}
+
+type rawUpdate struct {
+	Message           *rawMessage `json:"message"`
+	ChannelPost       *rawMessage `json:"channel_post"`
+	EditedChannelPost *rawMessage `json:"edited_channel_post"`
+}
+
+// getUpdatesChan - аналог GetUpdatesChan из tgbotapi, дополнительно разбирающий поле forward_origin
+func (t *BotTelegram) getUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	ch := make(chan tgbotapi.Update, t.Bot.Buffer)
+
+	go func() {
+		for {
+			updates, err := t.getUpdates(config)
+			if err != nil {
+				logging.Log("Telegram", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения обновлений, повтор через 3 секунды: %v", err))
+				time.Sleep(3 * time.Second)
+				continue
+			}
+
+			for _, update := range updates {
+				if update.UpdateID >= config.Offset {
+					config.Offset = update.UpdateID + 1
+					ch <- update
+				}
+			}
+		}
+	}()
+
+	return ch
+}
+
+func (t *BotTelegram) getUpdates(config tgbotapi.UpdateConfig) ([]tgbotapi.Update, error) {
+	resp, err := t.Bot.Request(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []tgbotapi.Update
+	if err = json.Unmarshal(resp.Result, &updates); err != nil {
+		return nil, err
+	}
+
+	var rawUpdates []rawUpdate
+	if err = json.Unmarshal(resp.Result, &rawUpdates); err != nil {
+		return nil, err
+	}
+
+	for idx := range updates {
+		if idx >= len(rawUpdates) {
+			break
+		}
+		if rawUpdates[idx].Message != nil {
+			applyForwardOrigin(updates[idx].Message, rawUpdates[idx].Message.ForwardOrigin)
+			// Комментарии отвечают на автоматическую копию поста, по которой определяется пост канала
+			if updates[idx].Message != nil && rawUpdates[idx].Message.ReplyToMessage != nil {
+				applyForwardOrigin(updates[idx].Message.ReplyToMessage, rawUpdates[idx].Message.ReplyToMessage.ForwardOrigin)
+			}
+		}
+		if rawUpdates[idx].ChannelPost != nil {
+			applyForwardOrigin(updates[idx].ChannelPost, rawUpdates[idx].ChannelPost.ForwardOrigin)
+		}
+		if rawUpdates[idx].EditedChannelPost != nil {
+			applyForwardOrigin(updates[idx].EditedChannelPost, rawUpdates[idx].EditedChannelPost.ForwardOrigin)
+		}
+	}
+
+	return updates, nil
+}
+
+// applyForwardOrigin - переносит данные forward_origin в устаревшие поля forward_*,
+// с которыми работают остальные обработчики
+func applyForwardOrigin(message *tgbotapi.Message, origin *messageOrigin) {
+	if message == nil || origin == nil || isForwarded(message) {
+		return
+	}
+
+	message.ForwardDate = origin.Date
+
+	switch origin.Type {
+	case "user":
+		message.ForwardFrom = origin.SenderUser
+	case "hidden_user":
+		message.ForwardSenderName = origin.SenderUserName
+	case "chat":
+		message.ForwardFromChat = origin.SenderChat
+		message.ForwardSignature = origin.AuthorSignature
+	case "channel":
+		message.ForwardFromChat = origin.Chat
+		message.ForwardFromMessageID = origin.MessageID
+		message.ForwardSignature = origin.AuthorSignature
+	}
+}
+
+// isForwarded - проверяет, является ли сообщение пересланным из любого источника
+func isForwarded(message *tgbotapi.Message) bool {
+	return message.ForwardFrom != nil || message.ForwardFromChat != nil || message.ForwardSenderName != "" || message.ForwardDate != 0
+}
@@ -0,0 +1,206 @@
+package twitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client - клиент Twitch Helix API с авторизацией по токену приложения
+type Client struct {
+	clientID     string
+	clientSecret string
+	apiURL       string
+	authURL      string
+	httpClient   *http.Client
+
+	tokenMutex     sync.Mutex
+	accessToken    string
+	tokenExpiresAt time.Time
+}
+
+type User struct {
+	ID              string `json:"id"`
+	Login           string `json:"login"`
+	DisplayName     string `json:"display_name"`
+	ProfileImageURL string `json:"profile_image_url"`
+}
+
+type Stream struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	UserLogin    string    `json:"user_login"`
+	UserName     string    `json:"user_name"`
+	GameName     string    `json:"game_name"`
+	Title        string    `json:"title"`
+	ViewerCount  int       `json:"viewer_count"`
+	StartedAt    time.Time `json:"started_at"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+}
+
+func NewClient(clientID, clientSecret, apiURL, authURL string) *Client {
+	return &Client{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		apiURL:       strings.TrimSuffix(apiURL, "/"),
+		authURL:      authURL,
+		httpClient:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// getAccessToken - возвращает действующий токен приложения, получая новый по client credentials при необходимости
+func (c *Client) getAccessToken(forceRefresh bool) (string, error) {
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+
+	if !forceRefresh && c.accessToken != "" && time.Now().Before(c.tokenExpiresAt) {
+		return c.accessToken, nil
+	}
+
+	form := url.Values{
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+		"grant_type":    {"client_credentials"},
+	}
+	resp, err := c.httpClient.PostForm(c.authURL, form)
+	if err != nil {
+		return "", fmt.Errorf("ошибка запроса токена Twitch: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("не удалось получить токен Twitch: статус %d", resp.StatusCode)
+	}
+
+	var tokenData struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&tokenData); err != nil {
+		return "", fmt.Errorf("ошибка декодирования токена Twitch: %v", err)
+	}
+
+	c.accessToken = tokenData.AccessToken
+	// Обновляем токен заранее, чтобы не отправлять запросы с истекающим токеном
+	c.tokenExpiresAt = time.Now().Add(time.Duration(tokenData.ExpiresIn)*time.Second - time.Minute)
+
+	return c.accessToken, nil
+}
+
+// doRequest - выполняет запрос к Helix API, повторяя его один раз с новым токеном при ответе 401
+func (c *Client) doRequest(method, path string, body interface{}, result interface{}) (int, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		token, err := c.getAccessToken(attempt > 0)
+		if err != nil {
+			return 0, err
+		}
+
+		req, err := http.NewRequest(method, c.apiURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Client-Id", c.clientID)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка запроса к Twitch: %v", err)
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return resp.StatusCode, err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			continue
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			return resp.StatusCode, fmt.Errorf("Twitch вернул статус %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+		}
+
+		if result != nil && len(data) > 0 {
+			if err = json.Unmarshal(data, result); err != nil {
+				return resp.StatusCode, fmt.Errorf("ошибка декодирования ответа Twitch: %v", err)
+			}
+		}
+		return resp.StatusCode, nil
+	}
+
+	return http.StatusUnauthorized, fmt.Errorf("Twitch отклонил токен приложения")
+}
+
+// GetUsers - получает пользователей Twitch по логинам
+func (c *Client) GetUsers(logins []string) ([]User, error) {
+	query := url.Values{}
+	for _, login := range logins {
+		query.Add("login", login)
+	}
+
+	var response struct {
+		Data []User `json:"data"`
+	}
+	if _, err := c.doRequest(http.MethodGet, "/users?"+query.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
+// GetStreams - получает активные трансляции по логинам
+func (c *Client) GetStreams(logins []string) ([]Stream, error) {
+	query := url.Values{}
+	for _, login := range logins {
+		query.Add("user_login", login)
+	}
+
+	var response struct {
+		Data []Stream `json:"data"`
+	}
+	if _, err := c.doRequest(http.MethodGet, "/streams?"+query.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
+// CreateEventSubSubscription - подписывает вебхук на событие трансляции; уже существующая подписка не считается ошибкой
+func (c *Client) CreateEventSubSubscription(eventType, broadcasterID, callbackURL, secret string) error {
+	body := map[string]interface{}{
+		"type":    eventType,
+		"version": "1",
+		"condition": map[string]string{
+			"broadcaster_user_id": broadcasterID,
+		},
+		"transport": map[string]string{
+			"method":   "webhook",
+			"callback": callbackURL,
+			"secret":   secret,
+		},
+	}
+
+	status, err := c.doRequest(http.MethodPost, "/eventsub/subscriptions", body, nil)
+	if status == http.StatusConflict {
+		return nil
+	}
+	return err
+}
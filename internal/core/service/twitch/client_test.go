@@ -0,0 +1,195 @@
+package twitch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTwitch - локальный сервер с токенами приложения и Helix API
+type fakeTwitch struct {
+	server *httptest.Server
+
+	mutex        sync.Mutex
+	tokenCounter int
+	validToken   string
+	live         map[string]Stream
+	users        map[string]User
+	subscribed   []string
+}
+
+func newFakeTwitch(t *testing.T) *fakeTwitch {
+	fake := &fakeTwitch{live: make(map[string]Stream), users: make(map[string]User)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		fake.mutex.Lock()
+		fake.tokenCounter++
+		fake.validToken = fmt.Sprintf("token-%d", fake.tokenCounter)
+		token := fake.validToken
+		fake.mutex.Unlock()
+
+		writeTestJSON(w, map[string]interface{}{"access_token": token, "expires_in": 3600})
+	})
+	mux.HandleFunc("GET /helix/streams", fake.authorized(func(w http.ResponseWriter, r *http.Request) {
+		var streams []Stream
+		for _, login := range r.URL.Query()["user_login"] {
+			if stream, live := fake.live[login]; live {
+				streams = append(streams, stream)
+			}
+		}
+		writeTestJSON(w, map[string]interface{}{"data": streams})
+	}))
+	mux.HandleFunc("GET /helix/users", fake.authorized(func(w http.ResponseWriter, r *http.Request) {
+		var users []User
+		for _, login := range r.URL.Query()["login"] {
+			if user, exists := fake.users[login]; exists {
+				users = append(users, user)
+			}
+		}
+		writeTestJSON(w, map[string]interface{}{"data": users})
+	}))
+	mux.HandleFunc("POST /helix/eventsub/subscriptions", fake.authorized(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Type      string            `json:"type"`
+			Condition map[string]string `json:"condition"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		fake.subscribed = append(fake.subscribed, body.Type+":"+body.Condition["broadcaster_user_id"])
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	fake.server = httptest.NewServer(mux)
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+// authorized - пропускает запрос только с действующим токеном и Client-Id, как Helix API
+func (f *fakeTwitch) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+
+		if r.Header.Get("Client-Id") != "client" || r.Header.Get("Authorization") != "Bearer "+f.validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// expireToken - отзывает текущий токен, как делает Twitch по истечении срока
+func (f *fakeTwitch) expireToken() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.validToken = "expired"
+}
+
+func (f *fakeTwitch) setLive(stream Stream) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.live[strings.ToLower(stream.UserLogin)] = stream
+}
+
+func (f *fakeTwitch) setOffline(login string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.live, login)
+}
+
+func (f *fakeTwitch) tokensIssued() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.tokenCounter
+}
+
+func (f *fakeTwitch) client() *Client {
+	return NewClient("client", "secret", f.server.URL+"/helix/", f.server.URL+"/token")
+}
+
+func writeTestJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func TestClientReusesToken(t *testing.T) {
+	fake := newFakeTwitch(t)
+	client := fake.client()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetStreams([]string{"test"}); err != nil {
+			t.Fatalf("GetStreams: %v", err)
+		}
+	}
+	if issued := fake.tokensIssued(); issued != 1 {
+		t.Fatalf("токенов выдано %d, ожидался 1", issued)
+	}
+}
+
+func TestClientRefreshesRejectedToken(t *testing.T) {
+	fake := newFakeTwitch(t)
+	client := fake.client()
+
+	if _, err := client.GetStreams([]string{"test"}); err != nil {
+		t.Fatalf("GetStreams: %v", err)
+	}
+	fake.expireToken()
+
+	if _, err := client.GetStreams([]string{"test"}); err != nil {
+		t.Fatalf("GetStreams после отзыва токена: %v", err)
+	}
+	if issued := fake.tokensIssued(); issued != 2 {
+		t.Fatalf("токенов выдано %d, ожидалось 2", issued)
+	}
+}
+
+func TestClientTokenError(t *testing.T) {
+	fake := newFakeTwitch(t)
+	client := NewClient("client", "wrong", fake.server.URL+"/helix", fake.server.URL+"/token")
+
+	if _, err := client.GetStreams([]string{"test"}); err == nil {
+		t.Fatal("ожидалась ошибка получения токена")
+	}
+}
+
+func TestGetStreams(t *testing.T) {
+	fake := newFakeTwitch(t)
+	fake.setLive(Stream{ID: "1", UserLogin: "first", Title: "Стрим", GameName: "Игра", ViewerCount: 10})
+	fake.setLive(Stream{ID: "2", UserLogin: "second"})
+
+	streams, err := fake.client().GetStreams([]string{"first", "offline"})
+	if err != nil {
+		t.Fatalf("GetStreams: %v", err)
+	}
+	if len(streams) != 1 {
+		t.Fatalf("получено трансляций %d, ожидалась 1", len(streams))
+	}
+	if stream := streams[0]; stream.ID != "1" || stream.Title != "Стрим" || stream.GameName != "Игра" || stream.ViewerCount != 10 {
+		t.Fatalf("неверная трансляция: %+v", stream)
+	}
+}
+
+func TestCreateEventSubSubscriptionConflict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			writeTestJSON(w, map[string]interface{}{"access_token": "token", "expires_in": 3600})
+			return
+		}
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+
+	client := NewClient("client", "secret", server.URL, server.URL+"/token")
+	if err := client.CreateEventSubSubscription(EventStreamOnline, "1", "https://example.com", "secret"); err != nil {
+		t.Fatalf("существующая подписка не должна быть ошибкой: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package twitch
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"slm-bot-publisher/logging"
+	"time"
+)
+
+const (
+	EventStreamOnline  = "stream.online"
+	EventStreamOffline = "stream.offline"
+
+	headerMessageID        = "Twitch-Eventsub-Message-Id"
+	headerMessageTimestamp = "Twitch-Eventsub-Message-Timestamp"
+	headerMessageSignature = "Twitch-Eventsub-Message-Signature"
+	headerMessageType      = "Twitch-Eventsub-Message-Type"
+
+	messageTypeVerification = "webhook_callback_verification"
+	messageTypeNotification = "notification"
+	messageTypeRevocation   = "revocation"
+
+	// Twitch рекомендует отклонять сообщения старше 10 минут, чтобы исключить повторную отправку
+	maxMessageAge = 10 * time.Minute
+)
+
+type eventSubMessage struct {
+	Challenge    string `json:"challenge"`
+	Subscription struct {
+		Type   string `json:"type"`
+		Status string `json:"status"`
+	} `json:"subscription"`
+	Event struct {
+		BroadcasterUserID    string `json:"broadcaster_user_id"`
+		BroadcasterUserLogin string `json:"broadcaster_user_login"`
+	} `json:"event"`
+}
+
+// ServeHTTP - принимает вебхуки EventSub от Twitch. Без секрета подпись подделывается, поэтому такие запросы отклоняются
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.secret == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	messageID := r.Header.Get(headerMessageID)
+	timestamp := r.Header.Get(headerMessageTimestamp)
+	if !verifySignature(s.secret, messageID, timestamp, body, r.Header.Get(headerMessageSignature)) {
+		logging.Log("Twitch", logrus.WarnLevel, "Получен вебхук EventSub с неверной подписью")
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if sentAt, err := time.Parse(time.RFC3339Nano, timestamp); err != nil || time.Since(sentAt) > maxMessageAge {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	var message eventSubMessage
+	if err = json.Unmarshal(body, &message); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	messageType := r.Header.Get(headerMessageType)
+	if messageType != messageTypeVerification && messageType != messageTypeNotification && messageType != messageTypeRevocation {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Twitch может доставить одно и то же сообщение несколько раз. ID запоминается только
+	// после разбора, чтобы испорченное при доставке сообщение можно было принять повторно
+	if _, duplicate := s.processedMessages.Get(messageID); duplicate {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	s.processedMessages.Set(messageID, struct{}{})
+
+	switch messageType {
+	case messageTypeVerification:
+		logging.Log("Twitch", logrus.InfoLevel, fmt.Sprintf("Подтверждена подписка EventSub %s", message.Subscription.Type))
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(message.Challenge))
+	case messageTypeNotification:
+		w.WriteHeader(http.StatusNoContent)
+		login := message.Event.BroadcasterUserLogin
+		switch message.Subscription.Type {
+		case EventStreamOnline:
+			go s.handleOnline(login)
+		case EventStreamOffline:
+			go s.handleOffline(login)
+		}
+	case messageTypeRevocation:
+		logging.Log("Twitch", logrus.WarnLevel, fmt.Sprintf("Twitch отозвал подписку %s (%s), остается опрос API", message.Subscription.Type, message.Subscription.Status))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// verifySignature - проверяет HMAC-SHA256 подпись сообщения EventSub
+func verifySignature(secret, messageID, timestamp string, body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(messageID))
+	mac.Write([]byte(timestamp))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
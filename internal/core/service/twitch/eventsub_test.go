@@ -0,0 +1,121 @@
+package twitch
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"slm-bot-publisher/internal/lib/cache"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testEventSubSecret = "eventsub-secret"
+
+func newEventSubService() *Service {
+	return &Service{secret: testEventSubSecret, processedMessages: cache.New[string, struct{}](maxMessageAge)}
+}
+
+// eventSubRequest - собирает запрос EventSub, подписанный так же, как это делает Twitch
+func eventSubRequest(secret, messageID, messageType string, sentAt time.Time, body string) *http.Request {
+	timestamp := sentAt.UTC().Format(time.RFC3339Nano)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(messageID + timestamp + body))
+
+	r := httptest.NewRequest(http.MethodPost, "/twitch/eventsub", strings.NewReader(body))
+	r.Header.Set(headerMessageID, messageID)
+	r.Header.Set(headerMessageTimestamp, timestamp)
+	r.Header.Set(headerMessageType, messageType)
+	r.Header.Set(headerMessageSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+const verificationBody = `{"challenge":"pogchamp","subscription":{"type":"stream.online","status":"webhook_callback_verification_pending"}}`
+
+func TestEventSubVerification(t *testing.T) {
+	service := newEventSubService()
+	w := httptest.NewRecorder()
+	service.ServeHTTP(w, eventSubRequest(testEventSubSecret, "1", messageTypeVerification, time.Now(), verificationBody))
+
+	if w.Code != http.StatusOK || w.Body.String() != "pogchamp" {
+		t.Fatalf("ожидался challenge, получено %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestEventSubRejectsBadSignature(t *testing.T) {
+	service := newEventSubService()
+	w := httptest.NewRecorder()
+	service.ServeHTTP(w, eventSubRequest("other-secret", "1", messageTypeVerification, time.Now(), verificationBody))
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("ожидался статус 403, получен %d", w.Code)
+	}
+}
+
+func TestEventSubRejectsTamperedBody(t *testing.T) {
+	service := newEventSubService()
+	r := eventSubRequest(testEventSubSecret, "1", messageTypeVerification, time.Now(), verificationBody)
+	r.Body = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Replace(verificationBody, "pogchamp", "other", 1))).Body
+
+	w := httptest.NewRecorder()
+	service.ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("ожидался статус 403, получен %d", w.Code)
+	}
+}
+
+func TestEventSubRejectsReplay(t *testing.T) {
+	service := newEventSubService()
+	w := httptest.NewRecorder()
+	service.ServeHTTP(w, eventSubRequest(testEventSubSecret, "1", messageTypeVerification, time.Now().Add(-maxMessageAge-time.Minute), verificationBody))
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("ожидался статус 403 для старого сообщения, получен %d", w.Code)
+	}
+}
+
+func TestEventSubSkipsDuplicate(t *testing.T) {
+	service := newEventSubService()
+
+	first := httptest.NewRecorder()
+	service.ServeHTTP(first, eventSubRequest(testEventSubSecret, "same", messageTypeVerification, time.Now(), verificationBody))
+	if first.Code != http.StatusOK {
+		t.Fatalf("ожидался статус 200, получен %d", first.Code)
+	}
+
+	second := httptest.NewRecorder()
+	service.ServeHTTP(second, eventSubRequest(testEventSubSecret, "same", messageTypeVerification, time.Now(), verificationBody))
+	if second.Code != http.StatusNoContent || second.Body.Len() != 0 {
+		t.Fatalf("повтор должен игнорироваться, получено %d %q", second.Code, second.Body.String())
+	}
+}
+
+func TestEventSubRejectsWithoutSecret(t *testing.T) {
+	service := newEventSubService()
+	service.secret = ""
+
+	// Подпись с пустым ключом может посчитать кто угодно
+	w := httptest.NewRecorder()
+	service.ServeHTTP(w, eventSubRequest("", "1", messageTypeVerification, time.Now(), verificationBody))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("ожидался статус 403 без секрета, получен %d", w.Code)
+	}
+}
+
+func TestEventSubMalformedBodyKeepsMessageID(t *testing.T) {
+	service := newEventSubService()
+
+	broken := httptest.NewRecorder()
+	service.ServeHTTP(broken, eventSubRequest(testEventSubSecret, "same", messageTypeVerification, time.Now(), `{"challenge":`))
+	if broken.Code != http.StatusBadRequest {
+		t.Fatalf("ожидался статус 400 для испорченного тела, получен %d", broken.Code)
+	}
+
+	retry := httptest.NewRecorder()
+	service.ServeHTTP(retry, eventSubRequest(testEventSubSecret, "same", messageTypeVerification, time.Now(), verificationBody))
+	if retry.Code != http.StatusOK || retry.Body.String() != "pogchamp" {
+		t.Fatalf("повторная доставка после ошибки должна обрабатываться, получено %d %q", retry.Code, retry.Body.String())
+	}
+}
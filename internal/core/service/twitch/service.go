@@ -0,0 +1,347 @@
+package twitch
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"slm-bot-publisher/config"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/cache"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"slm-bot-publisher/internal/lib/storage"
+	"slm-bot-publisher/logging"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Количество логинов в одном запросе к Helix API
+	loginsPerRequest = 100
+	// Сразу после stream.online трансляция может ещё не появиться в /streams
+	streamLookupAttempts = 3
+	streamLookupDelay    = 5 * time.Second
+)
+
+// Announcer - публикация анонсов трансляций в Discord
+type Announcer interface {
+	SendStreamAnnouncement(streamer *model.Streamer, channels []model.DiscordChannel, stream model.TwitchStream) map[string]string
+	EditStreamAnnouncement(streamer *model.Streamer, channelID, msgID string, stream model.TwitchStream)
+}
+
+// Service - следит за трансляциями стримеров на Twitch и публикует анонсы в Discord
+type Service struct {
+	client            *Client
+	storage           *storage.Storage
+	discordBot        Announcer
+	DBHandlers        *handlers.DBHandlers
+	secret            string
+	callbackURL       string
+	pollInterval      time.Duration
+	processedMessages *cache.Cache[string, struct{}]
+	streamMutex       sync.Mutex
+}
+
+func NewService(twitchConfig config.TwitchConfig, storage *storage.Storage, discordBot Announcer, DBHandlers *handlers.DBHandlers) *Service {
+	return &Service{
+		client:            NewClient(twitchConfig.ClientID, twitchConfig.ClientSecret, twitchConfig.APIURL, twitchConfig.AuthURL),
+		storage:           storage,
+		discordBot:        discordBot,
+		DBHandlers:        DBHandlers,
+		secret:            twitchConfig.EventSubSecret,
+		callbackURL:       twitchConfig.CallbackURL,
+		pollInterval:      twitchConfig.PollInterval,
+		processedMessages: cache.New[string, struct{}](maxMessageAge),
+	}
+}
+
+// Start - оформляет подписки EventSub и запускает опрос API, который подстраховывает вебхуки.
+// webhooksEnabled - принимает ли HTTP сервер вебхуки; без него подписки не оформляются
+func (s *Service) Start(webhooksEnabled bool) {
+	s.startWebhooks(webhooksEnabled)
+	go s.startPollRoutine()
+}
+
+// WebhooksConfigured - заданы ли адрес и секрет вебхуков EventSub; без них обработчик вебхуков не подключается
+func (s *Service) WebhooksConfigured() bool {
+	return s.callbackURL != "" && s.secret != ""
+}
+
+func (s *Service) startWebhooks(webhooksEnabled bool) {
+	switch {
+	case !webhooksEnabled:
+		logging.Log("Twitch", logrus.InfoLevel, "HTTP сервер выключен, вебхуки EventSub не принимаются, используется только опрос API")
+	case s.WebhooksConfigured():
+		if err := s.subscribe(); err != nil {
+			logging.Log("Twitch", logrus.ErrorLevel, fmt.Sprintf("Ошибка подписки на EventSub, используется только опрос API: %v", err))
+		}
+	default:
+		logging.Log("Twitch", logrus.InfoLevel, "Вебхуки EventSub не настроены, используется только опрос API")
+	}
+}
+
+// subscribe - подписывается на начало и окончание трансляций всех стримеров с настроенным Twitch
+func (s *Service) subscribe() error {
+	logins := s.twitchLogins()
+	for start := 0; start < len(logins); start += loginsPerRequest {
+		end := min(start+loginsPerRequest, len(logins))
+		users, err := s.client.GetUsers(logins[start:end])
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			for _, eventType := range []string{EventStreamOnline, EventStreamOffline} {
+				if err = s.client.CreateEventSubSubscription(eventType, user.ID, s.callbackURL, s.secret); err != nil {
+					return fmt.Errorf("подписка %s для %s: %v", eventType, user.Login, err)
+				}
+			}
+			logging.Log("Twitch", logrus.InfoLevel, fmt.Sprintf("Оформлена подписка EventSub для %s", user.Login))
+		}
+	}
+	return nil
+}
+
+func (s *Service) startPollRoutine() {
+	for {
+		s.poll()
+		time.Sleep(s.pollInterval)
+	}
+}
+
+// poll - сверяет состояние трансляций с опубликованными анонсами на случай пропущенных вебхуков
+func (s *Service) poll() {
+	logins := s.twitchLogins()
+	if len(logins) == 0 {
+		return
+	}
+
+	liveStreams := make(map[string]Stream)
+	for start := 0; start < len(logins); start += loginsPerRequest {
+		end := min(start+loginsPerRequest, len(logins))
+		streams, err := s.client.GetStreams(logins[start:end])
+		if err != nil {
+			logging.Log("Twitch", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения трансляций: %v", err))
+			return
+		}
+		for _, stream := range streams {
+			liveStreams[strings.ToLower(stream.UserLogin)] = stream
+		}
+	}
+
+	s.streamMutex.Lock()
+	defer s.streamMutex.Unlock()
+
+	for _, streamer := range s.storage.All() {
+		if streamer.Twitch == nil || streamer.Twitch.Login == "" {
+			continue
+		}
+
+		active, err := s.DBHandlers.AnnouncementHandlers.GetActiveAnnouncements(streamer.Name)
+		if err != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения анонсов трансляций %s: %v", streamer.Name, err))
+			continue
+		}
+
+		stream, live := liveStreams[strings.ToLower(streamer.Twitch.Login)]
+		switch {
+		case live && len(active) == 0:
+			s.announce(&streamer, stream)
+		case live:
+			s.refresh(&streamer, stream, active)
+		case len(active) > 0:
+			s.finish(&streamer, active)
+		}
+	}
+}
+
+// handleOnline - обрабатывает начало трансляции, полученное через EventSub
+func (s *Service) handleOnline(login string) {
+	streamer := s.findStreamer(login)
+	if streamer == nil {
+		return
+	}
+
+	var stream *Stream
+	for attempt := 0; attempt < streamLookupAttempts && stream == nil; attempt++ {
+		if attempt > 0 {
+			time.Sleep(streamLookupDelay)
+		}
+		streams, err := s.client.GetStreams([]string{login})
+		if err != nil {
+			logging.Log("Twitch", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения трансляции %s: %v", login, err))
+			continue
+		}
+		if len(streams) > 0 {
+			stream = &streams[0]
+		}
+	}
+	if stream == nil {
+		// Анонсируем без подробностей, опрос API дополнит их позже
+		stream = &Stream{UserLogin: login, StartedAt: time.Now()}
+	}
+
+	s.streamMutex.Lock()
+	defer s.streamMutex.Unlock()
+
+	active, err := s.DBHandlers.AnnouncementHandlers.GetActiveAnnouncements(streamer.Name)
+	if err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения анонсов трансляций %s: %v", streamer.Name, err))
+		return
+	}
+	if len(active) > 0 {
+		return
+	}
+
+	s.announce(streamer, *stream)
+}
+
+// handleOffline - обрабатывает окончание трансляции, полученное через EventSub. Окончание сверяется с API:
+// если трансляция еще идет или проверить ее не удалось, анонс закроет опрос
+func (s *Service) handleOffline(login string) {
+	streamer := s.findStreamer(login)
+	if streamer == nil {
+		return
+	}
+
+	streams, err := s.client.GetStreams([]string{login})
+	if err != nil {
+		logging.Log("Twitch", logrus.ErrorLevel, fmt.Sprintf("Ошибка проверки окончания трансляции %s: %v", login, err))
+		return
+	}
+	if len(streams) > 0 {
+		logging.Log("Twitch", logrus.WarnLevel, fmt.Sprintf("Получено окончание трансляции %s, но она еще идет", login))
+		return
+	}
+
+	s.streamMutex.Lock()
+	defer s.streamMutex.Unlock()
+
+	active, err := s.DBHandlers.AnnouncementHandlers.GetActiveAnnouncements(streamer.Name)
+	if err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения анонсов трансляций %s: %v", streamer.Name, err))
+		return
+	}
+	if len(active) > 0 {
+		s.finish(streamer, active)
+	}
+}
+
+// announce - публикует анонс трансляции и сохраняет отправленные сообщения в базе
+func (s *Service) announce(streamer *model.Streamer, stream Stream) {
+	logging.Log("Twitch", logrus.InfoLevel, fmt.Sprintf("%s начинает трансляцию на Twitch", streamer.Name))
+
+	twitchStream := toTwitchStream(stream)
+	sentMessages := s.discordBot.SendStreamAnnouncement(streamer, announceChannels(streamer), twitchStream)
+
+	for channelID, msgID := range sentMessages {
+		announcement := modeldb.StreamAnnouncement{
+			StreamerName: streamer.Name,
+			StreamID:     stream.ID,
+			ChannelID:    channelID,
+			DiscordMsgID: msgID,
+			Title:        stream.Title,
+			GameName:     stream.GameName,
+			PeakViewers:  stream.ViewerCount,
+			StartedAt:    twitchStream.StartedAt,
+		}
+		if err := s.DBHandlers.AnnouncementHandlers.CreateAnnouncement(&announcement); err != nil {
+			logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения анонса трансляции %s: %v", streamer.Name, err))
+		}
+	}
+}
+
+// refresh - обновляет статистику идущей трансляции и анонс при смене названия или категории
+func (s *Service) refresh(streamer *model.Streamer, stream Stream, active []modeldb.StreamAnnouncement) {
+	current := active[0]
+	changed := current.Title != stream.Title || current.GameName != stream.GameName
+
+	update := modeldb.StreamAnnouncement{
+		StreamID:    stream.ID,
+		Title:       stream.Title,
+		GameName:    stream.GameName,
+		PeakViewers: max(current.PeakViewers, stream.ViewerCount),
+	}
+	if err := s.DBHandlers.AnnouncementHandlers.UpdateAnnouncementStats(streamer.Name, current.StreamID, update); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка обновления анонса трансляции %s: %v", streamer.Name, err))
+	}
+
+	if !changed {
+		return
+	}
+
+	twitchStream := toTwitchStream(stream)
+	for _, announcement := range active {
+		s.discordBot.EditStreamAnnouncement(streamer, announcement.ChannelID, announcement.DiscordMsgID, twitchStream)
+	}
+}
+
+// finish - превращает анонсы трансляции в итоги и закрывает их в базе
+func (s *Service) finish(streamer *model.Streamer, active []modeldb.StreamAnnouncement) {
+	logging.Log("Twitch", logrus.InfoLevel, fmt.Sprintf("%s завершает трансляцию на Twitch", streamer.Name))
+
+	endedAt := time.Now()
+	summary := model.TwitchStream{
+		StreamID:    active[0].StreamID,
+		Login:       streamer.Twitch.Login,
+		Title:       active[0].Title,
+		GameName:    active[0].GameName,
+		PeakViewers: active[0].PeakViewers,
+		StartedAt:   active[0].StartedAt,
+		EndedAt:     endedAt,
+	}
+
+	for _, announcement := range active {
+		s.discordBot.EditStreamAnnouncement(streamer, announcement.ChannelID, announcement.DiscordMsgID, summary)
+	}
+
+	if err := s.DBHandlers.AnnouncementHandlers.FinishAnnouncements(streamer.Name, endedAt); err != nil {
+		logging.Log("Database", logrus.ErrorLevel, fmt.Sprintf("Ошибка закрытия анонсов трансляции %s: %v", streamer.Name, err))
+	}
+}
+
+func (s *Service) twitchLogins() []string {
+	var logins []string
+	for _, streamer := range s.storage.All() {
+		if streamer.Twitch != nil && streamer.Twitch.Login != "" {
+			logins = append(logins, strings.ToLower(streamer.Twitch.Login))
+		}
+	}
+	return logins
+}
+
+func (s *Service) findStreamer(login string) *model.Streamer {
+	for _, streamer := range s.storage.All() {
+		if streamer.Twitch != nil && strings.EqualFold(streamer.Twitch.Login, login) {
+			return &streamer
+		}
+	}
+	return nil
+}
+
+// announceChannels - возвращает каналы для анонсов; если они не заданы, используются каналы для постов
+func announceChannels(streamer *model.Streamer) []model.DiscordChannel {
+	if len(streamer.Twitch.DiscordChannels) > 0 {
+		return streamer.Twitch.DiscordChannels
+	}
+	return streamer.DiscordChannels
+}
+
+func toTwitchStream(stream Stream) model.TwitchStream {
+	startedAt := stream.StartedAt
+	if startedAt.IsZero() {
+		startedAt = time.Now()
+	}
+
+	return model.TwitchStream{
+		StreamID:     stream.ID,
+		Login:        stream.UserLogin,
+		DisplayName:  stream.UserName,
+		Title:        stream.Title,
+		GameName:     stream.GameName,
+		ThumbnailURL: stream.ThumbnailURL,
+		ViewerCount:  stream.ViewerCount,
+		PeakViewers:  stream.ViewerCount,
+		StartedAt:    startedAt,
+	}
+}
@@ -0,0 +1,166 @@
+package twitch
+
+import (
+	"fmt"
+	"slm-bot-publisher/config"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/database/dbtest"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	"slm-bot-publisher/internal/lib/secrets"
+	"slm-bot-publisher/internal/lib/storage"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAnnouncer - запоминает анонсы вместо отправки в Discord
+type fakeAnnouncer struct {
+	mutex   sync.Mutex
+	counter int
+	sent    []model.TwitchStream
+	edits   map[string]model.TwitchStream
+}
+
+func (a *fakeAnnouncer) SendStreamAnnouncement(streamer *model.Streamer, channels []model.DiscordChannel, stream model.TwitchStream) map[string]string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.sent = append(a.sent, stream)
+	sentMessages := make(map[string]string)
+	for _, channel := range channels {
+		a.counter++
+		sentMessages[channel.ChannelID] = fmt.Sprintf("msg-%d", a.counter)
+	}
+	return sentMessages
+}
+
+func (a *fakeAnnouncer) EditStreamAnnouncement(streamer *model.Streamer, channelID, msgID string, stream model.TwitchStream) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.edits[channelID+"/"+msgID] = stream
+}
+
+func newTestService(t *testing.T) (*Service, *fakeTwitch, *fakeAnnouncer, *handlers.DBHandlers) {
+	fake := newFakeTwitch(t)
+	DBHandlers := dbtest.New(t)
+
+	storageData := storage.NewStorage(DBHandlers.StreamerHandlers, &secrets.Keeper{}, "")
+	err := storageData.Add(model.Streamer{
+		Name:              "Test",
+		TelegramChannelID: -100,
+		DiscordChannels:   []model.DiscordChannel{{ChannelID: "posts"}},
+		Twitch: &model.TwitchSettings{
+			Login:           "Test",
+			DiscordChannels: []model.DiscordChannel{{ChannelID: "live-1"}, {ChannelID: "live-2"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	announcer := &fakeAnnouncer{edits: make(map[string]model.TwitchStream)}
+	service := NewService(config.TwitchConfig{
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		EventSubSecret: testEventSubSecret,
+		APIURL:         fake.server.URL + "/helix",
+		AuthURL:        fake.server.URL + "/token",
+		PollInterval:   time.Hour,
+	}, storageData, announcer, DBHandlers)
+	return service, fake, announcer, DBHandlers
+}
+
+func TestPollAnnouncesAndFinishesStream(t *testing.T) {
+	service, fake, announcer, DBHandlers := newTestService(t)
+	startedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	fake.setLive(Stream{ID: "stream-1", UserLogin: "test", Title: "Первый", GameName: "Игра", ViewerCount: 5, StartedAt: startedAt})
+
+	service.poll()
+	if len(announcer.sent) != 1 {
+		t.Fatalf("анонсов отправлено %d, ожидался 1", len(announcer.sent))
+	}
+	active, err := DBHandlers.AnnouncementHandlers.GetActiveAnnouncements("Test")
+	if err != nil || len(active) != 2 {
+		t.Fatalf("активных анонсов %d (%v), ожидалось по одному на канал анонсов", len(active), err)
+	}
+
+	// Повторный опрос той же трансляции не создает новый анонс, а обновляет статистику
+	fake.setLive(Stream{ID: "stream-1", UserLogin: "test", Title: "Второй", GameName: "Игра", ViewerCount: 42, StartedAt: startedAt})
+	service.poll()
+	if len(announcer.sent) != 1 {
+		t.Fatalf("анонс отправлен повторно")
+	}
+	if len(announcer.edits) != 2 {
+		t.Fatalf("анонсы не обновлены после смены названия: %+v", announcer.edits)
+	}
+	for key, stream := range announcer.edits {
+		if stream.Title != "Второй" {
+			t.Fatalf("анонс %s не обновлен: %+v", key, stream)
+		}
+	}
+
+	fake.setOffline("test")
+	service.poll()
+
+	if active, _ = DBHandlers.AnnouncementHandlers.GetActiveAnnouncements("Test"); len(active) != 0 {
+		t.Fatalf("анонсы не закрыты после окончания трансляции")
+	}
+	for key, summary := range announcer.edits {
+		if summary.EndedAt.IsZero() || summary.PeakViewers != 42 || summary.Title != "Второй" {
+			t.Fatalf("анонс %s не превращен в итоги: %+v", key, summary)
+		}
+	}
+}
+
+func TestEventSubOnlineOffline(t *testing.T) {
+	service, fake, announcer, DBHandlers := newTestService(t)
+	fake.setLive(Stream{ID: "stream-1", UserLogin: "test", Title: "Стрим", StartedAt: time.Now()})
+
+	service.handleOnline("test")
+	// Повторное уведомление о той же трансляции не дублирует анонс
+	service.handleOnline("test")
+	if len(announcer.sent) != 1 || announcer.sent[0].StreamID != "stream-1" {
+		t.Fatalf("ожидался один анонс трансляции, получено %+v", announcer.sent)
+	}
+
+	// Подложное окончание, пока трансляция идет, анонс не закрывает
+	service.handleOffline("test")
+	if active, _ := DBHandlers.AnnouncementHandlers.GetActiveAnnouncements("Test"); len(active) != 2 || len(announcer.edits) != 0 {
+		t.Fatalf("анонсы закрыты, хотя трансляция еще идет")
+	}
+
+	fake.setOffline("test")
+	service.handleOffline("test")
+	if active, _ := DBHandlers.AnnouncementHandlers.GetActiveAnnouncements("Test"); len(active) != 0 {
+		t.Fatalf("анонсы не закрыты после stream.offline")
+	}
+	if len(announcer.edits) != 2 {
+		t.Fatalf("итоги отправлены в %d каналов, ожидалось 2", len(announcer.edits))
+	}
+}
+
+func TestUnknownLoginIgnored(t *testing.T) {
+	service, _, announcer, _ := newTestService(t)
+
+	service.handleOnline("other")
+	service.handleOffline("other")
+	if len(announcer.sent) != 0 || len(announcer.edits) != 0 {
+		t.Fatalf("анонсы для неизвестного логина не должны отправляться")
+	}
+}
+
+func TestStartSkipsSubscriptionsWithoutWebhooks(t *testing.T) {
+	service, fake, _, _ := newTestService(t)
+	service.callbackURL = "https://example.com/twitch/eventsub"
+	fake.users["test"] = User{ID: "42", Login: "test"}
+
+	service.startWebhooks(false)
+	if len(fake.subscribed) != 0 {
+		t.Fatalf("подписки оформлены без HTTP сервера: %v", fake.subscribed)
+	}
+
+	service.startWebhooks(true)
+	if len(fake.subscribed) != 2 {
+		t.Fatalf("ожидались подписки на начало и окончание трансляции, получено %v", fake.subscribed)
+	}
+}
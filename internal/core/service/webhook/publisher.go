@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"slm-bot-publisher/internal/core/format"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/media"
+	"slm-bot-publisher/logging"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 5
+	initialRetryDelay = 5 * time.Second
+)
+
+// Settings - настройки исходящего вебхука в конфиге стримера
+type Settings struct {
+	URL        string
+	Secret     string
+	MaxRetries int
+}
+
+// Publisher - отправляет события о постах на внешние вебхуки в формате JSON с подписью HMAC
+type Publisher struct {
+	mediaStore *media.Store
+	httpClient *http.Client
+}
+
+func NewPublisher(mediaStore *media.Store) *Publisher {
+	return &Publisher{
+		mediaStore: mediaStore,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *Publisher) Publish(streamer *model.Streamer, post *model.Post) {
+	p.dispatch(streamer, EventPostCreated, p.buildDocument(streamer, post))
+}
+
+func (p *Publisher) Edit(streamer *model.Streamer, post *model.Post) {
+	p.dispatch(streamer, EventPostEdited, p.buildDocument(streamer, post))
+}
+
+func (p *Publisher) Delete(streamer *model.Streamer, post *model.Post) {
+	p.dispatch(streamer, EventPostDeleted, PostDocument{
+		Streamer:           streamer.Name,
+		TelegramChatID:     post.ChatID,
+		TelegramMessageID:  post.MessageID,
+		TelegramMessageIDs: []int{post.MessageID},
+	})
+}
+
+// dispatch - отправляет событие во все вебхуки стримера в фоне, чтобы повторы не задерживали обработку постов.
+// Очередь повторов не сохраняется: при перезапуске бота недоставленные события теряются
+func (p *Publisher) dispatch(streamer *model.Streamer, eventType string, document PostDocument) {
+	for _, destination := range p.destinations(streamer) {
+		event := Event{
+			Version:    SchemaVersion,
+			DeliveryID: newDeliveryID(),
+			Type:       eventType,
+			OccurredAt: time.Now().UTC(),
+			Post:       document,
+		}
+
+		go p.deliver(destination, event)
+	}
+}
+
+// deliver - доставляет событие с повторами и экспоненциальной задержкой; ID доставки при повторах не меняется
+func (p *Publisher) deliver(destination Settings, event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		logging.Log("Webhook", logrus.ErrorLevel, fmt.Sprintf("Ошибка сериализации события %s: %v", event.DeliveryID, err))
+		return
+	}
+
+	maxRetries := destination.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+
+	delay := initialRetryDelay
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		retry, err := p.send(destination, event, body)
+		if err == nil {
+			logging.Log("Webhook", logrus.InfoLevel, fmt.Sprintf("Событие %s (%s) доставлено на вебхук", event.Type, event.DeliveryID))
+			return
+		}
+
+		logging.Log("Webhook", logrus.WarnLevel, fmt.Sprintf("Попытка %d доставки события %s не удалась: %v", attempt+1, event.DeliveryID, err))
+		if !retry {
+			break
+		}
+	}
+
+	logging.Log("Webhook", logrus.ErrorLevel, fmt.Sprintf("Не удалось доставить событие %s (%s) на вебхук", event.Type, event.DeliveryID))
+}
+
+// send - выполняет одну попытку доставки и сообщает, имеет ли смысл повторять запрос
+func (p *Publisher) send(destination Settings, event Event, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, destination.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set(HeaderSignature, Sign(destination.Secret, body))
+	req.Header.Set(HeaderDelivery, event.DeliveryID)
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(event.OccurredAt.Unix(), 10))
+	req.Header.Set(HeaderVersion, SchemaVersion)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("статус %d", resp.StatusCode)
+	default:
+		// Остальные ошибки клиента при повторе не исправятся
+		return false, fmt.Errorf("статус %d", resp.StatusCode)
+	}
+}
+
+// Sign - вычисляет подпись тела запроса для заголовка X-SLM-Signature
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return SignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *Publisher) buildDocument(streamer *model.Streamer, post *model.Post) PostDocument {
+	document := PostDocument{
+		Streamer:          streamer.Name,
+		TelegramChatID:    post.ChatID,
+		TelegramMessageID: post.MessageID,
+		Link:              post.Link,
+		Text:              post.Text,
+		Markdown:          format.Markdown(post.Text, post.Entities),
+	}
+
+	for _, part := range post.Parts {
+		document.TelegramMessageIDs = append(document.TelegramMessageIDs, part.MessageID)
+	}
+	if len(document.TelegramMessageIDs) == 0 {
+		document.TelegramMessageIDs = []int{post.MessageID}
+	}
+
+	for _, attachment := range post.Attachments {
+		mediaDocument := MediaDocument{
+			Kind:              attachment.Kind,
+			Name:              attachment.Name,
+			Size:              len(attachment.Data),
+			MimeType:          http.DetectContentType(attachment.Data),
+			TelegramMessageID: attachment.MessageID,
+			TelegramFileID:    attachment.FileID,
+		}
+		if p.mediaStore.Enabled() {
+			url, err := p.mediaStore.Save(attachment)
+			if err != nil {
+				logging.Log("Webhook", logrus.ErrorLevel, fmt.Sprintf("Ошибка сохранения вложения %s: %v", attachment.Name, err))
+			}
+			mediaDocument.URL = url
+		}
+		document.Media = append(document.Media, mediaDocument)
+	}
+
+	if post.Repost != nil && post.Repost.Type != "" {
+		document.Repost = &RepostDocument{
+			Type:       post.Repost.Type,
+			AuthorName: post.Repost.AuthorName,
+			AuthorLink: post.Repost.AuthorLink,
+			Link:       post.Repost.Link,
+		}
+		if !post.Repost.Date.IsZero() {
+			date := post.Repost.Date.UTC()
+			document.Repost.Date = &date
+		}
+	}
+
+	return document
+}
+
+// destinations - разбирает настройки всех исходящих вебхуков стримера
+func (p *Publisher) destinations(streamer *model.Streamer) []Settings {
+	var settings []Settings
+	for _, destination := range streamer.DestinationsOf(model.DestinationWebhook) {
+		var destinationSettings Settings
+		if err := json.Unmarshal(destination.Settings, &destinationSettings); err != nil {
+			logging.Log("Webhook", logrus.ErrorLevel, fmt.Sprintf("Ошибка разбора настроек вебхука у стримера %s: %v", streamer.Name, err))
+			continue
+		}
+		settings = append(settings, destinationSettings)
+	}
+	return settings
+}
+
+func newDeliveryID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
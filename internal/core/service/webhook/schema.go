@@ -0,0 +1,68 @@
+package webhook
+
+import "time"
+
+// SchemaVersion - версия формата событий. Увеличивается при несовместимых изменениях;
+// новые необязательные поля добавляются без смены версии
+const SchemaVersion = "1"
+
+const (
+	EventPostCreated = "post.created"
+	EventPostEdited  = "post.edited"
+	EventPostDeleted = "post.deleted"
+)
+
+const (
+	HeaderSignature = "X-SLM-Signature"
+	HeaderDelivery  = "X-SLM-Delivery"
+	HeaderEvent     = "X-SLM-Event"
+	HeaderTimestamp = "X-SLM-Timestamp"
+	HeaderVersion   = "X-SLM-Schema-Version"
+	SignaturePrefix = "sha256="
+	contentTypeJSON = "application/json; charset=utf-8"
+)
+
+// Event - тело запроса, которое получает вебхук. Подпись X-SLM-Signature считается
+// как HMAC-SHA256 от тела запроса с секретом вебхука и передается в виде "sha256=<hex>".
+// Повторы доставки идут в памяти процесса и не сохраняются, поэтому при перезапуске бота недоставленные события теряются
+type Event struct {
+	Version    string       `json:"version"`
+	DeliveryID string       `json:"delivery_id"`
+	Type       string       `json:"type"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	Post       PostDocument `json:"post"`
+}
+
+// PostDocument - пост из Telegram. Для события post.deleted заполнены только стример и идентификаторы.
+// В post.edited нет media: правка в Telegram меняет только текст, вложения заново не скачиваются
+type PostDocument struct {
+	Streamer           string          `json:"streamer"`
+	TelegramChatID     int64           `json:"telegram_chat_id"`
+	TelegramMessageID  int             `json:"telegram_message_id"`
+	TelegramMessageIDs []int           `json:"telegram_message_ids"`
+	Link               string          `json:"link,omitempty"`
+	Text               string          `json:"text,omitempty"`
+	Markdown           string          `json:"markdown,omitempty"`
+	Media              []MediaDocument `json:"media,omitempty"`
+	Repost             *RepostDocument `json:"repost,omitempty"`
+}
+
+// MediaDocument - вложение поста. URL заполнен, если у бота настроен публичный адрес для вложений
+type MediaDocument struct {
+	Kind              string `json:"kind"`
+	Name              string `json:"name"`
+	Size              int    `json:"size"`
+	MimeType          string `json:"mime_type"`
+	URL               string `json:"url,omitempty"`
+	TelegramMessageID int    `json:"telegram_message_id"`
+	TelegramFileID    string `json:"telegram_file_id"`
+}
+
+// RepostDocument - источник пересланного поста
+type RepostDocument struct {
+	Type       string     `json:"type"`
+	AuthorName string     `json:"author_name"`
+	AuthorLink string     `json:"author_link,omitempty"`
+	Link       string     `json:"link,omitempty"`
+	Date       *time.Time `json:"date,omitempty"`
+}
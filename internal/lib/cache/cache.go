@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type item[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// Cache - потокобезопасный кэш значений с ограниченным временем жизни записей
+type Cache[K comparable, V any] struct {
+	ttl   time.Duration
+	mutex sync.Mutex
+	items map[K]item[V]
+}
+
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		ttl:   ttl,
+		items: make(map[K]item[V]),
+	}
+}
+
+// Get - возвращает значение по ключу, если оно есть в кэше и ещё не устарело
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cached, exists := c.items[key]
+	if !exists {
+		var empty V
+		return empty, false
+	}
+
+	if time.Now().After(cached.expiresAt) {
+		delete(c.items, key)
+		var empty V
+		return empty, false
+	}
+
+	return cached.value, true
+}
+
+// Set - сохраняет значение в кэш на время жизни кэша
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.items[key] = item[V]{
+		value:     value,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+}
+
+// Delete - удаляет значение из кэша
+func (c *Cache[K, V]) Delete(key K) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.items, key)
+}
+
+// Prune - удаляет устаревшие записи, которые больше не запрашивались
+func (c *Cache[K, V]) Prune() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for key, cached := range c.items {
+		if now.After(cached.expiresAt) {
+			delete(c.items, key)
+		}
+	}
+}
@@ -9,13 +9,25 @@ import (
 	"log"
 	"os"
 	"slm-bot-publisher/internal/lib/database/handlers"
+	"slm-bot-publisher/internal/lib/database/handlers/announcement"
+	"slm-bot-publisher/internal/lib/database/handlers/comment"
+	"slm-bot-publisher/internal/lib/database/handlers/delivery"
 	"slm-bot-publisher/internal/lib/database/handlers/message"
+	"slm-bot-publisher/internal/lib/database/handlers/post"
+	"slm-bot-publisher/internal/lib/database/handlers/queue"
+	"slm-bot-publisher/internal/lib/database/handlers/schedule"
+	"slm-bot-publisher/internal/lib/database/handlers/streamer"
 	modeldb "slm-bot-publisher/internal/lib/database/model"
 	"slm-bot-publisher/logging"
 	"time"
 )
 
 func InitDB(dbFilePath string) *handlers.DBHandlers {
+	return InitDBWithLog(dbFilePath, "logs/db_queries.log")
+}
+
+// InitDBWithLog - как InitDB, но пишет лог запросов в указанный файл, а не в logs текущей директории
+func InitDBWithLog(dbFilePath, logFilePath string) *handlers.DBHandlers {
 	// Создаем файл базы данных, если он не существует
 	if _, err := os.Stat(dbFilePath); os.IsNotExist(err) {
 		logging.Log("Database", logrus.InfoLevel, fmt.Sprintf("Создание базы данных по адресу: %s", dbFilePath))
@@ -28,7 +40,7 @@ func InitDB(dbFilePath string) *handlers.DBHandlers {
 	}
 
 	// Создание файла для логов запросов базы данных
-	logFile, err := os.OpenFile("logs/db_queries.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		logging.Log("Database", logrus.PanicLevel, fmt.Sprintf("Ошибка создания файла логов: %v", err))
 		return nil
@@ -53,8 +65,8 @@ func InitDB(dbFilePath string) *handlers.DBHandlers {
 		return nil
 	}
 
-	// Автомиграция модели Message
-	err = db.AutoMigrate(&modeldb.Message{})
+	// Автомиграция моделей
+	err = db.AutoMigrate(&modeldb.Message{}, &modeldb.StreamAnnouncement{}, &modeldb.Post{}, &modeldb.DiscussionPost{}, &modeldb.Comment{}, &modeldb.QueuedPost{}, &modeldb.ScheduledPost{}, &modeldb.FailedDelivery{}, &modeldb.Streamer{}, &modeldb.DiscordChannel{})
 	if err != nil {
 		logging.Log("Database", logrus.PanicLevel, fmt.Sprintf("Ошибка автомиграции моделей: %v", err))
 		return nil
@@ -62,9 +74,30 @@ func InitDB(dbFilePath string) *handlers.DBHandlers {
 
 	// Инициализация хендлеров для работы с сообщениями
 	messageHandler := message.NewHandlerDBMessage(db)
+	// Инициализация хендлеров для работы с анонсами трансляций
+	announcementHandler := announcement.NewHandlerDBAnnouncement(db)
+	// Инициализация хендлеров для работы с постами лент
+	postHandler := post.NewHandlerDBPost(db)
+	// Инициализация хендлеров для работы с комментариями
+	commentHandler := comment.NewHandlerDBComment(db)
+	// Инициализация хендлеров для работы с очередью постов на паузе
+	queueHandler := queue.NewHandlerDBQueue(db)
+	// Инициализация хендлеров для работы с отложенными отправками
+	scheduleHandler := schedule.NewHandlerDBSchedule(db)
+	// Инициализация хендлеров для работы с неудачными отправками
+	deliveryHandler := delivery.NewHandlerDBDelivery(db)
+	// Инициализация хендлеров для работы со стримерами
+	streamerHandler := streamer.NewHandlerDBStreamer(db)
 
 	return &handlers.DBHandlers{
-		DB:              db,
-		MessageHandlers: messageHandler,
+		DB:                   db,
+		MessageHandlers:      messageHandler,
+		AnnouncementHandlers: announcementHandler,
+		PostHandlers:         postHandler,
+		CommentHandlers:      commentHandler,
+		QueueHandlers:        queueHandler,
+		ScheduleHandlers:     scheduleHandler,
+		DeliveryHandlers:     deliveryHandler,
+		StreamerHandlers:     streamerHandler,
 	}
 }
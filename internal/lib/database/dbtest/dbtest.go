@@ -0,0 +1,22 @@
+package dbtest
+
+import (
+	"path/filepath"
+	"slm-bot-publisher/internal/lib/database"
+	"slm-bot-publisher/internal/lib/database/handlers"
+	"testing"
+)
+
+// New - создает базу и лог ее запросов во временной директории теста
+func New(t testing.TB) *handlers.DBHandlers {
+	t.Helper()
+
+	dir := t.TempDir()
+	DBHandlers := database.InitDBWithLog(filepath.Join(dir, "test.db"), filepath.Join(dir, "db_queries.log"))
+	t.Cleanup(func() {
+		if db, err := DBHandlers.DB.DB(); err == nil {
+			_ = db.Close()
+		}
+	})
+	return DBHandlers
+}
@@ -0,0 +1,7 @@
+package announcement
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBAnnouncement) CreateAnnouncement(announcement *modeldb.StreamAnnouncement) error {
+	return h.DB.Create(announcement).Error
+}
@@ -0,0 +1,12 @@
+package announcement
+
+import (
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"time"
+)
+
+func (h *HandlerDBAnnouncement) FinishAnnouncements(streamerName string, endedAt time.Time) error {
+	return h.DB.Model(&modeldb.StreamAnnouncement{}).
+		Where("streamer_name = ? AND ended_at IS NULL", streamerName).
+		Update("ended_at", endedAt).Error
+}
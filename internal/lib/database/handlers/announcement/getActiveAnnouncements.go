@@ -0,0 +1,14 @@
+package announcement
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBAnnouncement) GetActiveAnnouncements(streamerName string) ([]modeldb.StreamAnnouncement, error) {
+	var announcements []modeldb.StreamAnnouncement
+
+	err := h.DB.Where("streamer_name = ? AND ended_at IS NULL", streamerName).Find(&announcements).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return announcements, nil
+}
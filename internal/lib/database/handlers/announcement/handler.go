@@ -0,0 +1,11 @@
+package announcement
+
+import "gorm.io/gorm"
+
+type HandlerDBAnnouncement struct {
+	DB *gorm.DB
+}
+
+func NewHandlerDBAnnouncement(db *gorm.DB) *HandlerDBAnnouncement {
+	return &HandlerDBAnnouncement{DB: db}
+}
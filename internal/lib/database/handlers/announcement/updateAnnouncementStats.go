@@ -0,0 +1,9 @@
+package announcement
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBAnnouncement) UpdateAnnouncementStats(streamerName, streamID string, announcement modeldb.StreamAnnouncement) error {
+	return h.DB.Model(&modeldb.StreamAnnouncement{}).
+		Where("streamer_name = ? AND stream_id = ? AND ended_at IS NULL", streamerName, streamID).
+		Updates(announcement).Error
+}
@@ -0,0 +1,7 @@
+package comment
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBComment) CreateComment(comment *modeldb.Comment) error {
+	return h.DB.Create(comment).Error
+}
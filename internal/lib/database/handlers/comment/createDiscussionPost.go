@@ -0,0 +1,7 @@
+package comment
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBComment) CreateDiscussionPost(discussionPost *modeldb.DiscussionPost) error {
+	return h.DB.Create(discussionPost).Error
+}
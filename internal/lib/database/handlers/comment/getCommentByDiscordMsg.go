@@ -0,0 +1,14 @@
+package comment
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBComment) GetCommentByDiscordMsg(discordThreadID string, discordMsgID string) (*modeldb.Comment, error) {
+	var comment modeldb.Comment
+
+	err := h.DB.Where("discord_thread_id = ? AND discord_msg_id = ?", discordThreadID, discordMsgID).First(&comment).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
@@ -0,0 +1,14 @@
+package comment
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBComment) GetCommentByDiscussionMsg(discussionChatID int64, discussionMsgID int) (*modeldb.Comment, error) {
+	var comment modeldb.Comment
+
+	err := h.DB.Where("discussion_chat_id = ? AND discussion_msg_id = ?", discussionChatID, discussionMsgID).First(&comment).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
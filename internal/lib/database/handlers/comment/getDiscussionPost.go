@@ -0,0 +1,15 @@
+package comment
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetDiscussionPost - возвращает копию поста канала в группе обсуждения
+func (h *HandlerDBComment) GetDiscussionPost(telegramChatID int64, telegramMsgID int) (*modeldb.DiscussionPost, error) {
+	var discussionPost modeldb.DiscussionPost
+
+	err := h.DB.Where("telegram_chat_id = ? AND telegram_msg_id = ?", telegramChatID, telegramMsgID).First(&discussionPost).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &discussionPost, nil
+}
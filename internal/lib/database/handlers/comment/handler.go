@@ -0,0 +1,11 @@
+package comment
+
+import "gorm.io/gorm"
+
+type HandlerDBComment struct {
+	DB *gorm.DB
+}
+
+func NewHandlerDBComment(db *gorm.DB) *HandlerDBComment {
+	return &HandlerDBComment{DB: db}
+}
@@ -0,0 +1,7 @@
+package delivery
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBDelivery) CreateFailedDelivery(delivery *modeldb.FailedDelivery) error {
+	return h.DB.Create(delivery).Error
+}
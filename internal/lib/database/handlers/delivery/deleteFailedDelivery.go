@@ -0,0 +1,7 @@
+package delivery
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBDelivery) DeleteFailedDelivery(id uint) error {
+	return h.DB.Delete(&modeldb.FailedDelivery{}, id).Error
+}
@@ -0,0 +1,15 @@
+package delivery
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetFailedDeliveries - возвращает до limit последних неудачных отправок, начиная с новых
+func (h *HandlerDBDelivery) GetFailedDeliveries(limit int) ([]modeldb.FailedDelivery, error) {
+	var deliveries []modeldb.FailedDelivery
+
+	err := h.DB.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,14 @@
+package delivery
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBDelivery) GetFailedDelivery(id uint) (*modeldb.FailedDelivery, error) {
+	var delivery modeldb.FailedDelivery
+
+	err := h.DB.First(&delivery, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
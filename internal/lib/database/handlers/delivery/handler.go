@@ -0,0 +1,11 @@
+package delivery
+
+import "gorm.io/gorm"
+
+type HandlerDBDelivery struct {
+	DB *gorm.DB
+}
+
+func NewHandlerDBDelivery(db *gorm.DB) *HandlerDBDelivery {
+	return &HandlerDBDelivery{DB: db}
+}
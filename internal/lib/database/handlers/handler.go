@@ -2,10 +2,24 @@ package handlers
 
 import (
 	"gorm.io/gorm"
+	"slm-bot-publisher/internal/lib/database/handlers/announcement"
+	"slm-bot-publisher/internal/lib/database/handlers/comment"
+	"slm-bot-publisher/internal/lib/database/handlers/delivery"
 	"slm-bot-publisher/internal/lib/database/handlers/message"
+	"slm-bot-publisher/internal/lib/database/handlers/post"
+	"slm-bot-publisher/internal/lib/database/handlers/queue"
+	"slm-bot-publisher/internal/lib/database/handlers/schedule"
+	"slm-bot-publisher/internal/lib/database/handlers/streamer"
 )
 
 type DBHandlers struct {
-	DB              *gorm.DB
-	MessageHandlers *message.HandlerDBMessage
+	DB                   *gorm.DB
+	MessageHandlers      *message.HandlerDBMessage
+	AnnouncementHandlers *announcement.HandlerDBAnnouncement
+	PostHandlers         *post.HandlerDBPost
+	CommentHandlers      *comment.HandlerDBComment
+	QueueHandlers        *queue.HandlerDBQueue
+	ScheduleHandlers     *schedule.HandlerDBSchedule
+	DeliveryHandlers     *delivery.HandlerDBDelivery
+	StreamerHandlers     *streamer.HandlerDBStreamer
 }
@@ -0,0 +1,20 @@
+package message
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// DeleteChatMessageByID - как DeleteMessageByID, но учитывает канал Telegram
+func (h *HandlerDBMessage) DeleteChatMessageByID(channelID string, telegramChatID int64, telegramMsgID int) error {
+	var message modeldb.Message
+
+	err := h.DB.Where("channel_id = ? AND telegram_chat_id = ? AND telegram_msg_id = ?", channelID, telegramChatID, telegramMsgID).First(&message).Error
+	if err != nil {
+		return err
+	}
+
+	err = h.DB.Where("channel_id = ? AND telegram_chat_id = ? AND discord_msg_id = ?", channelID, telegramChatID, message.DestinationMsgID).Delete(&modeldb.Message{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+package message
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBMessage) DeleteIncomingMessages(channelID string, destinationMsgID string) error {
+	return h.DB.Where("channel_id = ? AND discord_msg_id = ? AND direction = ?", channelID, destinationMsgID, modeldb.DirectionIncoming).
+		Delete(&modeldb.Message{}).Error
+}
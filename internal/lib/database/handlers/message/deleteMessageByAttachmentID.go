@@ -0,0 +1,8 @@
+package message
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// DeleteMessageByAttachmentID - удаляет одну запись о сообщении площадки, не затрагивая остальные сообщения поста
+func (h *HandlerDBMessage) DeleteMessageByAttachmentID(channelID string, destinationAttachmentID string) error {
+	return h.DB.Where("channel_id = ? AND discord_attachment_id = ?", channelID, destinationAttachmentID).Delete(&modeldb.Message{}).Error
+}
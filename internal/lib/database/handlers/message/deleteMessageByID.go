@@ -10,7 +10,7 @@ func (h *HandlerDBMessage) DeleteMessageByID(channelID string, telegramMsgID int
 		return err
 	}
 
-	err = h.DB.Where("discord_msg_id = ?", message.DiscordMsgID).Delete(&modeldb.Message{}).Error
+	err = h.DB.Where("discord_msg_id = ?", message.DestinationMsgID).Delete(&modeldb.Message{}).Error
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,22 @@
+package message
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetChatMessageByID - как GetMessageByID, но учитывает канал Telegram. Нужен площадкам, у которых
+// ChannelID общий для разных стримеров, например сервер Mastodon
+func (h *HandlerDBMessage) GetChatMessageByID(channelID string, telegramChatID int64, telegramMsgID int) ([]modeldb.Message, error) {
+	var message modeldb.Message
+
+	err := h.DB.Where("channel_id = ? AND telegram_chat_id = ? AND telegram_msg_id = ? AND direction = ?", channelID, telegramChatID, telegramMsgID, modeldb.DirectionOutgoing).First(&message).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var relatedMessages []modeldb.Message
+	err = h.DB.Where("channel_id = ? AND telegram_chat_id = ? AND discord_msg_id = ?", channelID, telegramChatID, message.DestinationMsgID).Find(&relatedMessages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return relatedMessages, nil
+}
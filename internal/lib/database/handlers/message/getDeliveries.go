@@ -0,0 +1,18 @@
+package message
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetDeliveries - возвращает все копии сообщения Telegram, опубликованные ботом на площадках.
+// Записи без ID чата остались от версий, где бот публиковал только из одного канала
+func (h *HandlerDBMessage) GetDeliveries(telegramChatID int64, telegramMsgID int) ([]modeldb.Message, error) {
+	var messages []modeldb.Message
+
+	err := h.DB.Where("telegram_chat_id IN (?, 0) AND telegram_msg_id = ? AND direction = ?", telegramChatID, telegramMsgID, modeldb.DirectionOutgoing).
+		Order("platform, channel_id").
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,16 @@
+package message
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetIncomingMessages - возвращает сообщения Telegram, созданные обратным мостом из сообщения площадки
+func (h *HandlerDBMessage) GetIncomingMessages(channelID string, destinationMsgID string) ([]modeldb.Message, error) {
+	var messages []modeldb.Message
+
+	err := h.DB.Where("channel_id = ? AND discord_msg_id = ? AND direction = ?", channelID, destinationMsgID, modeldb.DirectionIncoming).
+		Order("telegram_msg_id").Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,18 @@
+package message
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetLatestOutgoingMessages - возвращает основные записи копий последних постов на всех площадках, начиная с новых
+func (h *HandlerDBMessage) GetLatestOutgoingMessages(limit int) ([]modeldb.Message, error) {
+	var messages []modeldb.Message
+
+	err := h.DB.Where("direction = ? AND main_post = ?", modeldb.DirectionOutgoing, true).
+		Order("id DESC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
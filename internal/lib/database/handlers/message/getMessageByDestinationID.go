@@ -0,0 +1,16 @@
+package message
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetMessageByDestinationID - возвращает основную запись поста Telegram по ID его копии на площадке
+func (h *HandlerDBMessage) GetMessageByDestinationID(platform string, destinationMsgID string) (*modeldb.Message, error) {
+	var message modeldb.Message
+
+	err := h.DB.Where("platform = ? AND discord_msg_id = ? AND direction = ? AND main_post = ?", platform, destinationMsgID, modeldb.DirectionOutgoing, true).
+		First(&message).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
@@ -5,13 +5,13 @@ import modeldb "slm-bot-publisher/internal/lib/database/model"
 func (h *HandlerDBMessage) GetMessageByID(channelID string, telegramMsgID int) ([]modeldb.Message, error) {
 	var message modeldb.Message
 
-	err := h.DB.Where("channel_id = ? AND telegram_msg_id = ?", channelID, telegramMsgID).First(&message).Error
+	err := h.DB.Where("channel_id = ? AND telegram_msg_id = ? AND direction = ?", channelID, telegramMsgID, modeldb.DirectionOutgoing).First(&message).Error
 	if err != nil {
 		return nil, err
 	}
 
 	var relatedMessages []modeldb.Message
-	err = h.DB.Where("discord_msg_id = ?", message.DiscordMsgID).Find(&relatedMessages).Error
+	err = h.DB.Where("discord_msg_id = ?", message.DestinationMsgID).Find(&relatedMessages).Error
 	if err != nil {
 		return nil, err
 	}
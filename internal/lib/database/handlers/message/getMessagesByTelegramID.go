@@ -0,0 +1,21 @@
+package message
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetMessagesByTelegramID - возвращает все сообщения Telegram, из которых состоит пост, независимо от канала публикации
+func (h *HandlerDBMessage) GetMessagesByTelegramID(telegramChatID int64, telegramMsgID int) ([]modeldb.Message, error) {
+	var message modeldb.Message
+
+	err := h.DB.Where("telegram_chat_id = ? AND telegram_msg_id = ?", telegramChatID, telegramMsgID).First(&message).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var relatedMessages []modeldb.Message
+	err = h.DB.Where("channel_id = ? AND discord_msg_id = ?", message.ChannelID, message.DestinationMsgID).Find(&relatedMessages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return relatedMessages, nil
+}
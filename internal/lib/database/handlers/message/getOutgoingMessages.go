@@ -0,0 +1,22 @@
+package message
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetOutgoingMessages - возвращает все копии постов канала Telegram на площадках.
+// Записи без ID чата остались от старых версий и относятся к каналу по ID канала площадки
+func (h *HandlerDBMessage) GetOutgoingMessages(telegramChatID int64, legacyChannelIDs []string) ([]modeldb.Message, error) {
+	var messages []modeldb.Message
+
+	query := h.DB.Where("direction = ?", modeldb.DirectionOutgoing)
+	if len(legacyChannelIDs) > 0 {
+		query = query.Where("telegram_chat_id = ? OR (telegram_chat_id = 0 AND channel_id IN ?)", telegramChatID, legacyChannelIDs)
+	} else {
+		query = query.Where("telegram_chat_id = ?", telegramChatID)
+	}
+
+	if err := query.Order("telegram_msg_id").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,14 @@
+package message
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// IsIncomingMessage - проверяет, что сообщение Telegram создано обратным мостом и не должно копироваться обратно
+func (h *HandlerDBMessage) IsIncomingMessage(telegramChatID int64, telegramMsgID int) bool {
+	var count int64
+
+	err := h.DB.Model(&modeldb.Message{}).
+		Where("telegram_chat_id = ? AND telegram_msg_id = ? AND direction = ?", telegramChatID, telegramMsgID, modeldb.DirectionIncoming).
+		Count(&count).Error
+
+	return err == nil && count > 0
+}
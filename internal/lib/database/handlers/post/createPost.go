@@ -0,0 +1,14 @@
+package post
+
+import (
+	"gorm.io/gorm/clause"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+)
+
+// CreatePost - сохраняет пост ленты; удаленный ранее пост с тем же сообщением Telegram восстанавливается (например, после /resend)
+func (h *HandlerDBPost) CreatePost(post *modeldb.Post) error {
+	return h.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "telegram_chat_id"}, {Name: "telegram_msg_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"streamer_name", "text", "html", "media", "link", "repost_from", "created_at", "updated_at", "deleted_at"}),
+	}).Create(post).Error
+}
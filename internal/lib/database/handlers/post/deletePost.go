@@ -0,0 +1,7 @@
+package post
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBPost) DeletePost(telegramChatID int64, telegramMsgID int) error {
+	return h.DB.Where("telegram_chat_id = ? AND telegram_msg_id = ?", telegramChatID, telegramMsgID).Delete(&modeldb.Post{}).Error
+}
@@ -0,0 +1,14 @@
+package post
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBPost) GetLatestPosts(streamerName string, limit int) ([]modeldb.Post, error) {
+	var posts []modeldb.Post
+
+	err := h.DB.Where("streamer_name = ?", streamerName).Order("created_at DESC").Limit(limit).Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
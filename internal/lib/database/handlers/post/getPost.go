@@ -0,0 +1,15 @@
+package post
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetPost - возвращает пост ленты по ID сообщения в канале Telegram
+func (h *HandlerDBPost) GetPost(telegramChatID int64, telegramMsgID int) (*modeldb.Post, error) {
+	var post modeldb.Post
+
+	err := h.DB.Where("telegram_chat_id = ? AND telegram_msg_id = ?", telegramChatID, telegramMsgID).First(&post).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &post, nil
+}
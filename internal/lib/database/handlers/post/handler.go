@@ -0,0 +1,11 @@
+package post
+
+import "gorm.io/gorm"
+
+type HandlerDBPost struct {
+	DB *gorm.DB
+}
+
+func NewHandlerDBPost(db *gorm.DB) *HandlerDBPost {
+	return &HandlerDBPost{DB: db}
+}
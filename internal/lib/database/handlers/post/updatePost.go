@@ -0,0 +1,10 @@
+package post
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBPost) UpdatePost(telegramChatID int64, telegramMsgID int, post modeldb.Post) error {
+	return h.DB.Model(&modeldb.Post{}).
+		Where("telegram_chat_id = ? AND telegram_msg_id = ?", telegramChatID, telegramMsgID).
+		Select("text", "html", "updated_at").
+		Updates(post).Error
+}
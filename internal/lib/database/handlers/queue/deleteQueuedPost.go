@@ -0,0 +1,7 @@
+package queue
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBQueue) DeleteQueuedPost(id uint) error {
+	return h.DB.Delete(&modeldb.QueuedPost{}, id).Error
+}
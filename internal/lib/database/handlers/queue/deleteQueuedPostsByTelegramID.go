@@ -0,0 +1,8 @@
+package queue
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// DeleteQueuedPostsByTelegramID - убирает из очереди пост, удаленный в Telegram до окончания паузы
+func (h *HandlerDBQueue) DeleteQueuedPostsByTelegramID(telegramChatID int64, telegramMsgID int) error {
+	return h.DB.Where("telegram_chat_id = ? AND telegram_msg_id = ?", telegramChatID, telegramMsgID).Delete(&modeldb.QueuedPost{}).Error
+}
@@ -0,0 +1,15 @@
+package queue
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetQueuedPosts - возвращает посты, ожидающие стримера или канала, в порядке публикации
+func (h *HandlerDBQueue) GetQueuedPosts(streamerName, channelID string) ([]modeldb.QueuedPost, error) {
+	var posts []modeldb.QueuedPost
+
+	err := h.DB.Where("streamer_name = ? AND channel_id = ?", streamerName, channelID).Order("id").Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
@@ -0,0 +1,15 @@
+package queue
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// GetQueuedPostsByTelegramID - возвращает все отложенные копии поста: для стримера и для каждого канала на паузе
+func (h *HandlerDBQueue) GetQueuedPostsByTelegramID(telegramChatID int64, telegramMsgID int) ([]modeldb.QueuedPost, error) {
+	var posts []modeldb.QueuedPost
+
+	err := h.DB.Where("telegram_chat_id = ? AND telegram_msg_id = ?", telegramChatID, telegramMsgID).Order("id").Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
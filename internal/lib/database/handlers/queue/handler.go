@@ -0,0 +1,11 @@
+package queue
+
+import "gorm.io/gorm"
+
+type HandlerDBQueue struct {
+	DB *gorm.DB
+}
+
+func NewHandlerDBQueue(db *gorm.DB) *HandlerDBQueue {
+	return &HandlerDBQueue{DB: db}
+}
@@ -0,0 +1,15 @@
+package queue
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// ListQueuedPosts - возвращает все отложенные паузой посты без содержимого, в порядке публикации
+func (h *HandlerDBQueue) ListQueuedPosts() ([]modeldb.QueuedPost, error) {
+	var posts []modeldb.QueuedPost
+
+	err := h.DB.Omit("payload").Order("id").Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
@@ -0,0 +1,7 @@
+package queue
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBQueue) UpdateQueuedPost(post *modeldb.QueuedPost) error {
+	return h.DB.Save(post).Error
+}
@@ -0,0 +1,7 @@
+package schedule
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBSchedule) DeleteScheduledPost(id uint) error {
+	return h.DB.Delete(&modeldb.ScheduledPost{}, id).Error
+}
@@ -0,0 +1,8 @@
+package schedule
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// DeleteScheduledPostsByTelegramID - отменяет все отложенные отправки поста
+func (h *HandlerDBSchedule) DeleteScheduledPostsByTelegramID(telegramChatID int64, telegramMsgID int) error {
+	return h.DB.Where("telegram_chat_id = ? AND telegram_msg_id = ?", telegramChatID, telegramMsgID).Delete(&modeldb.ScheduledPost{}).Error
+}
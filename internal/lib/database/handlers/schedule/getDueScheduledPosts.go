@@ -0,0 +1,18 @@
+package schedule
+
+import (
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+	"time"
+)
+
+// GetDueScheduledPosts - возвращает отправки, время которых наступило, начиная с самых ранних
+func (h *HandlerDBSchedule) GetDueScheduledPosts(now time.Time) ([]modeldb.ScheduledPost, error) {
+	var posts []modeldb.ScheduledPost
+
+	err := h.DB.Where("send_at <= ?", now).Order("send_at, id").Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
@@ -0,0 +1,14 @@
+package schedule
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBSchedule) GetScheduledPostsByTelegramID(telegramChatID int64, telegramMsgID int) ([]modeldb.ScheduledPost, error) {
+	var posts []modeldb.ScheduledPost
+
+	err := h.DB.Where("telegram_chat_id = ? AND telegram_msg_id = ?", telegramChatID, telegramMsgID).Order("send_at").Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
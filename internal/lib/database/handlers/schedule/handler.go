@@ -0,0 +1,11 @@
+package schedule
+
+import "gorm.io/gorm"
+
+type HandlerDBSchedule struct {
+	DB *gorm.DB
+}
+
+func NewHandlerDBSchedule(db *gorm.DB) *HandlerDBSchedule {
+	return &HandlerDBSchedule{DB: db}
+}
@@ -0,0 +1,15 @@
+package schedule
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+// ListScheduledPosts - возвращает все отложенные отправки без содержимого, начиная с самых ранних
+func (h *HandlerDBSchedule) ListScheduledPosts() ([]modeldb.ScheduledPost, error) {
+	var posts []modeldb.ScheduledPost
+
+	err := h.DB.Omit("payload").Order("send_at, id").Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
@@ -0,0 +1,7 @@
+package schedule
+
+import modeldb "slm-bot-publisher/internal/lib/database/model"
+
+func (h *HandlerDBSchedule) UpdateScheduledPost(post *modeldb.ScheduledPost) error {
+	return h.DB.Save(post).Error
+}
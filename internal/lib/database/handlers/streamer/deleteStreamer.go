@@ -0,0 +1,16 @@
+package streamer
+
+import (
+	"gorm.io/gorm"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+)
+
+// DeleteStreamer - удаляет стримера вместе с каналами Discord
+func (h *HandlerDBStreamer) DeleteStreamer(id uint) error {
+	return h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("streamer_id = ?", id).Delete(&modeldb.DiscordChannel{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&modeldb.Streamer{}, id).Error
+	})
+}
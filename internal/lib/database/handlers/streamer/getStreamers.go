@@ -0,0 +1,20 @@
+package streamer
+
+import (
+	"gorm.io/gorm"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+)
+
+// GetStreamers - возвращает всех стримеров с каналами Discord в порядке добавления
+func (h *HandlerDBStreamer) GetStreamers() ([]modeldb.Streamer, error) {
+	var streamers []modeldb.Streamer
+
+	err := h.DB.Preload("DiscordChannels", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Order("id").Find(&streamers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return streamers, nil
+}
@@ -0,0 +1,11 @@
+package streamer
+
+import "gorm.io/gorm"
+
+type HandlerDBStreamer struct {
+	DB *gorm.DB
+}
+
+func NewHandlerDBStreamer(db *gorm.DB) *HandlerDBStreamer {
+	return &HandlerDBStreamer{DB: db}
+}
@@ -0,0 +1,29 @@
+package streamer
+
+import (
+	"gorm.io/gorm"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+)
+
+// SaveStreamer - создает или изменяет стримера и заменяет его каналы Discord одной транзакцией
+func (h *HandlerDBStreamer) SaveStreamer(streamer *modeldb.Streamer) error {
+	return h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("DiscordChannels").Save(streamer).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("streamer_id = ?", streamer.ID).Delete(&modeldb.DiscordChannel{}).Error; err != nil {
+			return err
+		}
+
+		for idx := range streamer.DiscordChannels {
+			channel := &streamer.DiscordChannels[idx]
+			channel.ID = 0
+			channel.StreamerID = streamer.ID
+			channel.Position = idx
+			if err := tx.Create(channel).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,17 @@
+package modeldb
+
+import "time"
+
+// Comment - связь комментария в группе обсуждения Telegram с сообщением в ветке Discord.
+// Direction outgoing - комментарий из Telegram, incoming - ответ из Discord
+type Comment struct {
+	ID               uint   `gorm:"primaryKey"`
+	Direction        string `gorm:"not null;index"`
+	TelegramChatID   int64  `gorm:"not null"`
+	TelegramMsgID    int    `gorm:"not null"`
+	DiscussionChatID int64  `gorm:"not null;index:idx_comment_discussion"`
+	DiscussionMsgID  int    `gorm:"not null;index:idx_comment_discussion"`
+	DiscordThreadID  string `gorm:"not null;index:idx_comment_discord"`
+	DiscordMsgID     string `gorm:"not null;index:idx_comment_discord"`
+	CreatedAt        time.Time
+}
@@ -0,0 +1,10 @@
+package modeldb
+
+// DiscussionPost - автоматическая копия поста канала в группе обсуждения, под которой пишутся комментарии
+type DiscussionPost struct {
+	ID               uint  `gorm:"primaryKey"`
+	TelegramChatID   int64 `gorm:"not null;uniqueIndex:idx_discussion_post"`
+	TelegramMsgID    int   `gorm:"not null;uniqueIndex:idx_discussion_post"`
+	DiscussionChatID int64 `gorm:"not null"`
+	DiscussionMsgID  int   `gorm:"not null"`
+}
@@ -0,0 +1,17 @@
+package modeldb
+
+import "time"
+
+// FailedDelivery - пост, который не удалось отправить в канал Discord. Пустой ChannelID означает,
+// что не удалось подключиться к Discord и пост не попал ни в один канал.
+// TelegramMsgIDs - ID всех сообщений поста через запятую, чтобы повторить отправку альбома целиком
+type FailedDelivery struct {
+	ID             uint   `gorm:"primaryKey"`
+	StreamerName   string `gorm:"not null"`
+	ChannelID      string `gorm:"not null;default:''"`
+	TelegramChatID int64  `gorm:"not null"`
+	TelegramMsgID  int    `gorm:"not null"`
+	TelegramMsgIDs string `gorm:"not null"`
+	Error          string `gorm:"not null"`
+	CreatedAt      time.Time
+}
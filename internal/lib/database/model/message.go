@@ -1,11 +1,24 @@
 package modeldb
 
+// Message - связь сообщения Telegram с его копией на площадке. Колонки discord_* сохранены
+// с тех пор, когда единственной площадкой был Discord, и хранят ID сообщений любой площадки.
+// Direction отличает копии постов Telegram от сообщений, пришедших в Telegram из обратного моста
 type Message struct {
-	ID                   uint   `gorm:"primaryKey"`
-	MainPost             bool   `gorm:"not null"`
-	ChannelID            string `gorm:"not null"`
-	TelegramMsgID        int    `gorm:"not null"`
-	DiscordMsgID         string `gorm:"not null"`
-	TelegramAttachmentID string `gorm:"default:null"`
-	DiscordAttachmentID  string `gorm:"default:null"`
+	ID                      uint   `gorm:"primaryKey"`
+	Platform                string `gorm:"not null;default:discord;index"`
+	Direction               string `gorm:"not null;default:outgoing;index"`
+	MainPost                bool   `gorm:"not null"`
+	ChannelID               string `gorm:"not null"`
+	TelegramChatID          int64  `gorm:"not null;default:0;index"`
+	TelegramMsgID           int    `gorm:"not null"`
+	DestinationMsgID        string `gorm:"column:discord_msg_id;not null"`
+	TelegramAttachmentID    string `gorm:"default:null"`
+	DestinationAttachmentID string `gorm:"column:discord_attachment_id;default:null"`
 }
+
+const (
+	// DirectionOutgoing - пост Telegram, скопированный на площадку
+	DirectionOutgoing = "outgoing"
+	// DirectionIncoming - сообщение площадки, скопированное в канал Telegram
+	DirectionIncoming = "incoming"
+)
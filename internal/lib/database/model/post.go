@@ -0,0 +1,22 @@
+package modeldb
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Post - содержимое поста для лент RSS, Atom и JSON Feed; удаленные посты помечаются, а не стираются
+type Post struct {
+	ID             uint   `gorm:"primaryKey"`
+	StreamerName   string `gorm:"not null;index"`
+	TelegramChatID int64  `gorm:"not null;uniqueIndex:idx_post_telegram"`
+	TelegramMsgID  int    `gorm:"not null;uniqueIndex:idx_post_telegram"`
+	Text           string `gorm:"not null;default:''"`
+	HTML           string `gorm:"not null;default:''"`
+	Media          string `gorm:"not null;default:'[]'"`
+	Link           string `gorm:"default:null"`
+	RepostFrom     string `gorm:"default:null"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
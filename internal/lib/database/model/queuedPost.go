@@ -0,0 +1,16 @@
+package modeldb
+
+import "time"
+
+// QueuedPost - пост, опубликованный в Telegram во время паузы и ожидающий повторной отправки.
+// Пустой ChannelID означает паузу всего стримера, иначе пост ждет только указанный канал Discord.
+// Payload - пост площадки в JSON вместе с вложениями, потому что Bot API не отдает старые сообщения
+type QueuedPost struct {
+	ID             uint   `gorm:"primaryKey"`
+	StreamerName   string `gorm:"not null;index:idx_queued_post_target"`
+	ChannelID      string `gorm:"not null;default:'';index:idx_queued_post_target"`
+	TelegramChatID int64  `gorm:"not null;index:idx_queued_post_telegram"`
+	TelegramMsgID  int    `gorm:"not null;index:idx_queued_post_telegram"`
+	Payload        string `gorm:"not null"`
+	CreatedAt      time.Time
+}
@@ -0,0 +1,16 @@
+package modeldb
+
+import "time"
+
+// ScheduledPost - отложенная отправка поста в канал Discord: задержка канала или команда /schedule.
+// Payload - пост площадки в JSON вместе с вложениями
+type ScheduledPost struct {
+	ID             uint      `gorm:"primaryKey"`
+	StreamerName   string    `gorm:"not null"`
+	ChannelID      string    `gorm:"not null"`
+	TelegramChatID int64     `gorm:"not null;index:idx_scheduled_post_telegram"`
+	TelegramMsgID  int       `gorm:"not null;index:idx_scheduled_post_telegram"`
+	Payload        string    `gorm:"not null"`
+	SendAt         time.Time `gorm:"not null;index"`
+	CreatedAt      time.Time
+}
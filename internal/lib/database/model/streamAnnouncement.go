@@ -0,0 +1,16 @@
+package modeldb
+
+import "time"
+
+type StreamAnnouncement struct {
+	ID           uint      `gorm:"primaryKey"`
+	StreamerName string    `gorm:"not null;index"`
+	StreamID     string    `gorm:"not null"`
+	ChannelID    string    `gorm:"not null"`
+	DiscordMsgID string    `gorm:"not null"`
+	Title        string    `gorm:"default:null"`
+	GameName     string    `gorm:"default:null"`
+	PeakViewers  int       `gorm:"not null;default:0"`
+	StartedAt    time.Time `gorm:"not null"`
+	EndedAt      *time.Time
+}
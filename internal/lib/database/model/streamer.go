@@ -0,0 +1,33 @@
+package modeldb
+
+import "slm-bot-publisher/internal/core/model"
+
+// Streamer - стример и его настройки. Настройки площадок, Twitch и мостов хранятся в JSON,
+// потому что их разбирают сами сервисы, а искать по ним не нужно
+type Streamer struct {
+	ID                uint                  `gorm:"primaryKey"`
+	Name              string                `gorm:"not null;uniqueIndex"`
+	TelegramChannelID int64                 `gorm:"not null;uniqueIndex"`
+	DiscordBotToken   string                `gorm:"not null"`
+	DiscordChannels   []DiscordChannel      `gorm:"constraint:OnDelete:CASCADE"`
+	Destinations      []model.Destination   `gorm:"serializer:json"`
+	Twitch            *model.TwitchSettings `gorm:"serializer:json"`
+	ReverseBridge     *model.ReverseBridge  `gorm:"serializer:json"`
+	CommentBridge     *model.CommentBridge  `gorm:"serializer:json"`
+	Rewrite           []model.RewriteRule   `gorm:"serializer:json"`
+	Paused            bool                  `gorm:"not null;default:false"`
+	PauseMode         string                `gorm:"not null;default:''"`
+}
+
+// DiscordChannel - канал Discord стримера. Position сохраняет порядок каналов из настроек
+type DiscordChannel struct {
+	ID         uint                `gorm:"primaryKey"`
+	StreamerID uint                `gorm:"not null;index"`
+	Position   int                 `gorm:"not null"`
+	ChannelID  string              `gorm:"not null"`
+	Prefix     string              `gorm:"not null;default:''"`
+	Rules      []model.RouteRule   `gorm:"serializer:json"`
+	Rewrite    []model.RewriteRule `gorm:"serializer:json"`
+	Paused     bool                `gorm:"not null;default:false"`
+	Delay      string              `gorm:"not null;default:''"`
+}
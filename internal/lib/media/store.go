@@ -0,0 +1,67 @@
+package media
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slm-bot-publisher/internal/core/model"
+	"strings"
+)
+
+// Store - хранилище вложений на диске, раздаваемых встроенным HTTP сервером по публичным ссылкам.
+// Нужно площадкам, которые принимают медиа только ссылкой: так в ссылках не оказывается токен бота Telegram
+type Store struct {
+	dir       string
+	publicURL string
+}
+
+func NewStore(dir, publicURL string) *Store {
+	return &Store{
+		dir:       dir,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+// Enabled - проверяет, задан ли публичный адрес, по которому доступны вложения
+func (s *Store) Enabled() bool {
+	return s != nil && s.publicURL != ""
+}
+
+// Save - сохраняет вложение и возвращает публичную ссылку на него
+func (s *Store) Save(attachment model.Attachment) (string, error) {
+	if !s.Enabled() {
+		return "", fmt.Errorf("публичный адрес для вложений не настроен")
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", fmt.Errorf("ошибка создания директории вложений: %v", err)
+	}
+
+	// Имя по хэшу содержимого исключает дубли и не позволяет перебором найти чужие файлы
+	hash := sha256.Sum256(attachment.Data)
+	fileName := hex.EncodeToString(hash[:]) + strings.ToLower(filepath.Ext(attachment.Name))
+	filePath := filepath.Join(s.dir, fileName)
+
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		if err = os.WriteFile(filePath, attachment.Data, 0644); err != nil {
+			return "", fmt.Errorf("ошибка сохранения вложения: %v", err)
+		}
+	}
+
+	return s.publicURL + "/media/" + fileName, nil
+}
+
+// ServeHTTP - раздает сохраненные вложения без листинга директории
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fileName := filepath.Base(strings.TrimPrefix(r.URL.Path, "/media/"))
+	if fileName == "." || fileName == "/" || strings.HasPrefix(fileName, ".") {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeFile(w, r, filepath.Join(s.dir, fileName))
+}
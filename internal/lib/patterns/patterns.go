@@ -0,0 +1,37 @@
+package patterns
+
+import (
+	"regexp"
+	"sync"
+)
+
+// CacheLimit - сколько скомпилированных выражений хранится одновременно
+const CacheLimit = 1024
+
+// Скомпилированные выражения правил маршрутизации и преобразования текста. Правила меняются во время
+// работы через админку, команды и API, поэтому старые выражения не должны копиться: при переполнении
+// кэш очищается целиком, и выражения действующих правил компилируются заново при следующем посте
+var compiled = struct {
+	sync.Mutex
+	patterns map[string]*regexp.Regexp
+}{patterns: make(map[string]*regexp.Regexp)}
+
+// Compile - возвращает скомпилированное выражение, переиспользуя уже скомпилированные
+func Compile(pattern string) (*regexp.Regexp, error) {
+	compiled.Lock()
+	defer compiled.Unlock()
+
+	if cached, exists := compiled.patterns[pattern]; exists {
+		return cached, nil
+	}
+
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(compiled.patterns) >= CacheLimit {
+		clear(compiled.patterns)
+	}
+	compiled.patterns[pattern] = expression
+	return expression, nil
+}
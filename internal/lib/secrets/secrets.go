@@ -0,0 +1,184 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// EncryptedPrefix - значение зашифровано ключом Keeper
+	EncryptedPrefix = "enc:v1:"
+	// EnvPrefix - значение берется из переменной окружения: env:DISCORD_TOKEN_TEST
+	EnvPrefix = "env:"
+	// FilePrefix - значение читается из файла: file:/run/secrets/discord_test
+	FilePrefix = "file:"
+	// KeySize - длина мастер-ключа в байтах, AES-256
+	KeySize = 32
+)
+
+// Keeper - шифрование секретов конвертом: каждое значение шифруется своим случайным ключом данных,
+// а ключ данных - мастер-ключом. Без мастер-ключа Keeper только разрешает ссылки env: и file:
+type Keeper struct {
+	masterKey []byte
+}
+
+// NewKeeper - создает Keeper с мастер-ключом в base64 из значения или файла; значение важнее файла.
+// Если ключ не задан, шифрование выключено
+func NewKeeper(key, keyFile string) (*Keeper, error) {
+	if key == "" && keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения файла ключа: %v", err)
+		}
+		key = strings.TrimSpace(string(data))
+	}
+	if key == "" {
+		return &Keeper{}, nil
+	}
+
+	masterKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("мастер-ключ должен быть в base64: %v", err)
+	}
+	if len(masterKey) != KeySize {
+		return nil, fmt.Errorf("мастер-ключ должен содержать %d байта, получено %d", KeySize, len(masterKey))
+	}
+	return &Keeper{masterKey: masterKey}, nil
+}
+
+// GenerateKey - возвращает новый случайный мастер-ключ в base64
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Enabled - проверяет, задан ли мастер-ключ
+func (k *Keeper) Enabled() bool {
+	return len(k.masterKey) > 0
+}
+
+// IsPlain - проверяет, что значение хранится открытым текстом, а не зашифровано и не ссылается на окружение
+func IsPlain(value string) bool {
+	return value != "" && !strings.HasPrefix(value, EncryptedPrefix) && !strings.HasPrefix(value, EnvPrefix) && !strings.HasPrefix(value, FilePrefix)
+}
+
+// Seal - шифрует открытое значение. Ссылки и уже зашифрованные значения, а также любые значения
+// без мастер-ключа возвращаются как есть
+func (k *Keeper) Seal(value string) (string, error) {
+	if !k.Enabled() || !IsPlain(value) {
+		return value, nil
+	}
+
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	wrappedKey, err := seal(k.masterKey, dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataKey, []byte(value))
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	return EncryptedPrefix + encoding.EncodeToString(wrappedKey) + ":" + encoding.EncodeToString(ciphertext), nil
+}
+
+// Resolve - возвращает значение секрета: расшифровывает его, читает переменную окружения или файл.
+// Открытое значение возвращается как есть
+func (k *Keeper) Resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, EncryptedPrefix):
+		return k.open(strings.TrimPrefix(value, EncryptedPrefix))
+	case strings.HasPrefix(value, EnvPrefix):
+		name := strings.TrimPrefix(value, EnvPrefix)
+		resolved, exists := os.LookupEnv(name)
+		if !exists {
+			return "", fmt.Errorf("переменная окружения %s не задана", name)
+		}
+		return resolved, nil
+	case strings.HasPrefix(value, FilePrefix):
+		data, err := os.ReadFile(strings.TrimPrefix(value, FilePrefix))
+		if err != nil {
+			return "", fmt.Errorf("ошибка чтения секрета из файла: %v", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	default:
+		return value, nil
+	}
+}
+
+func (k *Keeper) open(value string) (string, error) {
+	if !k.Enabled() {
+		return "", fmt.Errorf("значение зашифровано, но мастер-ключ не задан")
+	}
+
+	wrappedPart, ciphertextPart, found := strings.Cut(value, ":")
+	if !found {
+		return "", fmt.Errorf("некорректный формат зашифрованного значения")
+	}
+	encoding := base64.RawURLEncoding
+	wrappedKey, err := encoding.DecodeString(wrappedPart)
+	if err != nil {
+		return "", fmt.Errorf("некорректный формат зашифрованного значения: %v", err)
+	}
+	ciphertext, err := encoding.DecodeString(ciphertextPart)
+	if err != nil {
+		return "", fmt.Errorf("некорректный формат зашифрованного значения: %v", err)
+	}
+
+	dataKey, err := open(k.masterKey, wrappedKey)
+	if err != nil {
+		return "", fmt.Errorf("ошибка расшифровки ключа данных, возможно, мастер-ключ другой: %v", err)
+	}
+	plaintext, err := open(dataKey, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("ошибка расшифровки значения: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// seal - шифрует AES-GCM и возвращает nonce вместе с шифротекстом
+func seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, data []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("шифротекст слишком короткий")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,147 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestKeeper(t *testing.T) *Keeper {
+	t.Helper()
+
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keeper, err := NewKeeper(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keeper
+}
+
+func TestSealResolve(t *testing.T) {
+	keeper := newTestKeeper(t)
+	t.Setenv("SECRETS_TEST_TOKEN", "env-token")
+	secretFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secretFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		value     string
+		encrypted bool
+		want      string
+	}{
+		{name: "открытое значение", value: "discord-token", encrypted: true, want: "discord-token"},
+		{name: "ссылка env", value: "env:SECRETS_TEST_TOKEN", want: "env-token"},
+		{name: "ссылка file", value: "file:" + secretFile, want: "file-token"},
+		{name: "пустое значение", value: "", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sealed, err := keeper.Seal(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encrypted := strings.HasPrefix(sealed, EncryptedPrefix); encrypted != test.encrypted {
+				t.Fatalf("Seal(%q) = %q, шифрование ожидалось: %v", test.value, sealed, test.encrypted)
+			}
+			if !test.encrypted && sealed != test.value {
+				t.Fatalf("ссылка и пустое значение должны сохраняться как есть: %q", sealed)
+			}
+
+			resolved, err := keeper.Resolve(sealed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resolved != test.want {
+				t.Fatalf("Resolve = %q, ожидалось %q", resolved, test.want)
+			}
+		})
+	}
+}
+
+func TestSealTwiceDiffers(t *testing.T) {
+	keeper := newTestKeeper(t)
+
+	first, _ := keeper.Seal("token")
+	second, _ := keeper.Seal("token")
+	if first == second {
+		t.Fatal("каждое шифрование должно использовать свой ключ данных")
+	}
+
+	resealed, _ := keeper.Seal(first)
+	if resealed != first {
+		t.Fatal("зашифрованное значение не должно шифроваться повторно")
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	keeper := newTestKeeper(t)
+	sealed, err := keeper.Seal("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		keeper *Keeper
+		value  string
+	}{
+		{name: "другой мастер-ключ", keeper: newTestKeeper(t), value: sealed},
+		{name: "без мастер-ключа", keeper: &Keeper{}, value: sealed},
+		{name: "поврежденное значение", keeper: keeper, value: EncryptedPrefix + "broken"},
+		{name: "нет переменной окружения", keeper: keeper, value: "env:SECRETS_TEST_MISSING"},
+		{name: "нет файла", keeper: keeper, value: "file:" + filepath.Join(t.TempDir(), "missing")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if resolved, err := test.keeper.Resolve(test.value); err == nil {
+				t.Fatalf("ожидалась ошибка, получено %q", resolved)
+			}
+		})
+	}
+}
+
+func TestNewKeeper(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "master.key")
+	if err = os.WriteFile(keyFile, []byte(key+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		keyFile string
+		enabled bool
+		wantErr bool
+	}{
+		{name: "ключ не задан", enabled: false},
+		{name: "ключ в значении", key: key, enabled: true},
+		{name: "ключ в файле", keyFile: keyFile, enabled: true},
+		{name: "не base64", key: "not base64!", wantErr: true},
+		{name: "короткий ключ", key: "c2hvcnQ=", wantErr: true},
+		{name: "нет файла ключа", keyFile: filepath.Join(t.TempDir(), "missing"), wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keeper, err := NewKeeper(test.key, test.keyFile)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ошибка %v, ожидалась: %v", err, test.wantErr)
+			}
+			if err == nil && keeper.Enabled() != test.enabled {
+				t.Fatalf("Enabled = %v, ожидалось %v", keeper.Enabled(), test.enabled)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"slm-bot-publisher/logging"
+	"time"
+)
+
+// Server - общий HTTP сервер бота, на котором регистрируются обработчики разных сервисов
+type Server struct {
+	addr string
+	mux  *http.ServeMux
+}
+
+func NewServer(addr string) *Server {
+	return &Server{
+		addr: addr,
+		mux:  http.NewServeMux(),
+	}
+}
+
+// Handle - регистрирует обработчик по шаблону пути
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Enabled - проверяет, задан ли адрес для запуска сервера
+func (s *Server) Enabled() bool {
+	return s.addr != ""
+}
+
+// Start - запускает сервер в отдельной горутине
+func (s *Server) Start() {
+	if !s.Enabled() {
+		return
+	}
+
+	httpServer := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		logging.Log("HTTP", logrus.InfoLevel, fmt.Sprintf("HTTP сервер запущен на %s", s.addr))
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Log("HTTP", logrus.ErrorLevel, fmt.Sprintf("Ошибка работы HTTP сервера: %v", err))
+		}
+	}()
+}
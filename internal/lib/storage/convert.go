@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"slm-bot-publisher/internal/core/model"
+	modeldb "slm-bot-publisher/internal/lib/database/model"
+)
+
+func toStreamer(record modeldb.Streamer) model.Streamer {
+	streamer := model.Streamer{
+		Name:              record.Name,
+		TelegramChannelID: record.TelegramChannelID,
+		DiscordBotToken:   record.DiscordBotToken,
+		Destinations:      record.Destinations,
+		Twitch:            record.Twitch,
+		ReverseBridge:     record.ReverseBridge,
+		CommentBridge:     record.CommentBridge,
+		Rewrite:           record.Rewrite,
+		Paused:            record.Paused,
+		PauseMode:         record.PauseMode,
+	}
+
+	for _, channel := range record.DiscordChannels {
+		streamer.DiscordChannels = append(streamer.DiscordChannels, model.DiscordChannel{
+			ChannelID: channel.ChannelID,
+			Prefix:    channel.Prefix,
+			Rules:     channel.Rules,
+			Rewrite:   channel.Rewrite,
+			Paused:    channel.Paused,
+			Delay:     channel.Delay,
+		})
+	}
+	return streamer
+}
+
+func toRecord(streamer model.Streamer, id uint) modeldb.Streamer {
+	record := modeldb.Streamer{
+		ID:                id,
+		Name:              streamer.Name,
+		TelegramChannelID: streamer.TelegramChannelID,
+		DiscordBotToken:   streamer.DiscordBotToken,
+		Destinations:      streamer.Destinations,
+		Twitch:            streamer.Twitch,
+		ReverseBridge:     streamer.ReverseBridge,
+		CommentBridge:     streamer.CommentBridge,
+		Rewrite:           streamer.Rewrite,
+		Paused:            streamer.Paused,
+		PauseMode:         streamer.PauseMode,
+	}
+
+	for _, channel := range streamer.DiscordChannels {
+		record.DiscordChannels = append(record.DiscordChannels, modeldb.DiscordChannel{
+			ChannelID: channel.ChannelID,
+			Prefix:    channel.Prefix,
+			Rules:     channel.Rules,
+			Rewrite:   channel.Rewrite,
+			Paused:    channel.Paused,
+			Delay:     channel.Delay,
+		})
+	}
+	return record
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/secrets"
+)
+
+// ReadFile - читает стримеров из JSON в формате прежнего файла STREAMER_DATA_FILE
+func ReadFile(path string) ([]model.Streamer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var streamers []model.Streamer
+	if err = json.Unmarshal(data, &streamers); err != nil {
+		return nil, fmt.Errorf("ошибка расшифровки файла стримеров: %v", err)
+	}
+	return streamers, nil
+}
+
+// Import - добавляет стримеров из JSON; стримеры с уже известными именами заменяются.
+// Возвращает число добавленных и замененных стримеров
+func (s *Storage) Import(streamers []model.Streamer) (int, int, error) {
+	var added, replaced int
+	for _, streamer := range streamers {
+		if s.GetStreamerByName(streamer.Name) == nil {
+			if err := s.Add(streamer); err != nil {
+				return added, replaced, err
+			}
+			added++
+			continue
+		}
+
+		if err := s.Update(streamer.Name, func(current *model.Streamer) { *current = streamer }); err != nil {
+			return added, replaced, err
+		}
+		replaced++
+	}
+	return added, replaced, nil
+}
+
+// Export - возвращает стримеров в JSON того же формата, что принимает Import.
+// Токены выгружаются в том виде, в каком хранятся в базе, то есть зашифрованными или ссылками
+func (s *Storage) Export() ([]byte, error) {
+	s.mutex.RLock()
+	streamers := append([]model.Streamer(nil), s.streamers...)
+	for idx := range streamers {
+		streamers[idx].DiscordBotToken = s.storedTokens[idx]
+	}
+	s.mutex.RUnlock()
+
+	return json.MarshalIndent(streamers, "", "  ")
+}
+
+// SealFile - шифрует открытые токены ботов в JSON-файле стримеров и перезаписывает его.
+// Возвращает число зашифрованных токенов
+func SealFile(path string, keeper *secrets.Keeper) (int, error) {
+	if !keeper.Enabled() {
+		return 0, fmt.Errorf("мастер-ключ не задан")
+	}
+
+	streamers, err := ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var sealed int
+	for idx := range streamers {
+		if !secrets.IsPlain(streamers[idx].DiscordBotToken) {
+			continue
+		}
+		if streamers[idx].DiscordBotToken, err = keeper.Seal(streamers[idx].DiscordBotToken); err != nil {
+			return 0, err
+		}
+		sealed++
+	}
+
+	data, err := json.MarshalIndent(streamers, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	// Файл заменяется целиком, чтобы при сбое не остался наполовину записанный конфиг
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	tmpPath := path + ".tmp"
+	if err = os.WriteFile(tmpPath, data, info.Mode().Perm()); err != nil {
+		return 0, err
+	}
+	return sealed, os.Rename(tmpPath, path)
+}
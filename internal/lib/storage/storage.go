@@ -1,38 +1,265 @@
 package storage
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/sirupsen/logrus"
-	"io/ioutil"
+	"os"
 	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/database/handlers/streamer"
+	"slm-bot-publisher/internal/lib/secrets"
 	"slm-bot-publisher/logging"
+	"sync"
 )
 
+// Storage - стримеры из базы данных. Список держится в памяти, методы возвращают копии стримеров,
+// а изменения выполняются через Update, Add и Remove и сразу сохраняются в базу.
+// Токены ботов в памяти расшифрованы, а в базе хранятся зашифрованными или ссылками env: и file:
 type Storage struct {
-	Streamers []model.Streamer
+	streamers []model.Streamer
+	// ids - ID записей стримеров в базе по индексу в streamers
+	ids []uint
+	// storedTokens - токены ботов в том виде, в каком они записаны в базу, по индексу в streamers
+	storedTokens []string
+	// byTelegramID - индекс стримера в streamers по ID канала Telegram
+	byTelegramID map[int64]int
+	handler      *streamer.HandlerDBStreamer
+	keeper       *secrets.Keeper
+	mutex        sync.RWMutex
 }
 
-func NewStorage(dataFile string) *Storage {
-	data, err := ioutil.ReadFile(dataFile)
+// NewStorage - загружает стримеров из базы. Если база пуста, а legacyFile указывает на файл
+// стримеров из прежних версий, стримеры переносятся из него. Если задан мастер-ключ,
+// открытые токены в базе шифруются при загрузке
+func NewStorage(handler *streamer.HandlerDBStreamer, keeper *secrets.Keeper, legacyFile string) *Storage {
+	s := &Storage{handler: handler, keeper: keeper}
+	if err := s.load(); err != nil {
+		logging.Log("Система", logrus.PanicLevel, fmt.Sprintf("Ошибка загрузки стримеров из базы: %v", err))
+	}
+
+	if keeper.Enabled() {
+		if err := s.sealTokens(); err != nil {
+			logging.Log("Система", logrus.PanicLevel, fmt.Sprintf("Ошибка шифрования токенов в базе: %v", err))
+		}
+	} else {
+		logging.Log("Система", logrus.WarnLevel, "Мастер-ключ не задан, токены ботов хранятся в базе без шифрования")
+	}
+
+	if len(s.streamers) == 0 && legacyFile != "" {
+		if _, err := os.Stat(legacyFile); err == nil {
+			streamers, err := ReadFile(legacyFile)
+			if err != nil {
+				logging.Log("Система", logrus.PanicLevel, fmt.Sprintf("Ошибка чтения файла стримеров: %v", err))
+			}
+			if _, _, err = s.Import(streamers); err != nil {
+				logging.Log("Система", logrus.PanicLevel, fmt.Sprintf("Ошибка переноса стримеров в базу: %v", err))
+			}
+			logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Стримеры перенесены в базу из файла %s: %d", legacyFile, len(streamers)))
+		}
+	}
+
+	return s
+}
+
+// load - перечитывает стримеров из базы
+func (s *Storage) load() error {
+	records, err := s.handler.GetStreamers()
 	if err != nil {
-		logging.Log("Система", logrus.PanicLevel, fmt.Sprintf("Ошибка загрузки файла стримеров: %v", err))
+		return err
 	}
 
-	var streamers []model.Streamer
-	err = json.Unmarshal(data, &streamers)
+	s.streamers = make([]model.Streamer, 0, len(records))
+	s.ids = make([]uint, 0, len(records))
+	s.storedTokens = make([]string, 0, len(records))
+	for _, record := range records {
+		streamer := toStreamer(record)
+		streamer.DiscordBotToken = s.resolveToken(streamer.Name, record.DiscordBotToken)
+
+		s.streamers = append(s.streamers, streamer)
+		s.ids = append(s.ids, record.ID)
+		s.storedTokens = append(s.storedTokens, record.DiscordBotToken)
+	}
+	s.reindex()
+	return nil
+}
+
+// resolveToken - расшифровывает токен бота. Если это не удалось, стример остается без токена,
+// и сессия Discord для него не создается
+func (s *Storage) resolveToken(name, stored string) string {
+	token, err := s.keeper.Resolve(stored)
 	if err != nil {
-		logging.Log("Система", logrus.PanicLevel, fmt.Sprintf("Ошибка расшифровки файла стримеров: %v", err))
+		logging.Log("Система", logrus.ErrorLevel, fmt.Sprintf("Ошибка получения токена бота стримера %s: %v", name, err))
+		return ""
 	}
+	logging.RegisterSecret(token)
+	return token
+}
 
-	return &Storage{Streamers: streamers}
+// storeToken - возвращает токен для записи в базу: прежний, если токен не менялся, иначе зашифрованный
+func (s *Storage) storeToken(previous, previousStored, token string) (string, error) {
+	if token == previous && previousStored != "" {
+		return previousStored, nil
+	}
+	return s.keeper.Seal(token)
 }
 
-func (s *Storage) GetStreamerByTelegramID(telegramID int64) *model.Streamer {
-	for _, streamer := range s.Streamers {
-		if streamer.TelegramChannelID == telegramID {
-			return &streamer
+// sealTokens - шифрует токены, записанные в базу открытым текстом
+func (s *Storage) sealTokens() error {
+	var sealed int
+	for idx, stored := range s.storedTokens {
+		if !secrets.IsPlain(stored) {
+			continue
+		}
+
+		record := toRecord(s.streamers[idx], s.ids[idx])
+		var err error
+		if record.DiscordBotToken, err = s.keeper.Seal(stored); err != nil {
+			return err
+		}
+		if err = s.handler.SaveStreamer(&record); err != nil {
+			return fmt.Errorf("ошибка сохранения стримера %s: %v", s.streamers[idx].Name, err)
 		}
+		s.storedTokens[idx] = record.DiscordBotToken
+		sealed++
+	}
+
+	if sealed > 0 {
+		logging.Log("Система", logrus.InfoLevel, fmt.Sprintf("Токены ботов в базе зашифрованы: %d", sealed))
+	}
+	return nil
+}
+
+func (s *Storage) reindex() {
+	s.byTelegramID = make(map[int64]int, len(s.streamers))
+	for idx, streamer := range s.streamers {
+		s.byTelegramID[streamer.TelegramChannelID] = idx
+	}
+}
+
+// All - возвращает копию списка стримеров
+func (s *Storage) All() []model.Streamer {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return append([]model.Streamer(nil), s.streamers...)
+}
+
+func (s *Storage) GetStreamerByTelegramID(telegramID int64) *model.Streamer {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	idx, exists := s.byTelegramID[telegramID]
+	if !exists {
+		return nil
+	}
+	streamer := s.streamers[idx]
+	return &streamer
+}
+
+func (s *Storage) GetStreamerByName(name string) *model.Streamer {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if idx := s.indexOf(name); idx >= 0 {
+		streamer := s.streamers[idx]
+		return &streamer
+	}
+	return nil
+}
+
+// Update - изменяет стримера и сохраняет его в базу. Если сохранить не удалось, изменение отменяется
+func (s *Storage) Update(name string, update func(streamer *model.Streamer)) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	idx := s.indexOf(name)
+	if idx < 0 {
+		return fmt.Errorf("стример %s не найден", name)
+	}
+
+	previous := s.streamers[idx]
+	// Каналы копируются, чтобы не менять их у копий стримера, уже выданных обработчикам
+	s.streamers[idx].DiscordChannels = append([]model.DiscordChannel(nil), previous.DiscordChannels...)
+	update(&s.streamers[idx])
+
+	stored, err := s.storeToken(previous.DiscordBotToken, s.storedTokens[idx], s.streamers[idx].DiscordBotToken)
+	if err != nil {
+		s.streamers[idx] = previous
+		return fmt.Errorf("ошибка шифрования токена стримера %s: %v", name, err)
+	}
+
+	record := toRecord(s.streamers[idx], s.ids[idx])
+	record.DiscordBotToken = stored
+	if err = s.handler.SaveStreamer(&record); err != nil {
+		s.streamers[idx] = previous
+		return fmt.Errorf("ошибка сохранения стримера %s: %v", name, err)
+	}
+
+	// Ссылка или шифротекст, совпадающие с записанными в базу, тоже разрешаются: иначе в памяти останется сама ссылка
+	if stored != s.storedTokens[idx] || !secrets.IsPlain(s.streamers[idx].DiscordBotToken) {
+		s.storedTokens[idx] = stored
+		s.streamers[idx].DiscordBotToken = s.resolveToken(s.streamers[idx].Name, stored)
+	}
+	s.reindex()
+	return nil
+}
+
+// Add - добавляет стримера и сохраняет его в базу. Имя и канал Telegram должны быть уникальными
+func (s *Storage) Add(streamer model.Streamer) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.indexOf(streamer.Name) >= 0 {
+		return fmt.Errorf("стример %s уже существует", streamer.Name)
 	}
+	if idx, exists := s.byTelegramID[streamer.TelegramChannelID]; exists {
+		return fmt.Errorf("канал Telegram %d уже подключен к стримеру %s", streamer.TelegramChannelID, s.streamers[idx].Name)
+	}
+
+	stored, err := s.keeper.Seal(streamer.DiscordBotToken)
+	if err != nil {
+		return fmt.Errorf("ошибка шифрования токена стримера %s: %v", streamer.Name, err)
+	}
+
+	record := toRecord(streamer, 0)
+	record.DiscordBotToken = stored
+	if err = s.handler.SaveStreamer(&record); err != nil {
+		return fmt.Errorf("ошибка сохранения стримера %s: %v", streamer.Name, err)
+	}
+
+	streamer.DiscordBotToken = s.resolveToken(streamer.Name, stored)
+	s.streamers = append(s.streamers, streamer)
+	s.ids = append(s.ids, record.ID)
+	s.storedTokens = append(s.storedTokens, stored)
+	s.reindex()
 	return nil
 }
+
+// Remove - удаляет стримера из базы
+func (s *Storage) Remove(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	idx := s.indexOf(name)
+	if idx < 0 {
+		return fmt.Errorf("стример %s не найден", name)
+	}
+
+	if err := s.handler.DeleteStreamer(s.ids[idx]); err != nil {
+		return fmt.Errorf("ошибка удаления стримера %s: %v", name, err)
+	}
+
+	s.streamers = append(s.streamers[:idx:idx], s.streamers[idx+1:]...)
+	s.ids = append(s.ids[:idx:idx], s.ids[idx+1:]...)
+	s.storedTokens = append(s.storedTokens[:idx:idx], s.storedTokens[idx+1:]...)
+	s.reindex()
+	return nil
+}
+
+func (s *Storage) indexOf(name string) int {
+	for idx, streamer := range s.streamers {
+		if streamer.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
@@ -0,0 +1,252 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slm-bot-publisher/internal/core/model"
+	"slm-bot-publisher/internal/lib/database/dbtest"
+	"slm-bot-publisher/internal/lib/database/handlers/streamer"
+	"slm-bot-publisher/internal/lib/secrets"
+	"strings"
+	"testing"
+)
+
+func newTestKeeper(t *testing.T) *secrets.Keeper {
+	t.Helper()
+
+	key, err := secrets.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keeper, err := secrets.NewKeeper(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keeper
+}
+
+func writeStreamers(t *testing.T, path string, streamers []model.Streamer) {
+	t.Helper()
+
+	data, err := json.Marshal(streamers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// storedToken - токен стримера в том виде, в каком он записан в базу
+func storedToken(t *testing.T, handler *streamer.HandlerDBStreamer, name string) string {
+	t.Helper()
+
+	records, err := handler.GetStreamers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if record.Name == name {
+			return record.DiscordBotToken
+		}
+	}
+	t.Fatalf("стример %s не найден в базе", name)
+	return ""
+}
+
+func TestUpdateTokens(t *testing.T) {
+	t.Setenv("STORAGE_TEST_TOKEN", "env-token")
+
+	tests := []struct {
+		name string
+		// initial - токен при добавлении стримера, token - токен, который задает Update
+		initial     string
+		token       string
+		wantToken   string
+		wantStored  string
+		wantSealed  bool
+		wantChanged bool
+	}{
+		{name: "токен не менялся", initial: "plain-token", token: "plain-token", wantToken: "plain-token", wantSealed: true},
+		{name: "новый токен", initial: "plain-token", token: "new-token", wantToken: "new-token", wantSealed: true, wantChanged: true},
+		{name: "ссылка env вместо токена", initial: "plain-token", token: "env:STORAGE_TEST_TOKEN", wantToken: "env-token", wantStored: "env:STORAGE_TEST_TOKEN", wantChanged: true},
+		{name: "та же ссылка env", initial: "env:STORAGE_TEST_TOKEN", token: "env:STORAGE_TEST_TOKEN", wantToken: "env-token", wantStored: "env:STORAGE_TEST_TOKEN"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := dbtest.New(t).StreamerHandlers
+			keeper := newTestKeeper(t)
+			s := NewStorage(handler, keeper, "")
+
+			if err := s.Add(model.Streamer{Name: "Test", TelegramChannelID: -100, DiscordBotToken: test.initial}); err != nil {
+				t.Fatal(err)
+			}
+			before := storedToken(t, handler, "Test")
+
+			if err := s.Update("Test", func(streamer *model.Streamer) { streamer.DiscordBotToken = test.token }); err != nil {
+				t.Fatal(err)
+			}
+
+			if token := s.GetStreamerByName("Test").DiscordBotToken; token != test.wantToken {
+				t.Fatalf("токен в памяти %q, ожидался %q", token, test.wantToken)
+			}
+
+			stored := storedToken(t, handler, "Test")
+			if test.wantStored != "" && stored != test.wantStored {
+				t.Fatalf("в базе %q, ожидалось %q", stored, test.wantStored)
+			}
+			if test.wantSealed && !strings.HasPrefix(stored, secrets.EncryptedPrefix) {
+				t.Fatalf("токен в базе не зашифрован: %q", stored)
+			}
+			if changed := stored != before; changed != test.wantChanged {
+				t.Fatalf("запись токена в базе изменилась: %v, ожидалось %v", changed, test.wantChanged)
+			}
+			if resolved, err := keeper.Resolve(stored); err != nil || resolved != test.wantToken {
+				t.Fatalf("токен из базы разрешается в %q (%v), ожидался %q", resolved, err, test.wantToken)
+			}
+		})
+	}
+}
+
+func TestUpdateKeepsTokenOnOtherChanges(t *testing.T) {
+	handler := dbtest.New(t).StreamerHandlers
+	s := NewStorage(handler, newTestKeeper(t), "")
+
+	if err := s.Add(model.Streamer{Name: "Test", TelegramChannelID: -100, DiscordBotToken: "plain-token"}); err != nil {
+		t.Fatal(err)
+	}
+	before := storedToken(t, handler, "Test")
+
+	if err := s.Update("Test", func(streamer *model.Streamer) { streamer.TelegramChannelID = -200 }); err != nil {
+		t.Fatal(err)
+	}
+
+	if stored := storedToken(t, handler, "Test"); stored != before {
+		t.Fatal("токен не менялся, но в базе перешифрован")
+	}
+	if streamer := s.GetStreamerByTelegramID(-200); streamer == nil || streamer.DiscordBotToken != "plain-token" {
+		t.Fatalf("стример не найден по новому каналу или токен потерян: %+v", streamer)
+	}
+}
+
+func TestLegacyFileImport(t *testing.T) {
+	legacyFile := filepath.Join(t.TempDir(), "streamers.json")
+	writeStreamers(t, legacyFile, []model.Streamer{
+		{Name: "First", TelegramChannelID: -100, DiscordBotToken: "first-token"},
+		{Name: "Second", TelegramChannelID: -200, DiscordBotToken: "second-token"},
+	})
+
+	tests := []struct {
+		name      string
+		existing  []model.Streamer
+		wantNames []string
+	}{
+		{name: "пустая база", wantNames: []string{"First", "Second"}},
+		{name: "в базе уже есть стримеры", existing: []model.Streamer{{Name: "Existing", TelegramChannelID: -300}}, wantNames: []string{"Existing"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := dbtest.New(t).StreamerHandlers
+			keeper := newTestKeeper(t)
+			if len(test.existing) > 0 {
+				current := NewStorage(handler, keeper, "")
+				if _, _, err := current.Import(test.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s := NewStorage(handler, keeper, legacyFile)
+
+			var names []string
+			for _, streamer := range s.All() {
+				names = append(names, streamer.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(test.wantNames, ",") {
+				t.Fatalf("стримеры %v, ожидались %v", names, test.wantNames)
+			}
+
+			if len(test.existing) == 0 {
+				if token := s.GetStreamerByName("First").DiscordBotToken; token != "first-token" {
+					t.Fatalf("токен в памяти %q", token)
+				}
+				if stored := storedToken(t, handler, "First"); !strings.HasPrefix(stored, secrets.EncryptedPrefix) {
+					t.Fatalf("перенесенный токен не зашифрован: %q", stored)
+				}
+			}
+		})
+	}
+}
+
+func TestNewStorageSealsPlainTokens(t *testing.T) {
+	handler := dbtest.New(t).StreamerHandlers
+	plain := NewStorage(handler, &secrets.Keeper{}, "")
+	if err := plain.Add(model.Streamer{Name: "Test", TelegramChannelID: -100, DiscordBotToken: "plain-token"}); err != nil {
+		t.Fatal(err)
+	}
+	if stored := storedToken(t, handler, "Test"); stored != "plain-token" {
+		t.Fatalf("без мастер-ключа токен должен храниться как есть: %q", stored)
+	}
+
+	s := NewStorage(handler, newTestKeeper(t), "")
+	if stored := storedToken(t, handler, "Test"); !strings.HasPrefix(stored, secrets.EncryptedPrefix) {
+		t.Fatalf("токен не зашифрован при загрузке: %q", stored)
+	}
+	if token := s.GetStreamerByName("Test").DiscordBotToken; token != "plain-token" {
+		t.Fatalf("токен в памяти %q", token)
+	}
+}
+
+func TestSealFile(t *testing.T) {
+	keeper := newTestKeeper(t)
+	alreadySealed, err := keeper.Seal("sealed-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		keeper     *secrets.Keeper
+		token      string
+		wantSealed int
+		wantErr    bool
+		wantSame   bool
+	}{
+		{name: "открытый токен", keeper: keeper, token: "plain-token", wantSealed: 1},
+		{name: "ссылка env", keeper: keeper, token: "env:STORAGE_TEST_TOKEN", wantSame: true},
+		{name: "уже зашифрован", keeper: keeper, token: alreadySealed, wantSame: true},
+		{name: "без мастер-ключа", keeper: &secrets.Keeper{}, token: "plain-token", wantErr: true, wantSame: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "streamers.json")
+			writeStreamers(t, path, []model.Streamer{{Name: "Test", TelegramChannelID: -100, DiscordBotToken: test.token}})
+
+			sealed, err := SealFile(path, test.keeper)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ошибка %v, ожидалась: %v", err, test.wantErr)
+			}
+			if sealed != test.wantSealed {
+				t.Fatalf("зашифровано %d, ожидалось %d", sealed, test.wantSealed)
+			}
+
+			streamers, err := ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token := streamers[0].DiscordBotToken
+			if test.wantSame {
+				if token != test.token {
+					t.Fatalf("токен в файле изменился: %q", token)
+				}
+				return
+			}
+			if resolved, err := keeper.Resolve(token); err != nil || resolved != test.token || token == test.token {
+				t.Fatalf("токен в файле %q разрешается в %q (%v)", token, resolved, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+// FailureLimit - сколько последних ошибок хранится в памяти
+const FailureLimit = 50
+
+// Failure - ошибка из лога, доступная в админке без чтения файлов
+type Failure struct {
+	Time    time.Time
+	Module  string
+	Message string
+}
+
+// failures - кольцевой буфер последних ошибок
+var failures = struct {
+	sync.Mutex
+	entries []Failure
+	next    int
+}{entries: make([]Failure, 0, FailureLimit)}
+
+func recordFailure(module, message string) {
+	failures.Lock()
+	defer failures.Unlock()
+
+	failure := Failure{Time: time.Now(), Module: module, Message: message}
+	if len(failures.entries) < FailureLimit {
+		failures.entries = append(failures.entries, failure)
+	} else {
+		failures.entries[failures.next] = failure
+	}
+	failures.next = (failures.next + 1) % FailureLimit
+}
+
+// RecentFailures - возвращает до limit последних ошибок, начиная с самой новой
+func RecentFailures(limit int) []Failure {
+	failures.Lock()
+	defer failures.Unlock()
+
+	count := min(limit, len(failures.entries))
+	recent := make([]Failure, 0, count)
+	for idx := 0; idx < count; idx++ {
+		position := (failures.next - 1 - idx + FailureLimit) % FailureLimit
+		recent = append(recent, failures.entries[position])
+	}
+	return recent
+}
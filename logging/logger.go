@@ -55,6 +55,14 @@ func Log(module string, level logrus.Level, message string) {
 	entry := log.WithFields(logrus.Fields{
 		"module": module,
 	})
+	message = Redact(message)
+
+	if level <= logrus.ErrorLevel {
+		recordFailure(module, message)
+	}
+	if log.IsLevelEnabled(level) {
+		recordEntry(Entry{Time: time.Now(), Level: level.String(), Module: module, Message: message})
+	}
 
 	switch level {
 	case logrus.DebugLevel:
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RedactedSecret - чем заменяются секреты в сообщениях лога
+const RedactedSecret = "***"
+
+// Токены ботов Discord и Telegram, попадающие в тексты ошибок, в том числе в ссылках на файлы
+var tokenRegexp = regexp.MustCompile(`[\w-]{24,}\.[\w-]{6}\.[\w-]{27,}|\d{6,12}:[\w-]{35}`)
+
+// secrets - известные значения секретов, которые не должны попадать в лог
+var secrets = struct {
+	sync.RWMutex
+	values map[string]struct{}
+}{values: make(map[string]struct{})}
+
+// RegisterSecret - запоминает секрет, чтобы скрывать его во всех сообщениях, проходящих через Log
+func RegisterSecret(secret string) {
+	if len(secret) < 8 {
+		return
+	}
+
+	secrets.Lock()
+	defer secrets.Unlock()
+	secrets.values[secret] = struct{}{}
+}
+
+// Redact - заменяет известные секреты и похожие на токены ботов строки на RedactedSecret
+func Redact(message string) string {
+	secrets.RLock()
+	for secret := range secrets.values {
+		message = strings.ReplaceAll(message, secret, RedactedSecret)
+	}
+	secrets.RUnlock()
+
+	return tokenRegexp.ReplaceAllString(message, RedactedSecret)
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+// TailLimit - сколько последних записей лога хранится в памяти для панели управления
+const TailLimit = 200
+
+// Entry - запись лога для просмотра в реальном времени
+type Entry struct {
+	Time    time.Time
+	Level   string
+	Module  string
+	Message string
+}
+
+// tail - кольцевой буфер последних записей и подписчики на новые
+var tail = struct {
+	sync.Mutex
+	entries     []Entry
+	next        int
+	subscribers map[chan Entry]struct{}
+}{entries: make([]Entry, 0, TailLimit), subscribers: make(map[chan Entry]struct{})}
+
+func recordEntry(entry Entry) {
+	tail.Lock()
+	defer tail.Unlock()
+
+	if len(tail.entries) < TailLimit {
+		tail.entries = append(tail.entries, entry)
+	} else {
+		tail.entries[tail.next] = entry
+	}
+	tail.next = (tail.next + 1) % TailLimit
+
+	// Медленный подписчик пропускает записи, а не задерживает логирование
+	for subscriber := range tail.subscribers {
+		select {
+		case subscriber <- entry:
+		default:
+		}
+	}
+}
+
+// RecentEntries - возвращает последние записи лога, начиная с самой старой
+func RecentEntries() []Entry {
+	tail.Lock()
+	defer tail.Unlock()
+
+	recent := make([]Entry, 0, len(tail.entries))
+	if len(tail.entries) == TailLimit {
+		recent = append(recent, tail.entries[tail.next:]...)
+		return append(recent, tail.entries[:tail.next]...)
+	}
+	return append(recent, tail.entries...)
+}
+
+// Subscribe - подписывает на новые записи лога. Возвращенную функцию нужно вызвать, чтобы отписаться
+func Subscribe() (<-chan Entry, func()) {
+	subscriber := make(chan Entry, 64)
+
+	tail.Lock()
+	tail.subscribers[subscriber] = struct{}{}
+	tail.Unlock()
+
+	return subscriber, func() {
+		tail.Lock()
+		delete(tail.subscribers, subscriber)
+		tail.Unlock()
+	}
+}